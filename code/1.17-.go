@@ -31,3 +31,7 @@ func isParameterizedType(tt types.Type) bool {
 func isTypeParam(tt types.Type) bool {
 	return false
 }
+
+func isParameterizedSignature(sig *types.Signature) bool {
+	return false
+}
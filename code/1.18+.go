@@ -30,3 +30,7 @@ func isTypeParam(tt types.Type) bool {
 	_, ok := tt.(*types.TypeParam)
 	return ok
 }
+
+func isParameterizedSignature(sig *types.Signature) bool {
+	return sig.TypeParams() != nil || sig.RecvTypeParams() != nil
+}
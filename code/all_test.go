@@ -1,11 +1,21 @@
 package code
 
 import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
 	"go/types"
 	"math/rand"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 
+	"golang.org/x/tools/go/packages"
 	"golang.org/x/tools/go/types/typeutil"
 )
 
@@ -145,3 +155,563 @@ func TestAnalyzeStandardPackage(t *testing.T) {
 		}
 	}
 }
+
+func TestRankedList(t *testing.T) {
+	var rl RankedList
+	rl.TryToInit(3)
+	for i, n := range []int{5, 0, 9, 1, 7, 9, -2, 3} {
+		rl.Push(n, i)
+	}
+	var scores []int
+	for _, item := range rl.Items {
+		scores = append(scores, item.Score)
+	}
+	if len(scores) != 3 || scores[0] != 9 || scores[1] != 9 || scores[2] != 7 {
+		t.Errorf("unexpected scores: %v", scores)
+	}
+	if rl.Items[0].Item != 2 || rl.Items[1].Item != 5 {
+		t.Errorf("items with equal scores should keep their push order: %v", rl.Items)
+	}
+}
+
+func TestStructLayout(t *testing.T) {
+	newVar := func(name string, kind types.BasicKind) *types.Var {
+		return types.NewField(0, nil, name, types.Typ[kind], false)
+	}
+	inner := types.NewStruct([]*types.Var{newVar("x", types.Int32), newVar("y", types.Bool)}, nil)
+	st := types.NewStruct([]*types.Var{
+		newVar("a", types.Bool),
+		newVar("b", types.Int64),
+		types.NewField(0, nil, "inner", inner, true),
+		newVar("c", types.Bool),
+	}, nil)
+
+	sl := structLayout(st, types.SizesFor("gc", "amd64"))
+	if sl.Size != 32 || sl.Align != 8 {
+		t.Fatalf("unexpected size/align: %d/%d", sl.Size, sl.Align)
+	}
+	if len(sl.Fields) != 6 {
+		t.Fatalf("promoted fields should be listed: %d", len(sl.Fields))
+	}
+	if f := sl.Fields[3]; f.Name != "x" || f.Depth != 1 || f.Offset != 16 {
+		t.Errorf("unexpected promoted field: %+v", f)
+	}
+	if sl.Padding != 7+7 {
+		t.Errorf("unexpected padding: %d", sl.Padding)
+	}
+	if sl.OptimalSize != 24 || sl.Wasted() != 8 {
+		t.Errorf("unexpected optimal size: %d", sl.OptimalSize)
+	}
+	if o := sl.OptimalOrder; len(o) != 4 || sl.Fields[o[0]].Name != "b" || sl.Fields[o[1]].Name != "inner" {
+		t.Errorf("unexpected optimal order: %v", o)
+	}
+
+	// Without the sizes of the declaring package, the
+	// architecture of the primary platform is used.
+	small := types.NewStruct([]*types.Var{newVar("a", types.Bool), newVar("b", types.Int64)}, nil)
+	named := types.NewNamed(types.NewTypeName(0, nil, "S", nil), small, nil)
+	tn := &TypeName{Pkg: &Package{}, Named: &TypeInfo{TT: named}}
+	d := &CodeAnalyzer{platforms: []Platform{{"linux", "386"}}}
+	if sl := d.StructLayoutOf(tn); sl == nil || sl.Size != 12 {
+		t.Errorf("unexpected layout for 386: %+v", sl)
+	}
+	d.platforms[0].GOARCH = "amd64"
+	if sl := d.StructLayoutOf(tn); sl == nil || sl.Size != 16 {
+		t.Errorf("unexpected layout for amd64: %+v", sl)
+	}
+}
+
+func TestParseErrorPosition(t *testing.T) {
+	for _, c := range []struct {
+		pos          string
+		filename     string
+		line, column int
+	}{
+		{"/a/b.go:12:5", "/a/b.go", 12, 5},
+		{"/a/b.go:12", "/a/b.go", 12, 0},
+		{"C:/a/b.go:3:1", "C:/a/b.go", 3, 1},
+		{"/a/b.go", "/a/b.go", 0, 0},
+		{"-", "", 0, 0},
+		{"", "", 0, 0},
+	} {
+		p := parseErrorPosition(c.pos)
+		if p.Filename != c.filename || p.Line != c.line || p.Column != c.column {
+			t.Errorf("parseErrorPosition(%q) = %v", c.pos, p)
+		}
+	}
+}
+
+func TestModuleVersionArguments(t *testing.T) {
+	for _, c := range []struct {
+		arg string
+		ok  bool
+	}{
+		{"golang.org/x/mod@v0.20.0", true},
+		{"github.com/a/b@v2.0.0+incompatible", true},
+		{"golang.org/x/mod@latest", false},
+		{"golang.org/x/mod@v0.20", false},
+		{"golang.org/x/mod", false},
+		{"./...", false},
+		{"@v1.0.0", false},
+	} {
+		if _, ok := parseModuleVersionArgument(c.arg); ok != c.ok {
+			t.Errorf("parseModuleVersionArgument(%q): %v", c.arg, ok)
+		}
+	}
+
+	if groups := collectModuleVersionArguments([]string{"a.b/c@v1.0.0", "./..."}); groups != nil {
+		t.Errorf("mixed arguments should not be handled: %v", groups)
+	}
+	if groups := collectModuleVersionArguments([]string{"a.b/c@v1.0.0", "a.b/d@v1.1.0"}); len(groups) != 1 || len(groups[0]) != 2 {
+		t.Errorf("unexpected result: %v", groups)
+	}
+
+	for _, c := range []struct {
+		args   []string
+		groups string
+		labels string
+	}{
+		{[]string{"./..."}, "./...", ""},
+		{[]string{"a.b/c@v1.0.0", "a.b/d@v1.1.0"}, "a.b/c@v1.0.0 a.b/d@v1.1.0", ""},
+		{[]string{"a.b/c@v1.0.0", "a.b/c@v1.0.0"}, "a.b/c@v1.0.0 a.b/c@v1.0.0", ""},
+		{[]string{"a.b/c@v1.0.0", "a.b/c@v1.1.0"}, "a.b/c@v1.0.0|a.b/c@v1.1.0", "v1.0.0|v1.1.0"},
+		{
+			[]string{"a.b/c@v1.0.0", "a.b/d@v0.1.0", "a.b/c@v1.1.0", "a.b/e@v0.1.0", "a.b/e@v0.2.0", "a.b/c@v1.2.0"},
+			"a.b/c@v1.0.0 a.b/d@v0.1.0 a.b/e@v0.1.0|a.b/c@v1.1.0 a.b/d@v0.1.0 a.b/e@v0.2.0|a.b/c@v1.2.0 a.b/d@v0.1.0 a.b/e@v0.2.0",
+			"v1.0.0+v0.1.0|v1.1.0+v0.2.0|v1.2.0+v0.2.0",
+		},
+	} {
+		groups, labels := SplitModuleVersionArguments(c.args)
+		var gs []string
+		for _, g := range groups {
+			gs = append(gs, strings.Join(g, " "))
+		}
+		if got := strings.Join(gs, "|"); got != c.groups {
+			t.Errorf("SplitModuleVersionArguments(%v) groups:\n got: %s\nwant: %s", c.args, got, c.groups)
+		}
+		if got := strings.Join(labels, "|"); got != c.labels {
+			t.Errorf("SplitModuleVersionArguments(%v) labels:\n got: %s\nwant: %s", c.args, got, c.labels)
+		}
+	}
+
+	goflags := os.Getenv("GOFLAGS")
+	env := moduleVersionsEnv()
+	if env2 := moduleVersionsEnv(); strings.Join(env2, " ") != strings.Join(env, " ") {
+		t.Errorf("moduleVersionsEnv should not accumulate: %v vs. %v", env2, env)
+	}
+	if os.Getenv("GOFLAGS") != goflags {
+		t.Errorf("moduleVersionsEnv should not modify the process environments")
+	}
+}
+
+func TestPlatformEnv(t *testing.T) {
+	platforms := resolvePlatforms([]Platform{{GOOS: "windows"}, {GOOS: "linux", GOARCH: "arm64"}})
+	if platforms[0] != (Platform{"windows", runtime.GOARCH}) || platforms[1] != (Platform{"linux", "arm64"}) {
+		t.Fatalf("resolvePlatforms: got %v", platforms)
+	}
+	env := platforms[1].env()
+	if n := len(env); n < 2 || env[n-2] != "GOOS=linux" || env[n-1] != "GOARCH=arm64" {
+		t.Errorf("env: the platform variables should be appended last: %v", env)
+	}
+
+	// A package which only builds on windows is only
+	// matched when the go command is run for windows.
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":         "module example.com/m\n",
+		"w/w_windows.go": "package w\n",
+	}
+	for name, content := range files {
+		filename := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The go commands are run in dir, without changing the working directory.
+	if hasMatchedPackages("example.com/m/w", nil, Platform{"linux", "amd64"}.env(), dir) {
+		t.Errorf("example.com/m/w should not be matched for linux")
+	}
+	if !hasMatchedPackages("example.com/m/w", nil, Platform{"windows", "amd64"}.env(), dir) {
+		t.Errorf("example.com/m/w should be matched for windows")
+	}
+	args, _, err := validateArgumentsAndSetOptions([]string{"example.com/m/w"}, "", nil, Platform{"windows", "amd64"}.env(), dir)
+	if err != nil || len(args) != 1 {
+		t.Errorf("validateArgumentsAndSetOptions: got %v, %v", args, err)
+	}
+}
+
+func TestPlatformOnlyDeclarations(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.go": `package a
+
+type T struct{}
+
+func F() {}
+`,
+		"a_linux.go": `package a
+
+func (T) M() {}
+`,
+		"a_windows.go": `package a
+
+import "io"
+
+// Handle is a windows handle.
+type Handle uintptr
+
+// Reader returns a reader.
+func (h Handle) Reader() io.Reader { return nil }
+
+const (
+	// X is a constant.
+	X, y = 1, 2
+)
+
+func (*T) W() {}
+`,
+	}
+	var platformFiles = [][]string{{"a.go", "a_linux.go"}, {"a.go", "a_windows.go"}, {"a.go"}}
+	var pds = make([]*platformDecls, len(platformFiles))
+	for i, names := range platformFiles {
+		var filenames []string
+		for _, name := range names {
+			filename := filepath.Join(dir, name)
+			if err := os.WriteFile(filename, []byte(files[name]), 0644); err != nil {
+				t.Fatal(err)
+			}
+			filenames = append(filenames, filename)
+		}
+		pds[i] = collectPlatformDecls(filenames)
+	}
+	platforms := []Platform{{"linux", "amd64"}, {"windows", "amd64"}, {"darwin", "arm64"}}
+
+	var got []string
+	for _, decl := range platformOnlyDeclarations(pds, platforms) {
+		got = append(got, decl.Recv+"."+decl.Name)
+		if len(decl.Platforms) != 1 || decl.Platforms[0] != platforms[1] || decl.File != "a_windows.go" {
+			t.Errorf("%s.%s: unexpected platforms or file: %v, %s", decl.Recv, decl.Name, decl.Platforms, decl.File)
+		}
+		switch decl.Name {
+		case "Reader":
+			if decl.Line != 9 || decl.Doc != "Reader returns a reader.\n" || decl.Imports["io"] != "io" ||
+				decl.Declaration != "func (h Handle) Reader() io.Reader" {
+				t.Errorf("Handle.Reader: got %+v", decl)
+			}
+		case "X":
+			if decl.Doc != "X is a constant.\n" || !strings.HasPrefix(decl.Declaration, "const X, y = 1, 2") {
+				t.Errorf("X: got %+v", decl)
+			}
+		}
+	}
+	want := []string{".Handle", ".X", ".y", "Handle.Reader", "T.W"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("platformOnlyDeclarations:\n got: %v\nwant: %v", got, want)
+	}
+}
+
+// newTestPackage parses and type-checks the source files of a package.
+// Type errors are ignored, as they are for ill-typed packages in tolerant mode.
+func newTestPackage(t *testing.T, fset *token.FileSet, dir, path string, files map[string]string, imports ...*Package) *Package {
+	ppkg := &packages.Package{
+		ID:      path,
+		PkgPath: path,
+		Fset:    fset,
+		Imports: make(map[string]*packages.Package),
+		TypesInfo: &types.Info{
+			Types:      make(map[ast.Expr]types.TypeAndValue),
+			Defs:       make(map[*ast.Ident]types.Object),
+			Uses:       make(map[*ast.Ident]types.Object),
+			Implicits:  make(map[ast.Node]types.Object),
+			Selections: make(map[*ast.SelectorExpr]*types.Selection),
+			Scopes:     make(map[ast.Node]*types.Scope),
+		},
+	}
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		filename := filepath.Join(dir, name)
+		if err := os.WriteFile(filename, []byte(files[name]), 0644); err != nil {
+			t.Fatal(err)
+		}
+		f, err := parser.ParseFile(fset, filename, files[name], parser.ParseComments)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ppkg.Name = f.Name.Name
+		ppkg.GoFiles = append(ppkg.GoFiles, filename)
+		ppkg.CompiledGoFiles = append(ppkg.CompiledGoFiles, filename)
+		ppkg.Syntax = append(ppkg.Syntax, f)
+	}
+	importer := make(testImporter)
+	for _, dep := range imports {
+		ppkg.Imports[dep.Path()] = dep.PPkg
+		importer[dep.Path()] = dep.PPkg.Types
+	}
+	conf := types.Config{
+		Importer: importer,
+		Error: func(error) {
+			ppkg.IllTyped = true
+		},
+	}
+	ppkg.Types, _ = conf.Check(path, fset, ppkg.Syntax, ppkg.TypesInfo)
+	return &Package{PPkg: ppkg}
+}
+
+type testImporter map[string]*types.Package
+
+func (ti testImporter) Import(path string) (*types.Package, error) {
+	if p := ti[path]; p != nil {
+		return p, nil
+	}
+	return nil, fmt.Errorf("package %s not found", path)
+}
+
+func TestAnalyzeIllTypedPackage(t *testing.T) {
+	dir := t.TempDir()
+	fset := token.NewFileSet()
+	builtinPkg := newTestPackage(t, fset, dir, "builtin", map[string]string{
+		"builtin.go": `package builtin
+
+type error interface {
+	Error() string
+}
+`,
+	})
+	if err := os.Mkdir(filepath.Join(dir, "p"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	pkg := newTestPackage(t, fset, filepath.Join(dir, "p"), "example.com/p", map[string]string{
+		"p.go": `package p
+
+type I interface {
+	Undefined
+	M()
+	N(x undefined)
+}
+
+type S struct {
+	Missing
+	*missing.Type
+	x undefined
+}
+
+type U missing.Type
+
+var v = undefined
+
+type T struct{}
+
+func (T) M() {}
+
+func (T) N(int) {}
+
+func (T) String() string { return "" }
+
+type Stringer interface {
+	String() string
+}
+`,
+	})
+
+	d := &CodeAnalyzer{tolerant: true}
+	d.builtinPkg = builtinPkg
+	d.packageList = []*Package{builtinPkg, pkg}
+	d.packageTable = map[string]*Package{"builtin": builtinPkg, pkg.Path(): pkg}
+	d.AnalyzePackages(nil)
+
+	var got []string
+	for _, p := range d.PackageProblems(pkg.Path()) {
+		if p.Kind != ProblemKind_Analyze {
+			t.Errorf("unexpected problem: %s", p)
+			continue
+		}
+		got = append(got, fmt.Sprintf("%d: %s", p.Pos.Line, p.Msg))
+	}
+	want := []string{
+		"4: skipped unresolvable embedded interface Undefined",
+		"10: skipped unresolvable embedded field Missing",
+		"11: skipped unresolvable embedded field *missing.Type",
+		"15: skipped unresolvable source type missing.Type of type U",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("problems:\n got: %q\nwant: %q", got, want)
+	}
+	if problems := d.PackageProblems("builtin"); len(problems) > 0 {
+		t.Errorf("the builtin package has problems: %v", problems)
+	}
+
+	// The well-typed declarations are still analyzed.
+	res := pkg.PackageAnalyzeResult
+	tn := res.AllTypeNames
+	var typeT, typeStringer *TypeName
+	for _, n := range tn {
+		switch n.Name() {
+		case "T":
+			typeT = n
+		case "Stringer":
+			typeStringer = n
+		}
+	}
+	if typeT == nil || typeStringer == nil {
+		t.Fatalf("type names T and Stringer should be collected")
+	}
+	if n := len(typeT.Named.AllMethods); n != 3 {
+		t.Errorf("T should have 3 methods, got %d", n)
+	}
+	var implemented bool
+	for _, impl := range typeT.Named.Implements {
+		if impl.Interface.TypeName == typeStringer {
+			implemented = true
+		}
+	}
+	if !implemented {
+		t.Errorf("T should implement Stringer")
+	}
+	if typeStringer.Named.ImplementedBys == nil {
+		t.Errorf("Stringer should be implemented by T")
+	}
+}
+
+func TestDiagnoseImplementation(t *testing.T) {
+	dir := t.TempDir()
+	fset := token.NewFileSet()
+	builtinPkg := newTestPackage(t, fset, dir, "builtin", map[string]string{
+		"builtin.go": "package builtin\n\ntype error interface {\n\tError() string\n}\n",
+	})
+	if err := os.Mkdir(filepath.Join(dir, "p"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	pkg := newTestPackage(t, fset, filepath.Join(dir, "p"), "example.com/p", map[string]string{
+		"p.go": `package p
+
+type I interface {
+	M()
+	N(int) string
+	P()
+	Q()
+}
+
+type A struct{}
+
+func (A) M() {}
+func (A) N(string) string { return "" }
+func (*A) P() {}
+
+type B struct{}
+
+func (B) M() {}
+func (B) N(int) string { return "" }
+func (*B) P() {}
+func (*B) Q() {}
+
+type E struct{}
+`,
+	})
+
+	d := &CodeAnalyzer{}
+	d.builtinPkg = builtinPkg
+	d.packageList = []*Package{builtinPkg, pkg}
+	d.packageTable = map[string]*Package{"builtin": builtinPkg, pkg.Path(): pkg}
+	d.AnalyzePackages(nil)
+
+	typeInfo := func(name string) *TypeInfo {
+		return d.RegisterType(pkg.PPkg.Types.Scope().Lookup(name).Type())
+	}
+	names := func(mismatches []MethodMismatch) string {
+		var list []string
+		for _, m := range mismatches {
+			list = append(list, m.Required.Name())
+		}
+		return strings.Join(list, ",")
+	}
+
+	itf := typeInfo("I")
+	for _, c := range []struct {
+		name                            string
+		missings, wrongs, pointerOnlys  string
+		implemented, pointerImplemented bool
+	}{
+		{"A", "Q", "N", "P", false, false},
+		{"B", "", "", "P,Q", false, true},
+		{"E", "M,N,P,Q", "", "", false, false},
+	} {
+		diag := d.DiagnoseImplementation(typeInfo(c.name), itf)
+		if got := names(diag.Missings); got != c.missings {
+			t.Errorf("%s: missing methods: got %q, want %q", c.name, got, c.missings)
+		}
+		if got := names(diag.WrongSignatures); got != c.wrongs {
+			t.Errorf("%s: methods with wrong signatures: got %q, want %q", c.name, got, c.wrongs)
+		}
+		if got := names(diag.PointerOnlys); got != c.pointerOnlys {
+			t.Errorf("%s: pointer receiver only methods: got %q, want %q", c.name, got, c.pointerOnlys)
+		}
+		if diag.Implemented() != c.implemented || diag.PointerImplemented() != c.pointerImplemented {
+			t.Errorf("%s: implemented: %v, %v", c.name, diag.Implemented(), diag.PointerImplemented())
+		}
+	}
+}
+
+func TestAssignableValues(t *testing.T) {
+	dir := t.TempDir()
+	fset := token.NewFileSet()
+	builtinPkg := newTestPackage(t, fset, dir, "builtin", map[string]string{
+		"builtin.go": "package builtin\n\ntype error interface {\n\tError() string\n}\n",
+	})
+	if err := os.Mkdir(filepath.Join(dir, "p"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	pkg := newTestPackage(t, fset, filepath.Join(dir, "p"), "example.com/p", map[string]string{
+		"p.go": `package p
+
+type H func(int) string
+
+type S []int
+
+type T struct{}
+
+func (T) M(int) string { return "" }
+
+func F(int) string { return "" }
+
+func G(int) {}
+
+var V = func(int) string { return "" }
+
+var L = []int{}
+
+var N S
+`,
+	})
+
+	d := &CodeAnalyzer{}
+	d.builtinPkg = builtinPkg
+	d.packageList = []*Package{builtinPkg, pkg}
+	d.packageTable = map[string]*Package{"builtin": builtinPkg, pkg.Path(): pkg}
+	d.AnalyzePackages(nil)
+
+	assignables := func(name string) string {
+		var names []string
+		for _, v := range d.RegisterType(pkg.PPkg.Types.Scope().Lookup(name).Type()).AssignableValues {
+			if f, ok := v.(*Function); ok && f.IsMethod() {
+				names = append(names, "T."+v.Name())
+			} else {
+				names = append(names, v.Name())
+			}
+		}
+		sort.Strings(names)
+		return strings.Join(names, ",")
+	}
+	if got, want := assignables("H"), "F,T.M,V"; got != want {
+		t.Errorf("assignable values of H: got %s, want %s", got, want)
+	}
+	if got, want := assignables("S"), "L"; got != want {
+		t.Errorf("assignable values of S: got %s, want %s", got, want)
+	}
+}
@@ -43,6 +43,16 @@ type ToolchainInfo struct {
 	// A commit hash or something like "go1.16".
 	// ToDo: now lso might be blank, but need some handling ...
 	Version string
+
+	// The target platforms. The first one is the primary one.
+	// Blank means the host platform only.
+	Platforms []Platform
+
+	// The build tags used to load packages.
+	BuildTags []string
+
+	// Whether or not to go on analyzing packages with errors.
+	Tolerant bool
 }
 
 // CodeAnalyzer holds all the analysis results and functionalities.
@@ -50,13 +60,37 @@ type CodeAnalyzer struct {
 	modulesByPath       map[string]*Module // including stdModule
 	nonToolchainModules []Module           // not including stdModule and std/cmd module
 	stdModule           *Module
-	wdModule            *Module // working diretory module. It might be the cmd toolchain module, or nil if modules feature is off.
+	wdModule            *Module   // working diretory module. It might be the cmd toolchain module, or nil if modules feature is off.
+	wdModules           []*Module // all working directory modules (more than one in workspace mode), wdModule is one of them.
 
 	//stdPackages  map[string]struct{}
 	packageTable map[string]*Package
 	packageList  []*Package
 	builtinPkg   *Package
 
+	// The paths of the packages matching the arguments of ParsePackages.
+	argumentPackagePaths []string
+
+	// The first one is the primary platform.
+	platforms []Platform
+	// For multi-platform mode only. Indexed by platform indexes.
+	packagePlatformDecls map[string][]*platformDecls
+
+	buildTags []string
+
+	// The directory and extra environments to run go commands in,
+	// such as the temp project and the environments to resolve module
+	// versions against the module cache. goDir is blank for the
+	// current directory.
+	goDir string
+	goEnv []string
+
+	// In tolerant mode, load errors and analyze panics are
+	// recorded as problems, instead of stopping the analysis.
+	tolerant        bool
+	problems        []*Problem
+	packageProblems map[string][]*Problem
+
 	// This one is removed now. We should use FileSet.PositionFor.
 	//sourceFileLineOffsetTable map[string]int32
 
@@ -99,6 +133,9 @@ type CodeAnalyzer struct {
 	// Identifer references (ToDo: need optimizations)
 	objectRefs map[types.Object][]Identifier
 
+	// Only for package-level objects, fields and methods.
+	objectRefCounts map[types.Object]RefCounts
+
 	// Not concurrent safe.
 	tempTypeLookup map[uint32]struct{}
 
@@ -114,6 +151,24 @@ func (d *CodeAnalyzer) WorkingDirectoryModule() *Module {
 	return d.wdModule
 }
 
+// WorkingDirectoryModules returns all the main modules. In workspace
+// mode, they are the modules used in the go.work file. The cmd
+// toolchain module is not included.
+func (d *CodeAnalyzer) WorkingDirectoryModules() []*Module {
+	return d.wdModules
+}
+
+// IsWorkingDirectoryModule returns whether or not the specified
+// module is one of the ones returned by WorkingDirectoryModules.
+func (d *CodeAnalyzer) IsWorkingDirectoryModule(m *Module) bool {
+	for _, wdm := range d.wdModules {
+		if wdm == m {
+			return true
+		}
+	}
+	return false
+}
+
 // ModuleByPath returns the module corresponding the specified path.
 func (d *CodeAnalyzer) ModuleByPath(path string) *Module {
 	return d.modulesByPath[path]
@@ -135,6 +190,13 @@ func (d *CodeAnalyzer) IterateModule(f func(*Module)) {
 	}
 }
 
+// IterateTypes iterates all registered types and passes them to the specified callback f.
+func (d *CodeAnalyzer) IterateTypes(f func(*TypeInfo)) {
+	for _, t := range d.allTypeInfos {
+		f(t)
+	}
+}
+
 func (d *CodeAnalyzer) regObjectReference(obj types.Object, fileInfo *SourceFileInfo, id *ast.Ident) {
 	if d.objectRefs == nil {
 		d.objectRefs = map[types.Object][]Identifier{} // ToDo: estimate an initial minimum capacity
@@ -159,6 +221,108 @@ func (d *CodeAnalyzer) ObjectReferences(obj types.Object) []Identifier {
 	return dups
 }
 
+// RefCounts records how many times an object is referenced.
+// The declaration of the object is not counted.
+type RefCounts struct {
+	InPackage int32 // in the package declaring the object
+	InModule  int32 // in other packages of the same module
+	External  int32 // in packages of other modules
+}
+
+// Total returns the count of all references.
+func (rc RefCounts) Total() int32 {
+	return rc.InPackage + rc.InModule + rc.External
+}
+
+// Outside returns the count of the references outside of the package declaring the object.
+func (rc RefCounts) Outside() int32 {
+	return rc.InModule + rc.External
+}
+
+// ObjectReferenceCounts returns the reference counts of the given object.
+// Only the counts of package-level objects, fields and methods are recorded.
+func (d *CodeAnalyzer) ObjectReferenceCounts(obj types.Object) RefCounts {
+	return d.objectRefCounts[obj]
+}
+
+func (d *CodeAnalyzer) countObjectReferences() {
+	d.objectRefCounts = make(map[types.Object]RefCounts, len(d.objectRefs)/4)
+
+	for obj, ids := range d.objectRefs {
+		objPkg := obj.Pkg()
+		if objPkg == nil {
+			continue // builtin
+		}
+		switch o := obj.(type) {
+		default:
+			continue
+		case *types.Func:
+		case *types.Var:
+			if !o.IsField() && o.Parent() != objPkg.Scope() {
+				continue
+			}
+		case *types.TypeName, *types.Const:
+			if o.Parent() != objPkg.Scope() {
+				continue
+			}
+		}
+
+		pkg := d.packageTable[objPkg.Path()]
+		if pkg == nil {
+			continue
+		}
+
+		var counts RefCounts
+		for _, id := range ids {
+			refPkg := id.FileInfo.Pkg
+			switch {
+			case refPkg == pkg:
+				if id.AstIdent.Pos() == obj.Pos() {
+					continue // the declaration
+				}
+				counts.InPackage++
+			case refPkg.Module != nil && refPkg.Module == pkg.Module:
+				counts.InModule++
+			default:
+				counts.External++
+			}
+		}
+		if counts.Total() > 0 {
+			d.objectRefCounts[obj] = counts
+		}
+	}
+}
+
+// lookupTypeNameOf returns the registered TypeName denoted by
+// an identifier or a qualified identifier used in a package.
+// Nil is returned if the type name can't be resolved.
+func (d *CodeAnalyzer) lookupTypeNameOf(pkg *Package, expr ast.Expr) *TypeName {
+	var id string
+	switch expr := expr.(type) {
+	default:
+		return nil
+	case *ast.Ident:
+		// Uses (instead of ObjectOf) is used here, because
+		// Defs records the field objects of embedded fields.
+		tn, ok := pkg.PPkg.TypesInfo.Uses[expr].(*types.TypeName)
+		if !ok {
+			return nil
+		}
+		id = d.Id2(tn.Pkg(), expr.Name)
+	case *ast.SelectorExpr:
+		x, ok := expr.X.(*ast.Ident)
+		if !ok {
+			return nil
+		}
+		srcPkg, ok := pkg.PPkg.TypesInfo.Uses[x].(*types.PkgName)
+		if !ok {
+			return nil
+		}
+		id = d.Id2(srcPkg.Imported(), expr.Sel.Name)
+	}
+	return d.allTypeNameTable[id]
+}
+
 // Please reset it after using.
 func (d *CodeAnalyzer) tempTypeLookupTable() map[uint32]struct{} {
 	if d.tempTypeLookup == nil {
@@ -189,6 +353,19 @@ func (d *CodeAnalyzer) PackageByPath(path string) *Package {
 	return d.packageTable[path]
 }
 
+// ArgumentPackages returns the packages matching the arguments
+// passed to ParsePackages (not including their dependencies).
+// The returned packages are sorted by their import paths.
+func (d *CodeAnalyzer) ArgumentPackages() []*Package {
+	var pkgs = make([]*Package, 0, len(d.argumentPackagePaths))
+	for _, path := range d.argumentPackagePaths {
+		if pkg := d.packageTable[path]; pkg != nil {
+			pkgs = append(pkgs, pkg)
+		}
+	}
+	return pkgs
+}
+
 // IsStandardPackage returns whether or not the given package is a standard package.
 func (d *CodeAnalyzer) IsStandardPackage(pkg *Package) bool {
 	return pkg.Module == d.stdModule
@@ -200,6 +377,11 @@ func (d *CodeAnalyzer) IsStandardPackageByPath(path string) bool {
 	return ok && d.IsStandardPackage(pkg)
 }
 
+// BuildTags returns the build tags used to load packages.
+func (d *CodeAnalyzer) BuildTags() []string {
+	return d.buildTags
+}
+
 // BuiltinPackge returns the builtin package.
 func (d *CodeAnalyzer) BuiltinPackge() *Package {
 	return d.builtinPkg
@@ -751,23 +933,15 @@ func (d *CodeAnalyzer) registerDirectFields(typeInfo *TypeInfo, astStructNode *a
 
 	for _, field := range astStructNode.Fields.List {
 		if len(field.Names) == 0 {
-			var id string
+			var tn *TypeName
 
 			var isStar = false
 			for ok, node := true, field.Type; ok; ok = isStar {
 				switch expr := node.(type) {
 				default:
 					panic("not an embedded field but should be. type: " + fmt.Sprintf("%T", expr))
-				case *ast.Ident:
-					//id = d.Id1b(pkg, expr.Name) // incorrect for builtin typenames
-
-					tn := pkg.PPkg.TypesInfo.Uses[expr]
-					id = d.Id2(tn.Pkg(), expr.Name)
-
-				case *ast.SelectorExpr:
-					srcObj := pkg.PPkg.TypesInfo.ObjectOf(expr.X.(*ast.Ident))
-					srcPkg := srcObj.(*types.PkgName)
-					id = d.Id2(srcPkg.Imported(), expr.Sel.Name)
+				case *ast.Ident, *ast.SelectorExpr:
+					tn = d.lookupTypeNameOf(pkg, expr)
 				case *ast.StarExpr:
 					if isStar {
 						panic("bad embedded field **T.")
@@ -781,9 +955,11 @@ func (d *CodeAnalyzer) registerDirectFields(typeInfo *TypeInfo, astStructNode *a
 				break
 			}
 
-			tn := d.allTypeNameTable[id]
 			if tn == nil {
-				panic("TypeName for " + id + " not found")
+				if d.registerUnresolvable(pkg, field.Type.Pos(), "embedded field "+types.ExprString(field.Type)) {
+					continue
+				}
+				panic("TypeName for " + types.ExprString(field.Type) + " not found")
 			}
 
 			//if tn.Name() == "_" {
@@ -984,7 +1160,7 @@ func (d *CodeAnalyzer) registerExplicitlySpecifiedMethods(typeInfo *TypeInfo, as
 		// method is a *ast.Field.
 
 		if len(method.Names) == 0 {
-			var id string
+			var tn *TypeName
 			switch expr := method.Type.(type) {
 			default:
 				// embed interface type (anonymous field)
@@ -1000,18 +1176,15 @@ func (d *CodeAnalyzer) registerExplicitlySpecifiedMethods(typeInfo *TypeInfo, as
 				//<<
 
 				panic(fmt.Sprintf("not a valid embedding interface type name: %#v", method))
-			case *ast.Ident:
-				ttn := pkg.PPkg.TypesInfo.Uses[expr]
-				id = d.Id2(ttn.Pkg(), ttn.Name())
-			case *ast.SelectorExpr:
-				srcObj := pkg.PPkg.TypesInfo.ObjectOf(expr.X.(*ast.Ident))
-				srcPkg := srcObj.(*types.PkgName)
-				id = d.Id2(srcPkg.Imported(), expr.Sel.Name)
+			case *ast.Ident, *ast.SelectorExpr:
+				tn = d.lookupTypeNameOf(pkg, expr)
 			}
 
-			tn := d.allTypeNameTable[id]
 			if tn == nil {
-				panic("TypeName for " + id + " not found")
+				if d.registerUnresolvable(pkg, method.Type.Pos(), "embedded interface "+types.ExprString(method.Type)) {
+					continue
+				}
+				panic("TypeName for " + types.ExprString(method.Type) + " not found")
 			}
 
 			fieldTypeInfo := tn.Named
@@ -1046,6 +1219,12 @@ func (d *CodeAnalyzer) registerExplicitlySpecifiedMethods(typeInfo *TypeInfo, as
 			}
 
 			tv := pkg.PPkg.TypesInfo.Types[method.Type]
+			if _, ok := tv.Type.(*types.Signature); !ok {
+				if d.registerUnresolvable(pkg, ident.Pos(), "method "+ident.Name) {
+					continue
+				}
+				panic("the type of method " + ident.Name + " is not a signature")
+			}
 			methodTypeInfo := d.RegisterType(tv.Type)
 			if pkg == d.builtinPkg && ident.Name == "Error" {
 				// The special handling is to correctly find all implementations of the builtin "error" type.
@@ -1226,14 +1405,38 @@ func (d *CodeAnalyzer) registerFunctionForInvolvedTypeNames(f FunctionResource)
 	return
 }
 
+// registerValueForItsTypeName registers a value for the named type of the value
+// (or the base type of the pointer type of the value). If the type of the value
+// is unnamed, the value is registered as an assignable value for the named
+// types whose underlying types are the type of the value. Now, only the
+// values of function, slice and map types are registered as assignable ones.
+//
+// To avoid listing too many, a value is only registered as an assignable value
+// for the named types declared in the same package as the value or involved in
+// the value type (the "related" packages).
 func (d *CodeAnalyzer) registerValueForItsTypeName(res ValueResource) {
-
-	//>> 1.18, ToDo
-	// Now, for an instantiated type, t.TypeName is nil.
-	// ToDo: in d.registeringType, if t.TT is found a *types.Named,
-	//       then find the Origin type, and register the value on that origin type.
-	t := res.TypeInfo(d)
-	//<<
+	var t *TypeInfo
+	if f, ok := res.(*Function); ok {
+		if f.Func == nil {
+			return
+		}
+		sig := f.Func.Type().(*types.Signature)
+		if isParameterizedSignature(sig) {
+			return
+		}
+		if sig.Recv() != nil {
+			// The type of a method value.
+			sig = types.NewSignature(nil, sig.Params(), sig.Results(), sig.Variadic())
+		}
+		t = d.RegisterType(sig)
+	} else {
+		//>> 1.18, ToDo
+		// Now, for an instantiated type, t.TypeName is nil.
+		// ToDo: in d.registeringType, if t.TT is found a *types.Named,
+		//       then find the Origin type, and register the value on that origin type.
+		t = res.TypeInfo(d)
+		//<<
+	}
 	toRegsiter := t.TypeName != nil
 
 	//if d.debug {
@@ -1290,7 +1493,62 @@ Done:
 			t.AsTypesOf = make([]ValueResource, 0, 4)
 		}
 		t.AsTypesOf = append(t.AsTypesOf, res)
+		return
 	}
+
+	if t.TypeName != nil {
+		return
+	}
+	switch t.TT.(type) {
+	default:
+		return
+	case *types.Signature, *types.Slice, *types.Map:
+	}
+	pkg := res.Package()
+	for _, tn := range t.Underlieds {
+		if tn.Pkg != pkg && !typeInvolvesPackage(t.TT, tn.Pkg.PPkg.Types) {
+			continue
+		}
+		nt := tn.Denoting()
+		if nt.AssignableValues == nil {
+			nt.AssignableValues = make([]ValueResource, 0, 4)
+		}
+		nt.AssignableValues = append(nt.AssignableValues, res)
+	}
+}
+
+// typeInvolvesPackage returns whether or not a type is composed
+// of (or is) a named type declared in the specified package.
+func typeInvolvesPackage(tt types.Type, pkg *types.Package) bool {
+	switch tt := tt.(type) {
+	case *types.Named:
+		return tt.Obj().Pkg() == pkg
+	case *types.Pointer:
+		return typeInvolvesPackage(tt.Elem(), pkg)
+	case *types.Slice:
+		return typeInvolvesPackage(tt.Elem(), pkg)
+	case *types.Array:
+		return typeInvolvesPackage(tt.Elem(), pkg)
+	case *types.Chan:
+		return typeInvolvesPackage(tt.Elem(), pkg)
+	case *types.Map:
+		return typeInvolvesPackage(tt.Key(), pkg) || typeInvolvesPackage(tt.Elem(), pkg)
+	case *types.Signature:
+		return typeInvolvesPackage(tt.Params(), pkg) || typeInvolvesPackage(tt.Results(), pkg)
+	case *types.Tuple:
+		for i := 0; i < tt.Len(); i++ {
+			if typeInvolvesPackage(tt.At(i).Type(), pkg) {
+				return true
+			}
+		}
+	case *types.Struct:
+		for i := 0; i < tt.NumFields(); i++ {
+			if typeInvolvesPackage(tt.Field(i).Type(), pkg) {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 // BuildMethodSignatureFromFuncObject builds the signature for function object.
@@ -1308,6 +1566,77 @@ Done:
 //	return d.BuildMethodSignatureFromFunctionSignature(funcSig, methodName, pkgImportPath)
 //}
 
+// MethodMismatch pairs a method specified in an interface type with
+// the method (of another type) which has the same name.
+type MethodMismatch struct {
+	Required *Selector // the interface method
+	Provided *Selector // nil for missing methods
+}
+
+// ImplementationDiagnosis records why a type doesn't implement an interface type.
+type ImplementationDiagnosis struct {
+	Missings        []MethodMismatch // no methods with the same names
+	WrongSignatures []MethodMismatch // methods with the same names but different signatures
+	PointerOnlys    []MethodMismatch // methods only in the method set of the pointer type
+}
+
+// Implemented returns whether or not the diagnosed type implements the interface type.
+func (diag *ImplementationDiagnosis) Implemented() bool {
+	return diag.PointerImplemented() && len(diag.PointerOnlys) == 0
+}
+
+// PointerImplemented returns whether or not the pointer type of
+// the diagnosed type implements the interface type.
+func (diag *ImplementationDiagnosis) PointerImplemented() bool {
+	return len(diag.Missings) == 0 && len(diag.WrongSignatures) == 0
+}
+
+// DiagnoseImplementation checks the methods of the interface type itf one by one
+// against the methods of type t. Method signatures are compared in the same way
+// as finding implementations, so the result is consistent with the Implements
+// and ImplementedBys lists.
+func (d *CodeAnalyzer) DiagnoseImplementation(t, itf *TypeInfo) *ImplementationDiagnosis {
+	type methodName struct {
+		name, pkg string
+	}
+
+	var provideds = make(map[methodName]*Selector, len(t.AllMethods))
+	var signatures = make(map[*Selector]MethodSignature, len(t.AllMethods))
+	for _, sel := range t.AllMethods {
+		sig := d.methodSelectorSignature(sel)
+		provideds[methodName{sig.Name, sig.Pkg}] = sel
+		signatures[sel] = sig
+	}
+
+	var diag = &ImplementationDiagnosis{}
+	for _, sel := range itf.AllMethods {
+		sig := d.methodSelectorSignature(sel)
+		provided := provideds[methodName{sig.Name, sig.Pkg}]
+		mismatch := MethodMismatch{Required: sel, Provided: provided}
+		switch {
+		case provided == nil:
+			diag.Missings = append(diag.Missings, mismatch)
+		case signatures[provided] != sig:
+			diag.WrongSignatures = append(diag.WrongSignatures, mismatch)
+		case provided.PointerReceiverOnly():
+			diag.PointerOnlys = append(diag.PointerOnlys, mismatch)
+		}
+	}
+	return diag
+}
+
+func (d *CodeAnalyzer) methodSelectorSignature(sel *Selector) MethodSignature {
+	funcSig, ok := sel.Method.Type.TT.(*types.Signature)
+	if !ok {
+		panic("not a types.Signature")
+	}
+	pkgImportPath := ""
+	if sel.Method.Pkg != nil {
+		pkgImportPath = sel.Method.Pkg.Path()
+	}
+	return d.BuildMethodSignatureFromFunctionSignature(funcSig, sel.Method.Name, pkgImportPath)
+}
+
 // BuildMethodSignatureFromFunctionSignature  builds the signature for method function object.
 // pkgImportPath should be only passed for unexported method names.
 func (d *CodeAnalyzer) BuildMethodSignatureFromFunctionSignature(funcSig *types.Signature, methodName string, pkgImportPath string) MethodSignature {
@@ -41,8 +41,9 @@ func (d *CodeAnalyzer) AnalyzePackages(onSubTaskDone func(int, time.Duration, ..
 	logProgress(SubTask_CollectSourceFiles)
 
 	for _, pkg := range d.packageList {
-		d.analyzePackage_CollectDeclarations(pkg)
+		d.analyzeTolerantly(pkg, "collect declarations", d.analyzePackage_CollectDeclarations)
 	}
+	d.analyzePackages_CollectIdenticalsIgnoringTags()
 
 	logProgress(SubTask_CollectDeclarations)
 
@@ -51,7 +52,7 @@ func (d *CodeAnalyzer) AnalyzePackages(onSubTaskDone func(int, time.Duration, ..
 	//log.Println("[analyze packages 2...]")
 
 	for _, pkg := range d.packageList {
-		d.analyzePackage_ConfirmTypeSources(pkg) // need collect source files firstly
+		d.analyzeTolerantly(pkg, "confirm type sources", d.analyzePackage_ConfirmTypeSources) // need collect source files firstly
 	}
 
 	logProgress(SubTask_ConfirmTypeSources)
@@ -70,6 +71,10 @@ func (d *CodeAnalyzer) AnalyzePackages(onSubTaskDone func(int, time.Duration, ..
 	d.forbidRegisterTypes = true
 
 	//methodCache := d.analyzePackages_FindImplementations_Old()
+	// The implementations of all types are found together and the found
+	// results of a type depend on other types, so this phase is not run
+	// tolerantly. Instead, the unresolvable methods in ill-typed packages
+	// have been skipped in the above phases.
 	d.analyzePackages_FindImplementations()
 	methodCache := &typeutil.MethodSetCache{}
 
@@ -78,16 +83,22 @@ func (d *CodeAnalyzer) AnalyzePackages(onSubTaskDone func(int, time.Duration, ..
 	logProgress(SubTask_FindImplementations)
 
 	for _, pkg := range d.packageList {
-		d.registerNamedInterfaceMethodsForInvolvedTypeNames(pkg)
+		d.analyzeTolerantly(pkg, "register interface methods", d.registerNamedInterfaceMethodsForInvolvedTypeNames)
 	}
 
 	logProgress(SubTask_RegisterInterfaceMethodsForTypes)
 
-	d.collectObjectReferences()
+	for _, pkg := range d.packageList {
+		d.analyzeTolerantly(pkg, "collect object references", d.collectObjectReferences)
+	}
+	d.countObjectReferences()
 
 	logProgress(SubTask_CollectObjectReferences)
 
-	d.collectCodeExamples() // need the pkg.Directory confirmed in the last step
+	d.exampleFileSet = token.NewFileSet()
+	for _, pkg := range d.packageList {
+		d.analyzeTolerantly(pkg, "collect code examples", d.collectCodeExamples) // need the pkg.Directory confirmed in the last step
+	}
 
 	logProgress(SubTask_CollectExamples)
 
@@ -100,13 +111,14 @@ func (d *CodeAnalyzer) AnalyzePackages(onSubTaskDone func(int, time.Duration, ..
 	logProgress(SubTask_CollectRuntimeFunctionPositions)
 
 	for _, pkg := range d.packageList {
-		d.analyzePackage_CollectMoreStatistics(pkg)
+		d.analyzeTolerantly(pkg, "collect statistics", d.analyzePackage_CollectMoreStatistics)
 	}
 	d.analyzePackage_CollectMoreStatisticsFinal()
 
 	logProgress(SubTask_MakeStatistics)
 
 	d.buildSourceFileTable()
+	d.sortProblems()
 
 	// ...
 
@@ -914,7 +926,9 @@ func (d *CodeAnalyzer) analyzePackages_CollectSelectors() {
 
 		currentCounter++ // faster than map
 		//log.Println("===================================", currentCounter)
-		d.collectSelectorsForInterfaceType(t, 0, currentCounter, smm)
+		d.analyzeTypeTolerantly(t, "collect selectors", func(t *TypeInfo) {
+			d.collectSelectorsForInterfaceType(t, 0, currentCounter, smm)
+		})
 	}
 
 	var checkedTypes = make(map[uint32]uint16) // type index: embedding depth
@@ -923,7 +937,9 @@ func (d *CodeAnalyzer) analyzePackages_CollectSelectors() {
 
 		//currentCounter++ // can't replace map
 
-		d.collectSelectorsForNonInterfaceType(t, smm, checkedTypes)
+		d.analyzeTypeTolerantly(t, "collect selectors", func(t *TypeInfo) {
+			d.collectSelectorsForNonInterfaceType(t, smm, checkedTypes)
+		})
 
 		// print selectors
 		//if len(t.AllMethods)+len(t.AllFields) > 0 {
@@ -1784,6 +1800,9 @@ func (d *CodeAnalyzer) analyzePackage_CollectDeclarations(pkg *Package) {
 				obj := pkg.PPkg.TypesInfo.Defs[fd.Name]
 				switch funcObj := obj.(type) {
 				default:
+					if obj == nil && d.registerUnresolvable(pkg, fd.Name.Pos(), "function "+fd.Name.Name) {
+						continue
+					}
 					panic(pkg.Path() + "." + fd.Name.Name + " not a types.Func or types.Builtin, but " + fmt.Sprintf("%T", funcObj))
 				case *types.Func:
 					f = &Function{
@@ -1818,13 +1837,19 @@ func (d *CodeAnalyzer) analyzePackage_CollectDeclarations(pkg *Package) {
 						obj := pkg.PPkg.TypesInfo.Defs[typeSpec.Name]
 						typeObj, ok := obj.(*types.TypeName)
 						if !ok {
+							if obj == nil && d.registerUnresolvable(pkg, typeSpec.Name.Pos(), "type "+typeSpec.Name.Name) {
+								continue
+							}
 							//log.Println(pkg.PPkg.Fset.PositionFor(typeSpec.Pos(), false))
 							//log.Println(pkg.PPkg.TypesInfo.Defs)
 							panic(fmt.Sprintf("not a types.TypeName: %[1]v, %[1]T. Spec: %v", obj, typeSpec.Name.Name))
 						}
 
 						tv := pkg.PPkg.TypesInfo.Types[typeSpec.Type]
-						if !tv.IsType() {
+						if !tv.IsType() && pkg.PPkg.IllTyped && d.tolerant {
+							// The source type is unresolvable.
+							tv.Type = types.Typ[types.Invalid]
+						} else if !tv.IsType() {
 							if pkg.Path() != "unsafe" {
 								panic(typeSpec.Name.Name + ": not type")
 							}
@@ -1944,6 +1969,9 @@ func (d *CodeAnalyzer) analyzePackage_CollectDeclarations(pkg *Package) {
 							if isBuiltinPkg || isUnsafePkg {
 								tn.Named.attributes |= Builtin
 							}
+							if ut := newTypeInfo.Underlying; ut != nil && ut != newTypeInfo {
+								ut.Underlieds = append(ut.Underlieds, tn)
+							}
 						}
 
 						registerTypeName(tn)
@@ -1961,6 +1989,9 @@ func (d *CodeAnalyzer) analyzePackage_CollectDeclarations(pkg *Package) {
 							obj := pkg.PPkg.TypesInfo.Defs[name]
 							varObj, ok := obj.(*types.Var)
 							if !ok {
+								if obj == nil && d.registerUnresolvable(pkg, name.Pos(), "variable "+name.Name) {
+									continue
+								}
 								panic("not a types.Var")
 							}
 
@@ -1988,6 +2019,9 @@ func (d *CodeAnalyzer) analyzePackage_CollectDeclarations(pkg *Package) {
 							obj := pkg.PPkg.TypesInfo.Defs[name]
 							constObj, ok := obj.(*types.Const)
 							if !ok {
+								if obj == nil && d.registerUnresolvable(pkg, name.Pos(), "constant "+name.Name) {
+									continue
+								}
 								panic("not a types.Const")
 							}
 
@@ -2018,6 +2052,9 @@ func (d *CodeAnalyzer) analyzePackage_CollectDeclarations(pkg *Package) {
 
 						pkgObj, ok := obj.(*types.PkgName)
 						if !ok {
+							if obj == nil && d.registerUnresolvable(pkg, importSpec.Pos(), "import "+importSpec.Path.Value) {
+								continue
+							}
 							//log.Println(pkg.PPkg.Fset.PositionFor(importSpec.Pos(), false))
 							//log.Println(pkg.PPkg.TypesInfo.Implicits)
 							panic(fmt.Sprintf("not a types.PkgName: %[1]v, %[1]T. Spec: %v, %v", obj, importSpec.Name, importSpec.Path.Value))
@@ -2109,6 +2146,7 @@ func (d *CodeAnalyzer) analyzePackage_CollectDeclarations(pkg *Package) {
 		//	d.registerFunctionForInvolvedTypeNames(f)
 		//}
 		numParams, numResults, lastResultIsError := d.registerFunctionForInvolvedTypeNames(f)
+		d.registerValueForItsTypeName(f)
 
 		// ToDo: sometimes unexported ones are also needed to read code.
 		if f.Exported() {
@@ -2229,6 +2267,7 @@ func (d *CodeAnalyzer) analyzePackage_CollectMoreStatistics(pkg *Package) {
 			//d.stats.ExportedIdentifersSumLength += int32(len(tn.Name()))
 			//d.stats.ExportedIdentifers++
 			d.stat_OnNewExportedIdentifer(len(tn.Name()), tn)
+			d.stat_OnExportedResourceReferenced(tn, d.ObjectReferenceCounts(tn.TypeName))
 
 			denoting := tn.Denoting()
 			kind := denoting.Kind()
@@ -2313,9 +2352,30 @@ func (d *CodeAnalyzer) analyzePackage_CollectMoreStatistics(pkg *Package) {
 				//incSliceStat(d.stats.ExportedNamedStructsByFieldCount[:], len(denoting.AllFields))
 				//d.stats.ExportedNamedStructTypeFields += int32(len(denoting.AllFields))
 				d.stat_OnNewExportedStructTypeName(hasEmbeddeds, len(denoting.AllFields), int(ut.EmbeddingFields), numExpliciteds, numExporteds, numExportedExpliciteds, numExportedPromoteds, tn)
+				d.stat_OnExportedStructLayout(tn, d.StructLayoutOf(tn))
 			}
 		}
 	}
+
+	if isBuiltinPkg {
+		return
+	}
+
+	for _, f := range pkg.PackageAnalyzeResult.AllFunctions {
+		if f.Func != nil && f.Exported() {
+			d.stat_OnExportedResourceReferenced(f, d.ObjectReferenceCounts(f.Func))
+		}
+	}
+	for _, v := range pkg.PackageAnalyzeResult.AllVariables {
+		if v.Exported() {
+			d.stat_OnExportedResourceReferenced(v, d.ObjectReferenceCounts(v.Var))
+		}
+	}
+	for _, c := range pkg.PackageAnalyzeResult.AllConstants {
+		if c.Exported() {
+			d.stat_OnExportedResourceReferenced(c, d.ObjectReferenceCounts(c.Const))
+		}
+	}
 }
 
 func (d *CodeAnalyzer) analyzePackage_CollectMoreStatisticsFinal() {
@@ -2369,6 +2429,7 @@ func (d *CodeAnalyzer) analyzePackage_CollectSomeRuntimeFunctionPositions() {
 			obj := runtimePkg.PPkg.Types.Scope().Lookup(f)
 			if obj == nil {
 				log.Printf("!!! runtime.%s is not found", f)
+				continue
 			}
 			d.runtimeFuncPositions[f] = runtimePkg.PPkg.Fset.PositionFor(obj.Pos(), false)
 		}
@@ -2392,7 +2453,11 @@ func (d *CodeAnalyzer) analyzePackage_ConfirmTypeSources(pkg *Package) {
 					typeSpec := spec.(*ast.TypeSpec)
 
 					obj := pkg.PPkg.TypesInfo.Defs[typeSpec.Name]
-					typeObj := obj.(*types.TypeName)
+					typeObj, ok := obj.(*types.TypeName)
+					if !ok {
+						// Already registered as unresolvable when collecting declarations.
+						continue
+					}
 					if typeObj.Name() == "_" {
 						continue
 					}
@@ -2403,6 +2468,9 @@ func (d *CodeAnalyzer) analyzePackage_ConfirmTypeSources(pkg *Package) {
 
 					newTypeName := d.allTypeNameTable[d.Id2(typeObj.Pkg(), typeObj.Name())]
 					if newTypeName == nil {
+						if d.registerUnresolvable(pkg, typeSpec.Name.Pos(), "type "+typeSpec.Name.Name) {
+							continue
+						}
 						panic("type name " + typeSpec.Name.Name + " not found: " + d.Id1(typeObj.Pkg(), typeObj.Name()))
 					}
 
@@ -2425,45 +2493,23 @@ func (d *CodeAnalyzer) analyzePackage_ConfirmTypeSources(pkg *Package) {
 						//>> 1.18, ToDo
 						// handle Index and IndexList?
 						//<<
-						case *ast.Ident:
+						case *ast.Ident, *ast.SelectorExpr:
+							// if the source type is a builtin type, tn.Pkg == nil
 
-							//log.Println("???", d.Id(pkg.PPkg.Types, expr.Name))
-
-							//log.Println("   ", pkg.PPkg.TypesInfo.ObjectOf(expr))
-
-							srcObj := pkg.PPkg.TypesInfo.ObjectOf(expr)
-							if srcObj == nil {
-								if pkg.Path() != "unsafe" {
-									panic("srcObj is nil but package is not unsafe")
-								}
-								return
-							}
-							srcTypeObj := srcObj.(*types.TypeName)
-
-							//log.Println("   srcTypeObj.Pkg() =", srcTypeObj.Pkg())
-							// if srcType is a built type, srcTypeObj.Pkg() == nil
-
-							tn := d.allTypeNameTable[d.Id2(srcTypeObj.Pkg(), expr.Name)]
+							tn := d.lookupTypeNameOf(pkg, expr)
 							if tn == nil {
-								panic("type name " + expr.Name + " not found")
+								if pkg.Path() == "unsafe" {
+									return
+								}
+								if d.registerUnresolvable(pkg, expr.Pos(), "source type "+types.ExprString(expr)+" of type "+typeSpec.Name.Name) {
+									return
+								}
+								panic("type name " + types.ExprString(expr) + " not found")
 							}
 							source.TypeName = tn
 
-							//log.Println(starSource, "ident,", pkg.Path()+"."+typeSpec.Name.Name, "source is:", tn.Pkg.Path()+"."+expr.Name)
+							//log.Println(starSource, pkg.Path()+"."+typeSpec.Name.Name, "source is:", types.ExprString(expr))
 
-							return
-						case *ast.SelectorExpr:
-							//log.Println("selector,", pkg.Path()+"."+typeSpec.Name.Name, "source is:")
-							srcObj := pkg.PPkg.TypesInfo.ObjectOf(expr.X.(*ast.Ident))
-							srcPkg := srcObj.(*types.PkgName)
-
-							tn := d.allTypeNameTable[d.Id2(srcPkg.Imported(), expr.Sel.Name)]
-							if tn == nil {
-								panic("type name " + expr.Sel.Name + " not found")
-							}
-							source.TypeName = tn
-
-							//log.Println(starSource, "selector,", pkg.Path()+"."+typeSpec.Name.Name, "source is:", tn.Pkg.Path()+"."+expr.Sel.Name)
 							return
 						case *ast.ParenExpr:
 							//log.Println("paren,", pkg.Path()+"."+typeSpec.Name.Name, "source is:")
@@ -2535,3 +2581,52 @@ func (d *CodeAnalyzer) analyzePackage_ConfirmTypeSources(pkg *Package) {
 
 	return
 }
+
+// Find unnamed struct types which are only different in field tags.
+// Only the tags of the top-level fields are ignored now.
+func (d *CodeAnalyzer) analyzePackages_CollectIdenticalsIgnoringTags() {
+	var stripTags = func(st *types.Struct) types.Type {
+		n := st.NumFields()
+		hasTags := false
+		for i := 0; i < n; i++ {
+			if st.Tag(i) != "" {
+				hasTags = true
+				break
+			}
+		}
+		if !hasTags {
+			return st
+		}
+		fields := make([]*types.Var, n)
+		for i := range fields {
+			fields[i] = st.Field(i)
+		}
+		return types.NewStruct(fields, nil)
+	}
+
+	var groups typeutil.Map
+	var structTypes []*TypeInfo
+	for _, t := range d.allTypeInfos {
+		st, ok := t.TT.(*types.Struct)
+		if !ok || len(t.Underlieds) == 0 {
+			continue
+		}
+		key := stripTags(st)
+		ts, _ := groups.At(key).([]*TypeInfo)
+		groups.Set(key, append(ts, t))
+		structTypes = append(structTypes, t)
+	}
+
+	for _, t := range structTypes {
+		ts := groups.At(stripTags(t.TT.(*types.Struct))).([]*TypeInfo)
+		if len(ts) < 2 {
+			continue
+		}
+		t.IdenticalsIgnoringTags = make([]*TypeInfo, 0, len(ts)-1)
+		for _, o := range ts {
+			if o != t {
+				t.IdenticalsIgnoringTags = append(t.IdenticalsIgnoringTags, o)
+			}
+		}
+	}
+}
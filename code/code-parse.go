@@ -14,6 +14,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -60,14 +61,23 @@ func collectPPackages(ppkgs []*packages.Package) map[string]*packages.Package {
 	return allPPkgs
 }
 
-func getMatchedPackages(arg string, jsonFormat bool) ([][]byte, error) {
-	var output []byte
-	var err error
+// buildTagsFlags returns the build flags corresponding to the build tags.
+func buildTagsFlags(tags []string) []string {
+	if len(tags) == 0 {
+		return nil
+	}
+	return []string{"-tags=" + strings.Join(tags, ",")}
+}
+
+// envs are the environment variables (such as GOOS and GOARCH) to run the go command with.
+// dir is the directory to run the go command in (blank for the current directory).
+func getMatchedPackages(arg string, jsonFormat bool, buildFlags, envs []string, dir string) ([][]byte, error) {
+	cmdAndArgs := append([]string{"go", "list", "-find"}, buildFlags...)
 	if jsonFormat {
-		output, err = util.RunShell(time.Minute*3, "", nil, "go", "list", "-find", "-json", arg)
-	} else {
-		output, err = util.RunShell(time.Minute*3, "", nil, "go", "list", "-find", arg)
+		cmdAndArgs = append(cmdAndArgs, "-json")
 	}
+	cmdAndArgs = append(cmdAndArgs, arg)
+	output, err := util.RunShell(time.Minute*3, dir, envs, cmdAndArgs...)
 	if err != nil {
 		return nil, fmt.Errorf("go list %s error: %w", arg, err)
 	}
@@ -87,9 +97,9 @@ func getMatchedPackages(arg string, jsonFormat bool) ([][]byte, error) {
 	return bytes.Fields(output), nil
 }
 
-func hasMatchedPackages(arg string) bool {
-	//out, err := getMatchedPackages(arg, true)
-	out, err := getMatchedPackages(arg, false)
+func hasMatchedPackages(arg string, buildFlags, envs []string, dir string) bool {
+	//out, err := getMatchedPackages(arg, true, buildFlags, envs)
+	out, err := getMatchedPackages(arg, false, buildFlags, envs, dir)
 	return err == nil && len(out) > 0
 }
 
@@ -115,7 +125,7 @@ func hasMatchedPackages(arg string) bool {
 //	return pkgs, nil
 //}
 
-func validateArgumentsAndSetOptions(args []string, toolchainPath string) ([]string, bool, error) {
+func validateArgumentsAndSetOptions(args []string, toolchainPath string, buildFlags, envs []string, dir string) ([]string, bool, error) {
 	if len(args) == 0 {
 		//panic("should not")
 		return []string{"."}, false, nil
@@ -153,7 +163,7 @@ func validateArgumentsAndSetOptions(args []string, toolchainPath string) ([]stri
 			} else if strings.HasPrefix(p, ".\\") {
 				args = append(args, strings.Replace(p, "\\", "/", -1))
 			} else {
-				if !hasMatchedPackages(p) {
+				if !hasMatchedPackages(p, buildFlags, envs, dir) {
 					//log.Printf("argument %s does not match any package, so it is discarded", p)
 					continue
 				}
@@ -191,7 +201,36 @@ func (d *CodeAnalyzer) ParsePackages(onSubTaskDone func(int, time.Duration, ...i
 	// the length of the input args is not zero for sure.
 	oldArgs := args
 
-	args, hasToolchain, err := validateArgumentsAndSetOptions(args, toolchain.Cmd)
+	d.buildTags = toolchain.BuildTags
+	d.tolerant = toolchain.Tolerant
+	buildFlags := buildTagsFlags(d.buildTags)
+
+	// The go commands must list packages for the primary target platform.
+	d.platforms = resolvePlatforms(toolchain.Platforms)
+
+	// For "golds aModule@version" cases. The module versions
+	// are resolved against the module cache only.
+	mvGroups := collectModuleVersionArguments(args)
+	if len(mvGroups) > 1 {
+		return errors.New("several versions of a module are specified, which is only supported when serving or generating HTML docs (each version is analyzed separately)")
+	}
+	if len(mvGroups) == 1 {
+		mvs := mvGroups[0]
+		tempDir, err := prepareModuleVersionsProject(mvs)
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(tempDir)
+		d.goDir, d.goEnv = tempDir, moduleVersionsEnv()
+
+		args = make([]string, len(mvs))
+		for i, mv := range mvs {
+			args[i] = mv.Path + "/..."
+		}
+		oldArgs = args
+	}
+
+	args, hasToolchain, err := validateArgumentsAndSetOptions(args, toolchain.Cmd, buildFlags, d.goCommandEnv(d.platforms[0]), d.goDir)
 	if err != nil {
 		return err
 	}
@@ -262,6 +301,10 @@ func (d *CodeAnalyzer) ParsePackages(onSubTaskDone func(int, time.Duration, ...i
 	var numParsedPackages int32
 
 	var configForParsing = &packages.Config{
+		Dir:        d.goDir,
+		Env:        d.goCommandEnv(d.platforms[0]),
+		BuildFlags: buildFlags,
+
 		Mode: packages.NeedName | packages.NeedImports | packages.NeedDeps |
 			packages.NeedTypes | packages.NeedExportsFile | packages.NeedFiles |
 			packages.NeedCompiledGoFiles | packages.NeedTypesSizes |
@@ -310,7 +353,9 @@ func (d *CodeAnalyzer) ParsePackages(onSubTaskDone func(int, time.Duration, ...i
 
 	var hasRuntime bool
 	var loadErrs = make([]error, 0, len(ppkgs))
+	d.argumentPackagePaths = make([]string, 0, len(ppkgs))
 	for _, ppkg := range ppkgs {
+		d.argumentPackagePaths = append(d.argumentPackagePaths, ppkg.PkgPath)
 		switch ppkg.PkgPath {
 		case "runtime":
 			hasRuntime = true
@@ -322,11 +367,14 @@ func (d *CodeAnalyzer) ParsePackages(onSubTaskDone func(int, time.Duration, ...i
 
 			for _, e := range ppkg.Errors {
 				loadErrs = append(loadErrs, e)
+				d.registerProblem(newLoadProblem(ppkg.PkgPath, e))
 			}
 		}
 	}
+	sort.Strings(d.argumentPackagePaths)
+	d.sortProblems()
 
-	if len(loadErrs) > 0 {
+	if len(loadErrs) > 0 && !d.tolerant {
 		//return errors.New("code parsing errors")
 		return &LoadError{Errs: loadErrs}
 	}
@@ -361,7 +409,7 @@ func (d *CodeAnalyzer) ParsePackages(onSubTaskDone func(int, time.Duration, ...i
 	//...
 
 	//stdPkgs, err := collectStdPackages()
-	stdPkgs, err := getMatchedPackages("std", false)
+	stdPkgs, err := getMatchedPackages("std", false, buildFlags, d.goCommandEnv(d.platforms[0]), d.goDir)
 	if err != nil {
 		return fmt.Errorf("failed to collect std packages: %w", err)
 	}
@@ -440,6 +488,10 @@ func (d *CodeAnalyzer) ParsePackages(onSubTaskDone func(int, time.Duration, ...i
 		}
 	}
 
+	if err := d.collectPlatformDifferences(args); err != nil {
+		return err
+	}
+
 	logProgress(true, SubTask_CollectPackages, int32(len(d.packageList)))
 
 	//log.Println("[parse packages done]")
@@ -486,8 +538,9 @@ func (d *CodeAnalyzer) confirmPackageModules(args []string, hasToolchain bool, t
 	// which makes the command return some incorrect modules for some packages.
 
 	// In the output, packages under GOROOT have not .Module info.
-	cmdAndArgs := append([]string{"go", "list", "-deps", "-json"}, args...)
-	output, err := util.RunShell(time.Minute*3, "", nil, cmdAndArgs...)
+	cmdAndArgs := append([]string{"go", "list", "-deps", "-json"}, buildTagsFlags(d.buildTags)...)
+	cmdAndArgs = append(cmdAndArgs, args...)
+	output, err := util.RunShell(time.Minute*3, d.goDir, d.goCommandEnv(d.platforms[0]), cmdAndArgs...)
 	if err != nil {
 		return fmt.Errorf("unable to list packages and modules info: %s : %s. %w", strings.Join(cmdAndArgs, " "), output, err)
 	}
@@ -668,12 +721,30 @@ func (d *CodeAnalyzer) confirmPackageModules(args []string, hasToolchain bool, t
 		// Not weird. Toolchain depends on some golang.org/x/... packages.
 	}
 
+	// In workspace mode, each module used in go.work is a main module.
+	// The one containing the current directory is viewed as the primary one.
+	wd := util.WorkingDirectory()
+	if d.goDir != "" {
+		wd = d.goDir
+	}
+	var wdModule, lastMainModule *Module
+	d.wdModules = nil
 	for i := range d.nonToolchainModules {
 		m := &d.nonToolchainModules[i]
-		if m.ActualVersion() == "" && m.Replace.Path == "" {
-			d.wdModule = m
+		if m.Main || m.ActualVersion() == "" && m.Replace.Path == "" {
+			d.wdModules = append(d.wdModules, m)
+			lastMainModule = m
+			if util.IsInDirectory(wd, m.Dir) && (wdModule == nil || len(m.Dir) > len(wdModule.Dir)) {
+				wdModule = m
+			}
 		}
 	}
+	if wdModule == nil {
+		wdModule = lastMainModule
+	}
+	if wdModule != nil {
+		d.wdModule = wdModule
+	}
 	// Confirm wdModule firstly so that the vendor directory could be determined,
 	if completeModuleInfo != nil {
 		var wg sync.WaitGroup
@@ -700,21 +771,31 @@ func (d *CodeAnalyzer) confirmPackageModules(args []string, hasToolchain bool, t
 			m := &d.nonToolchainModules[i]
 			if strings.HasPrefix(m.Replace.Path, ".") {
 				moduleDir := m.ActualDir()
-				if !strings.HasPrefix(moduleDir, d.wdModule.Dir) {
-					panic("should not\n" + moduleDir + "\n" + d.wdModule.Dir + "\n" + m.Replace.Dir)
+				// Use the innermost working directory module containing the replacement.
+				var wdModule *Module
+				for _, wdm := range d.wdModules {
+					if util.IsInDirectory(moduleDir, wdm.Dir) && (wdModule == nil || len(wdm.Dir) > len(wdModule.Dir)) {
+						wdModule = wdm
+					}
+				}
+				if wdModule == nil {
+					// Possible in workspace mode. For example, a replacement in
+					// go.work is relative to the workspace directory.
+					log.Printf("unable to find the working directory module containing the replacement (%s) of module %s", m.Replace.Dir, m.Path)
+					continue
 				}
-				path := moduleDir[len(d.wdModule.Dir):]
-				m.ExtraPathInRepository = d.wdModule.ExtraPathInRepository + path
-				m.RepositoryCommit = d.wdModule.RepositoryCommit
-				m.RepositoryDir = d.wdModule.RepositoryDir
-				m.RepositoryURL = d.wdModule.RepositoryURL
+				path := moduleDir[len(wdModule.Dir):]
+				m.ExtraPathInRepository = wdModule.ExtraPathInRepository + path
+				m.RepositoryCommit = wdModule.RepositoryCommit
+				m.RepositoryDir = wdModule.RepositoryDir
+				m.RepositoryURL = wdModule.RepositoryURL
 			}
 		}
 	}
 
 	for i := range d.nonToolchainModules {
 		m := &d.nonToolchainModules[i]
-		if m != d.wdModule && m.ActualVersion() == "" && strings.HasPrefix(m.Replace.Dir, ".") {
+		if !d.IsWorkingDirectoryModule(m) && m.ActualVersion() == "" && strings.HasPrefix(m.Replace.Dir, ".") {
 			log.Printf("!!! the version of module %s is not confirmed, weird", m.Path)
 		}
 	}
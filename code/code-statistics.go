@@ -104,6 +104,14 @@ type Stats struct {
 	ExportedIdentifersSumLength    int32
 	ExportedIdentifiersByLength    [100]int32
 	ExportedIdentiferLengthTopList TopList
+
+	// References (from outside of the declaring packages).
+	MostReferencedExportedTypeNames RankedList
+	MostReferencedExportedFunctions RankedList // including methods
+	MostReferencedExportedValues    RankedList // variables and constants
+
+	// Struct layouts.
+	ExportedStructsWastingMostBytes RankedList // bytes which could be saved by reordering fields
 }
 
 // A TopList specifies the minimu criteria for a top list
@@ -136,6 +144,51 @@ func (tl *TopList) Push(n int, obj interface{}) {
 	tl.Items = append(tl.Items, obj)
 }
 
+// A RankedList holds at most Capacity items with the largest scores.
+// Items are sorted by their scores in descending order.
+type RankedList struct {
+	Capacity int
+	Items    []RankedItem
+}
+
+// A RankedItem is an item in a RankedList.
+type RankedItem struct {
+	Score int
+	Item  interface{}
+}
+
+// TryToInit inits a RankedList if it has not been.
+func (rl *RankedList) TryToInit(n int) {
+	if rl.Capacity == 0 {
+		if n <= 0 {
+			panic("shoould not")
+		}
+		rl.Capacity = n
+		rl.Items = make([]RankedItem, 0, n)
+	}
+}
+
+// Push trys to add a new ranked item.
+// Items with non-positive scores are ignored.
+func (rl *RankedList) Push(n int, obj interface{}) {
+	if n <= 0 {
+		return
+	}
+	if len(rl.Items) == rl.Capacity {
+		if n <= rl.Items[len(rl.Items)-1].Score {
+			return
+		}
+		rl.Items = rl.Items[:len(rl.Items)-1]
+	}
+	i := len(rl.Items)
+	for i > 0 && rl.Items[i-1].Score < n {
+		i--
+	}
+	rl.Items = append(rl.Items, RankedItem{})
+	copy(rl.Items[i+1:], rl.Items[i:])
+	rl.Items[i] = RankedItem{Score: n, Item: obj}
+}
+
 func incSliceStat(stats []int32, index int) {
 	if index >= len(stats) {
 		stats[len(stats)-1]++
@@ -272,3 +325,27 @@ func (d *CodeAnalyzer) stat_OnNewExportedIdentifer(length int, obj interface{})
 	d.stats.ExportedIdentiferLengthTopList.TryToInit(32)
 	d.stats.ExportedIdentiferLengthTopList.Push(length, obj)
 }
+
+func (d *CodeAnalyzer) stat_OnExportedResourceReferenced(res Resource, counts RefCounts) {
+	var rl *RankedList
+	switch res.(type) {
+	default:
+		return
+	case *TypeName:
+		rl = &d.stats.MostReferencedExportedTypeNames
+	case *Function:
+		rl = &d.stats.MostReferencedExportedFunctions
+	case *Variable, *Constant:
+		rl = &d.stats.MostReferencedExportedValues
+	}
+	rl.TryToInit(32)
+	rl.Push(int(counts.Outside()), res)
+}
+
+func (d *CodeAnalyzer) stat_OnExportedStructLayout(tn *TypeName, layout *StructLayout) {
+	if layout == nil {
+		return
+	}
+	d.stats.ExportedStructsWastingMostBytes.TryToInit(32)
+	d.stats.ExportedStructsWastingMostBytes.Push(int(layout.Wasted()), tn)
+}
@@ -0,0 +1,206 @@
+package code
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+
+	"go101.org/golds/internal/util"
+)
+
+// A ModuleVersion is specified by a "module@version" argument,
+// which means all the packages in the module of that version.
+type ModuleVersion struct {
+	Path, Version string
+}
+
+func (mv ModuleVersion) String() string {
+	return mv.Path + "@" + mv.Version
+}
+
+// parseModuleVersionArgument parses a "module@version" argument.
+// Only exact semantic versions are accepted, for queries, such as
+// "latest" and branch names, can't be resolved without network.
+func parseModuleVersionArgument(arg string) (ModuleVersion, bool) {
+	i := strings.LastIndexByte(arg, '@')
+	if i <= 0 || i == len(arg)-1 {
+		return ModuleVersion{}, false
+	}
+	mv := ModuleVersion{Path: arg[:i], Version: arg[i+1:]}
+	if !semver.IsValid(mv.Version) || semver.Canonical(mv.Version) != mv.Version && !strings.HasSuffix(mv.Version, "+incompatible") {
+		return ModuleVersion{}, false
+	}
+	if module.CheckPath(mv.Path) != nil {
+		return ModuleVersion{}, false
+	}
+	return mv, true
+}
+
+// collectModuleVersionArguments returns the parsed results if all the arguments
+// are in the "module@version" form. The packages of different versions of a
+// module share the same import paths, so they must be analyzed in separated
+// projects. Hence the results are grouped, each group contains at most one
+// version of each module. The k-th group contains the k-th specified version
+// of each module, or the last specified one if fewer versions are specified.
+func collectModuleVersionArguments(args []string) [][]ModuleVersion {
+	var paths = make([]string, 0, len(args))
+	var versions = make(map[string][]string, len(args))
+	var numGroups = 0
+	for _, arg := range args {
+		mv, ok := parseModuleVersionArgument(arg)
+		if !ok {
+			return nil
+		}
+		vs, ok := versions[mv.Path]
+		if !ok {
+			paths = append(paths, mv.Path)
+		}
+		for _, v := range vs {
+			if v == mv.Version {
+				goto Next
+			}
+		}
+		vs = append(vs, mv.Version)
+		versions[mv.Path] = vs
+		if len(vs) > numGroups {
+			numGroups = len(vs)
+		}
+	Next:
+	}
+
+	var groups = make([][]ModuleVersion, numGroups)
+	for k := range groups {
+		groups[k] = make([]ModuleVersion, len(paths))
+		for i, path := range paths {
+			vs := versions[path]
+			if k < len(vs) {
+				groups[k][i] = ModuleVersion{Path: path, Version: vs[k]}
+			} else {
+				groups[k][i] = ModuleVersion{Path: path, Version: vs[len(vs)-1]}
+			}
+		}
+	}
+	return groups
+}
+
+// SplitModuleVersionArguments splits the arguments if several versions of
+// a module are specified by "module@version" arguments. Each of the returned
+// argument groups should be analyzed by a separated CodeAnalyzer, so that the
+// module versions can be viewed side by side. A label is also returned for
+// each group, which consists of the versions of the modules with several
+// versions specified. If the arguments don't need to be split, the only
+// group is the arguments themselves and its label is blank.
+func SplitModuleVersionArguments(args []string) (groups [][]string, labels []string) {
+	mvGroups := collectModuleVersionArguments(args)
+	if len(mvGroups) <= 1 {
+		return [][]string{args}, []string{""}
+	}
+
+	groups = make([][]string, len(mvGroups))
+	labels = make([]string, len(mvGroups))
+	for k, mvs := range mvGroups {
+		var label strings.Builder
+		groups[k] = make([]string, len(mvs))
+		for i, mv := range mvs {
+			groups[k][i] = mv.String()
+			for _, other := range mvGroups {
+				if other[i].Version != mv.Version {
+					if label.Len() > 0 {
+						label.WriteByte('+')
+					}
+					label.WriteString(mv.Version)
+					break
+				}
+			}
+		}
+		labels[k] = label.String()
+	}
+	return groups, labels
+}
+
+// moduleCacheDirectory returns the directory of a module version in the
+// module cache. An error is returned if the module version is not cached.
+func moduleCacheDirectory(mv ModuleVersion) (string, error) {
+	output, err := util.RunShell(time.Second*5, "", nil, "go", "env", "GOMODCACHE")
+	if err != nil {
+		return "", fmt.Errorf("go env GOMODCACHE error: %w", err)
+	}
+	modCache := string(bytes.TrimSpace(output))
+	if modCache == "" {
+		return "", errors.New("GOMODCACHE is unknown")
+	}
+
+	escapedPath, err := module.EscapePath(mv.Path)
+	if err != nil {
+		return "", err
+	}
+	escapedVersion, err := module.EscapeVersion(mv.Version)
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(modCache, filepath.FromSlash(escapedPath)+"@"+escapedVersion)
+	if _, err := os.Stat(dir); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf(`%s is not found in the module cache (%s).
+Please download it firstly by running:
+
+	go mod download %s
+`, mv, modCache, mv)
+		}
+		return "", err
+	}
+	return dir, nil
+}
+
+// prepareModuleVersionsProject creates a temp project which requires the
+// specified module versions. The go commands should be run in the project
+// directory with the environments returned by moduleVersionsEnv.
+// Callers should remove the returned directory when it is not used any more.
+func prepareModuleVersionsProject(mvs []ModuleVersion) (string, error) {
+	for _, mv := range mvs {
+		if _, err := moduleCacheDirectory(mv); err != nil {
+			return "", err
+		}
+	}
+
+	tempDir, err := os.MkdirTemp("", "golds-"+filepath.Base(mvs[0].Path)+"@"+mvs[0].Version+"-*")
+	if err != nil {
+		return "", fmt.Errorf("create temp dir error: %w", err)
+	}
+
+	var goMod strings.Builder
+	goMod.WriteString("module golds.app/tmp\n\nrequire (\n")
+	for _, mv := range mvs {
+		fmt.Fprintf(&goMod, "\t%s %s\n", mv.Path, mv.Version)
+	}
+	goMod.WriteString(")\n")
+	if err := os.WriteFile(filepath.Join(tempDir, "go.mod"), []byte(goMod.String()), 0644); err != nil {
+		os.RemoveAll(tempDir)
+		return "", fmt.Errorf("write go.mod error: %w", err)
+	}
+
+	return tempDir, nil
+}
+
+// moduleVersionsEnv returns the environments to resolve module
+// versions (and their dependencies) against the module cache only.
+func moduleVersionsEnv() []string {
+	// Allow go.sum to be completed from the module cache. The checksums
+	// are still verified against the checksum database data cached in
+	// the module cache.
+	env := []string{
+		"GOFLAGS=" + strings.TrimSpace(os.Getenv("GOFLAGS")+" -mod=mod"),
+	}
+	// A file:// proxy is also an offline one.
+	if !strings.HasPrefix(os.Getenv("GOPROXY"), "file://") {
+		env = append(env, "GOPROXY=off")
+	}
+	return env
+}
@@ -6,7 +6,9 @@ import (
 	"go/token"
 	"go/types"
 	"log"
+	"path/filepath"
 	"reflect"
+	"sort"
 	"strings"
 
 	"golang.org/x/tools/go/packages"
@@ -21,6 +23,10 @@ type Module struct {
 	Path    string
 	Version string
 
+	// Whether or not the module is a main module.
+	// In workspace mode, all the modules used in go.work are main modules.
+	Main bool
+
 	// ...
 	Replace moduleReplacement
 
@@ -97,6 +103,20 @@ func (p *Package) Path() string {
 	return p.PPkg.PkgPath // might be prefixed with "vendor/", which is different from import path.
 }
 
+// ExcludedFiles returns the bare names of the Go source files in the package
+// directory which are excluded by build constraints (with the current build
+// tags and target platform).
+func (p *Package) ExcludedFiles() []string {
+	var files []string
+	for _, f := range p.PPkg.IgnoredFiles {
+		if strings.HasSuffix(f, ".go") && !strings.HasSuffix(f, "_test.go") {
+			files = append(files, filepath.Base(f))
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
 // PackageAnalyzeResult holds the analysis result of a Go package.
 type PackageAnalyzeResult struct {
 	AllTypeNames []*TypeName
@@ -457,9 +477,16 @@ type TypeInfo struct {
 	//
 	Aliases []*TypeName
 
-	// ToDo: For unnamed and builtin basic types.
+	// For unnamed and builtin basic types.
+	// The defined types using this type as their underlying type.
 	Underlieds []*TypeName
 
+	// For unnamed struct types. The other unnamed struct types
+	// which are identical to this one if struct tags are ignored.
+	// Defined types with these underlying types are convertible
+	// to each other.
+	IdenticalsIgnoringTags []*TypeInfo
+
 	// For unnamed types (ToDo: need fake identifiers).
 	//UsePositions []token.Position
 
@@ -494,6 +521,12 @@ type TypeInfo struct {
 	AsTypesOf   []ValueResource // variables and constants
 	AsInputsOf  []ValueResource // variables and functions
 	AsOutputsOf []ValueResource // variables and functions
+	// Values which are assignable to values of the type but are not
+	// of the type: functions, methods and variables of the underlying
+	// types for named function types, and variables of the underlying
+	// types for named slice/map types. Only the values in the related
+	// packages are registered (see registerValueForItsTypeName).
+	AssignableValues []ValueResource
 	// ToDo: register variables (of function types) for AsInputsOf and AsOutputsOf
 
 	attributes Attribute // ToDo: fill the bits
@@ -508,6 +541,12 @@ type TypeInfo struct {
 	//counter2 int32
 }
 
+// ID returns the global index of a type.
+// It is unique in an analysis session.
+func (t *TypeInfo) ID() uint32 {
+	return t.index
+}
+
 // Kind returns the kinds (as reflect.Kind) of a type.
 func (t *TypeInfo) Kind() reflect.Kind {
 	return Kind(t.TT)
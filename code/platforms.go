@@ -0,0 +1,437 @@
+package code
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// Platform is a GOOS/GOARCH combination.
+type Platform struct {
+	GOOS, GOARCH string
+}
+
+// String returns the "GOOS/GOARCH" form of a Platform.
+func (p Platform) String() string {
+	return p.GOOS + "/" + p.GOARCH
+}
+
+// ParsePlatform parses a Platform from the "GOOS/GOARCH" form.
+func ParsePlatform(s string) (Platform, error) {
+	i := strings.IndexByte(s, '/')
+	if i <= 0 || i == len(s)-1 || strings.IndexByte(s[i+1:], '/') >= 0 {
+		return Platform{}, fmt.Errorf("invalid platform: %q (should be in the GOOS/GOARCH form)", s)
+	}
+	return Platform{GOOS: s[:i], GOARCH: s[i+1:]}, nil
+}
+
+// env returns the environment variables used to load packages for the platform.
+func (p Platform) env() []string {
+	env := os.Environ()
+	if p.GOOS != "" {
+		env = append(env, "GOOS="+p.GOOS)
+	}
+	if p.GOARCH != "" {
+		env = append(env, "GOARCH="+p.GOARCH)
+	}
+	return env
+}
+
+// goCommandEnv returns the environments to run go commands for a platform.
+func (d *CodeAnalyzer) goCommandEnv(p Platform) []string {
+	return append(p.env(), d.goEnv...)
+}
+
+// PlatformOnlyDeclaration is a package-level declaration (or a method)
+// which doesn't exist on the primary platform.
+type PlatformOnlyDeclaration struct {
+	Recv      string // the receiver base type name for methods, otherwise blank
+	Name      string
+	Platforms []Platform
+
+	// The following fields describe the declaration
+	// on the first one of the above platforms.
+	File        string            // the bare filename
+	Line        int               // the line of the declaration name
+	Doc         string            // the doc comment text
+	Declaration string            // formatted, without comments and function bodies
+	Imports     map[string]string // import names -> import paths of the file
+}
+
+// platformDecls records the Go files and the package-level
+// declarations of a package on one platform.
+type platformDecls struct {
+	files map[string]struct{} // bare filenames
+	decls map[string]string   // "Name" or "Recv.Name" (for methods) -> full filenames
+}
+
+// Platforms returns the target platforms. The first one is the primary
+// platform, which all the analysis is made for. Others are only used to
+// find out the files and declarations which don't exist on all platforms.
+func (d *CodeAnalyzer) Platforms() []Platform {
+	return d.platforms
+}
+
+// IsMultiPlatform returns whether or not packages are analyzed for more than one platform.
+func (d *CodeAnalyzer) IsMultiPlatform() bool {
+	return len(d.platforms) > 1
+}
+
+// DeclarationPlatforms returns the platforms on which a package-level declaration
+// exists. For a method, recv should be the base name of its receiver type.
+// The result is nil if the declaration exists on all the target platforms.
+func (d *CodeAnalyzer) DeclarationPlatforms(pkgPath, recv, name string) []Platform {
+	key := name
+	if recv != "" {
+		key = recv + "." + name
+	}
+	return d.findPlatforms(pkgPath, func(pd *platformDecls) bool {
+		_, ok := pd.decls[key]
+		return ok
+	})
+}
+
+// FilePlatforms returns the platforms on which a Go source file of a package is
+// compiled. The result is nil if the file is used on all the target platforms.
+func (d *CodeAnalyzer) FilePlatforms(pkgPath, bareFilename string) []Platform {
+	return d.findPlatforms(pkgPath, func(pd *platformDecls) bool {
+		_, ok := pd.files[bareFilename]
+		return ok
+	})
+}
+
+func (d *CodeAnalyzer) findPlatforms(pkgPath string, exists func(*platformDecls) bool) []Platform {
+	pds := d.packagePlatformDecls[pkgPath]
+	if pds == nil {
+		return nil
+	}
+	var platforms []Platform
+	for i, pd := range pds {
+		if pd != nil && exists(pd) {
+			platforms = append(platforms, d.platforms[i])
+		}
+	}
+	if len(platforms) == len(d.platforms) {
+		return nil
+	}
+	return platforms
+}
+
+// PlatformOnlyDeclarations returns the declarations of a package
+// which exist on some other platforms but not the primary platform.
+// The files declaring them are parsed again to get their details.
+func (d *CodeAnalyzer) PlatformOnlyDeclarations(pkgPath string) []PlatformOnlyDeclaration {
+	pds := d.packagePlatformDecls[pkgPath]
+	if pds == nil {
+		return nil
+	}
+	return platformOnlyDeclarations(pds, d.platforms)
+}
+
+func platformOnlyDeclarations(pds []*platformDecls, platforms []Platform) []PlatformOnlyDeclaration {
+	var table = make(map[string]*PlatformOnlyDeclaration)
+	var files = make(map[string]string) // keys -> full filenames
+	for i, pd := range pds[1:] {
+		if pd == nil {
+			continue
+		}
+		for key, filename := range pd.decls {
+			if pds[0] != nil {
+				if _, ok := pds[0].decls[key]; ok {
+					continue
+				}
+			}
+			decl := table[key]
+			if decl == nil {
+				decl = &PlatformOnlyDeclaration{Name: key}
+				if i := strings.IndexByte(key, '.'); i >= 0 {
+					decl.Recv, decl.Name = key[:i], key[i+1:]
+				}
+				table[key] = decl
+				files[key] = filename
+			}
+			decl.Platforms = append(decl.Platforms, platforms[i+1])
+		}
+	}
+
+	var fset = token.NewFileSet()
+	var parsedFiles = make(map[string]*ast.File)
+	var list = make([]PlatformOnlyDeclaration, 0, len(table))
+	for key, decl := range table {
+		filename := files[key]
+		file, ok := parsedFiles[filename]
+		if !ok {
+			file, _ = parser.ParseFile(fset, filename, nil, parser.ParseComments|parser.SkipObjectResolution)
+			parsedFiles[filename] = file
+		}
+		decl.File = filepath.Base(filename)
+		if file != nil {
+			describePlatformOnlyDeclaration(fset, file, decl)
+		}
+		list = append(list, *decl)
+	}
+	sort.Slice(list, func(a, b int) bool {
+		if list[a].Recv != list[b].Recv {
+			return list[a].Recv < list[b].Recv
+		}
+		return list[a].Name < list[b].Name
+	})
+	return list
+}
+
+// describePlatformOnlyDeclaration finds the declaration in the file
+// and fills the position, doc and declaration text fields.
+func describePlatformOnlyDeclaration(fset *token.FileSet, file *ast.File, decl *PlatformOnlyDeclaration) {
+	var ident *ast.Ident
+	var doc *ast.CommentGroup
+	var node ast.Node
+Loop:
+	for _, d := range file.Decls {
+		switch d := d.(type) {
+		case *ast.FuncDecl:
+			if d.Name.Name != decl.Name {
+				continue
+			}
+			recv := ""
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				recv = receiverBaseTypeName(d.Recv.List[0].Type)
+			}
+			if recv != decl.Recv {
+				continue
+			}
+			fd := *d
+			fd.Doc, fd.Body = nil, nil
+			ident, doc, node = d.Name, d.Doc, &fd
+			break Loop
+		case *ast.GenDecl:
+			if decl.Recv != "" {
+				continue
+			}
+			for _, spec := range d.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					if spec.Name.Name == decl.Name {
+						ts := *spec
+						ts.Doc, ts.Comment = nil, nil
+						ident, doc, node = spec.Name, spec.Doc, &ast.GenDecl{Tok: d.Tok, Specs: []ast.Spec{&ts}}
+					}
+				case *ast.ValueSpec:
+					for _, id := range spec.Names {
+						if id.Name == decl.Name {
+							vs := *spec
+							vs.Doc, vs.Comment = nil, nil
+							ident, doc, node = id, spec.Doc, &ast.GenDecl{Tok: d.Tok, Specs: []ast.Spec{&vs}}
+						}
+					}
+				}
+				if ident != nil {
+					if doc == nil {
+						doc = d.Doc
+					}
+					break Loop
+				}
+			}
+		}
+	}
+	if ident == nil {
+		return
+	}
+
+	decl.Line = fset.PositionFor(ident.Pos(), false).Line
+	decl.Doc = doc.Text()
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, node); err == nil {
+		decl.Declaration = buf.String()
+	}
+	decl.Imports = make(map[string]string, len(file.Imports))
+	for _, imp := range file.Imports {
+		path, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			continue
+		}
+		name := path[strings.LastIndexByte(path, '/')+1:]
+		if imp.Name != nil {
+			name = imp.Name.Name
+		}
+		decl.Imports[name] = path
+	}
+}
+
+// collectPlatformDifferences loads the packages for the non-primary platforms,
+// then records the Go files and the declarations of each package on each platform.
+// The heavy type checking is only done for the primary platform.
+func (d *CodeAnalyzer) collectPlatformDifferences(args []string) error {
+	if !d.IsMultiPlatform() {
+		return nil
+	}
+
+	d.packagePlatformDecls = make(map[string][]*platformDecls, len(d.packageList))
+	var register = func(index int, ppkg *packages.Package) {
+		if _, ok := d.packageTable[ppkg.PkgPath]; !ok {
+			return // only exists on non-primary platforms
+		}
+		pds := d.packagePlatformDecls[ppkg.PkgPath]
+		if pds == nil {
+			pds = make([]*platformDecls, len(d.platforms))
+			d.packagePlatformDecls[ppkg.PkgPath] = pds
+		}
+		pds[index] = collectPlatformDecls(ppkg.GoFiles)
+	}
+
+	for _, pkg := range d.packageList {
+		if pkg.PPkg.PkgPath == "builtin" || pkg.PPkg.PkgPath == "unsafe" {
+			continue
+		}
+		register(0, pkg.PPkg)
+	}
+
+	for i, p := range d.platforms[1:] {
+		config := &packages.Config{
+			Mode:       packages.NeedName | packages.NeedFiles | packages.NeedImports | packages.NeedDeps,
+			Dir:        d.goDir,
+			Env:        d.goCommandEnv(p),
+			BuildFlags: buildTagsFlags(d.buildTags),
+		}
+		ppkgs, err := packages.Load(config, args...)
+		if err != nil {
+			return fmt.Errorf("packages.Load (list packages for %s): %w", p, err)
+		}
+		if len(ppkgs) == 0 {
+			return errors.New("packages.Load: no packages found for " + p.String())
+		}
+		for _, ppkg := range collectPPackages(ppkgs) {
+			if ppkg.PkgPath == "unsafe" {
+				continue
+			}
+			register(i+1, ppkg)
+		}
+	}
+
+	return nil
+}
+
+func collectPlatformDecls(goFiles []string) *platformDecls {
+	pd := &platformDecls{
+		files: make(map[string]struct{}, len(goFiles)),
+		decls: make(map[string]string, 32),
+	}
+	fset := token.NewFileSet()
+	for _, filename := range goFiles {
+		pd.files[filepath.Base(filename)] = struct{}{}
+
+		// Errors are ignored here. The declarations in a partially
+		// parsed file are still collected.
+		file, _ := parser.ParseFile(fset, filename, nil, parser.SkipObjectResolution)
+		if file == nil {
+			continue
+		}
+		for _, decl := range file.Decls {
+			switch decl := decl.(type) {
+			case *ast.FuncDecl:
+				name := decl.Name.Name
+				if decl.Recv == nil {
+					if name != "init" && name != "_" {
+						pd.decls[name] = filename
+					}
+				} else if len(decl.Recv.List) > 0 {
+					if recv := receiverBaseTypeName(decl.Recv.List[0].Type); recv != "" {
+						pd.decls[recv+"."+name] = filename
+					}
+				}
+			case *ast.GenDecl:
+				for _, spec := range decl.Specs {
+					switch spec := spec.(type) {
+					case *ast.TypeSpec:
+						pd.decls[spec.Name.Name] = filename
+					case *ast.ValueSpec:
+						for _, id := range spec.Names {
+							if id.Name != "_" {
+								pd.decls[id.Name] = filename
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	return pd
+}
+
+func receiverBaseTypeName(expr ast.Expr) string {
+	for {
+		switch e := expr.(type) {
+		default:
+			return ""
+		case *ast.Ident:
+			return e.Name
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.ParenExpr:
+			expr = e.X
+		case *astIndexExpr:
+			expr = e.X
+		case *astIndexListExpr:
+			expr = e.X
+		}
+	}
+}
+
+// resolvePlatforms fills the blank GOOS/GOARCH fields with the host ones.
+func resolvePlatforms(platforms []Platform) []Platform {
+	if len(platforms) == 0 {
+		platforms = []Platform{{}}
+	}
+	var resolved = make([]Platform, len(platforms))
+	for i, p := range platforms {
+		if p.GOOS == "" {
+			p.GOOS = build.Default.GOOS
+		}
+		if p.GOARCH == "" {
+			p.GOARCH = build.Default.GOARCH
+		}
+		resolved[i] = p
+	}
+	return resolved
+}
+
+// ResourcePlatforms returns the platforms on which a package-level resource
+// (or a method) is declared. The result is nil if it exists on all the target
+// platforms, or its existences on non-primary platforms are unknown.
+func (d *CodeAnalyzer) ResourcePlatforms(res Resource) []Platform {
+	if !d.IsMultiPlatform() {
+		return nil
+	}
+	switch res := res.(type) {
+	default:
+		return nil
+	case *Function:
+		if res.AstDecl != nil && res.AstDecl.Recv != nil && len(res.AstDecl.Recv.List) > 0 {
+			recv := receiverBaseTypeName(res.AstDecl.Recv.List[0].Type)
+			return d.DeclarationPlatforms(res.Package().Path(), recv, res.Name())
+		}
+	case *TypeName, *Variable, *Constant:
+	}
+	return d.DeclarationPlatforms(res.Package().Path(), "", res.Name())
+}
+
+// MethodPlatforms returns the platforms on which an explicitly declared method
+// exists. The result is nil for interface methods or if the method exists on
+// all the target platforms.
+func (d *CodeAnalyzer) MethodPlatforms(m *Method) []Platform {
+	if !d.IsMultiPlatform() || m.AstFunc == nil || m.AstFunc.Recv == nil || len(m.AstFunc.Recv.List) == 0 {
+		return nil
+	}
+	return d.DeclarationPlatforms(m.Pkg.Path(), receiverBaseTypeName(m.AstFunc.Recv.List[0].Type), m.Name)
+}
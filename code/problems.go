@@ -0,0 +1,175 @@
+package code
+
+import (
+	"fmt"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// ProblemKind is the kind of a Problem.
+type ProblemKind string
+
+const (
+	ProblemKind_List    ProblemKind = "list"
+	ProblemKind_Parse   ProblemKind = "parse"
+	ProblemKind_Type    ProblemKind = "type"
+	ProblemKind_Analyze ProblemKind = "analyze"
+	ProblemKind_Unknown ProblemKind = "unknown"
+)
+
+// A Problem is an error met when loading, type-checking or analyzing a package.
+// In tolerant mode, problems don't stop the analysis.
+type Problem struct {
+	PkgPath string
+	Kind    ProblemKind
+	Pos     token.Position // Filename might be blank
+	Msg     string
+}
+
+func (p *Problem) String() string {
+	if p.Pos.Filename == "" {
+		return fmt.Sprintf("%s: %s", p.PkgPath, p.Msg)
+	}
+	return fmt.Sprintf("%s: %s", p.Pos, p.Msg)
+}
+
+// IsTolerant returns whether or not packages with errors are still analyzed.
+func (d *CodeAnalyzer) IsTolerant() bool {
+	return d.tolerant
+}
+
+// Problems returns all the problems met when loading and analyzing packages,
+// sorted by package paths and positions.
+func (d *CodeAnalyzer) Problems() []*Problem {
+	return d.problems
+}
+
+// PackageProblems returns the problems of a package.
+func (d *CodeAnalyzer) PackageProblems(pkgPath string) []*Problem {
+	return d.packageProblems[pkgPath]
+}
+
+func (d *CodeAnalyzer) registerProblem(p *Problem) {
+	d.problems = append(d.problems, p)
+	if d.packageProblems == nil {
+		d.packageProblems = make(map[string][]*Problem)
+	}
+	d.packageProblems[p.PkgPath] = append(d.packageProblems[p.PkgPath], p)
+}
+
+func (d *CodeAnalyzer) sortProblems() {
+	sort.SliceStable(d.problems, func(a, b int) bool {
+		pa, pb := d.problems[a], d.problems[b]
+		if pa.PkgPath != pb.PkgPath {
+			return pa.PkgPath < pb.PkgPath
+		}
+		if pa.Pos.Filename != pb.Pos.Filename {
+			return pa.Pos.Filename < pb.Pos.Filename
+		}
+		return pa.Pos.Line < pb.Pos.Line
+	})
+}
+
+// registerUnresolvable records an object which can't be resolved in an ill-typed
+// package. It returns false in non-tolerant mode, in which case, callers should panic.
+func (d *CodeAnalyzer) registerUnresolvable(pkg *Package, pos token.Pos, what string) bool {
+	if !d.tolerant {
+		return false
+	}
+	d.registerProblem(&Problem{
+		PkgPath: pkg.Path(),
+		Kind:    ProblemKind_Analyze,
+		Pos:     pkg.PPkg.Fset.PositionFor(pos, false),
+		Msg:     "skipped unresolvable " + what,
+	})
+	return true
+}
+
+// recoverAnalyzeProblem should be deferred directly. In tolerant mode, it
+// recovers from the panic in an analyze phase and records it as a problem.
+// pkgPath is blank if the problem can't be attributed to a package.
+func (d *CodeAnalyzer) recoverAnalyzeProblem(pkgPath, phase string) {
+	if !d.tolerant {
+		return
+	}
+	if r := recover(); r != nil {
+		d.registerProblem(&Problem{
+			PkgPath: pkgPath,
+			Kind:    ProblemKind_Analyze,
+			Msg:     fmt.Sprintf("%s: %v", phase, r),
+		})
+	}
+}
+
+// analyzeTolerantly calls f with the package. In tolerant mode,
+// a panic in f only stops the phase for the package.
+func (d *CodeAnalyzer) analyzeTolerantly(pkg *Package, phase string, f func(*Package)) {
+	defer d.recoverAnalyzeProblem(pkg.Path(), phase)
+	f(pkg)
+}
+
+// analyzeTypeTolerantly is like analyzeTolerantly, but for the phases which
+// handle all types together. In tolerant mode, a panic in f only stops the
+// phase for the type. The problem is recorded for the package declaring
+// the type, or with a blank package path for unnamed types.
+func (d *CodeAnalyzer) analyzeTypeTolerantly(t *TypeInfo, phase string, f func(*TypeInfo)) {
+	var pkgPath string
+	if t.TypeName != nil && t.TypeName.Pkg != nil {
+		pkgPath = t.TypeName.Pkg.Path()
+	}
+	defer d.recoverAnalyzeProblem(pkgPath, phase)
+	f(t)
+}
+
+func newLoadProblem(pkgPath string, e packages.Error) *Problem {
+	p := &Problem{
+		PkgPath: pkgPath,
+		Msg:     e.Msg,
+		Pos:     parseErrorPosition(e.Pos),
+	}
+	switch e.Kind {
+	case packages.ListError:
+		p.Kind = ProblemKind_List
+	case packages.ParseError:
+		p.Kind = ProblemKind_Parse
+	case packages.TypeError:
+		p.Kind = ProblemKind_Type
+	default:
+		p.Kind = ProblemKind_Unknown
+	}
+	return p
+}
+
+// parseErrorPosition parses positions in the "file:line:column",
+// "file:line" or "file" forms. "-" and blank mean unknown positions.
+func parseErrorPosition(pos string) token.Position {
+	if pos == "" || pos == "-" {
+		return token.Position{}
+	}
+	var p token.Position
+	var numbers []int
+	for len(numbers) < 2 {
+		i := strings.LastIndexByte(pos, ':')
+		if i < 0 {
+			break
+		}
+		n, err := strconv.Atoi(pos[i+1:])
+		if err != nil {
+			break
+		}
+		numbers = append(numbers, n)
+		pos = pos[:i]
+	}
+	p.Filename = pos
+	switch len(numbers) {
+	case 2:
+		p.Line, p.Column = numbers[1], numbers[0]
+	case 1:
+		p.Line = numbers[0]
+	}
+	return p
+}
@@ -6,7 +6,6 @@ import (
 	"go/ast"
 	"go/doc"
 	"go/parser"
-	"go/token"
 	"go/types"
 	"io/ioutil"
 	"log"
@@ -245,22 +244,20 @@ Done:
 	}
 }
 
-func (d *CodeAnalyzer) collectObjectReferences() {
-	for _, pkg := range d.packageList {
-		for i := range pkg.SourceFiles {
-			info := &pkg.SourceFiles[i]
-			// This if-block is still needed for std packages.
-			// For other packages, this field has been set in confirmPackageModules.
-			if pkg.Directory == "" && info.OriginalFile != "" {
-				pkg.Directory = filepath.Dir(info.OriginalFile)
-			}
-			//log.Println("===", info.OriginalGoFile)
-			//log.Println("   ", info.GeneratedFile, info.GoFileContentOffset)
-			if info.AstFile == nil {
-				continue
-			}
-			d.collectIdentiferFromFile(pkg, info)
+func (d *CodeAnalyzer) collectObjectReferences(pkg *Package) {
+	for i := range pkg.SourceFiles {
+		info := &pkg.SourceFiles[i]
+		// This if-block is still needed for std packages.
+		// For other packages, this field has been set in confirmPackageModules.
+		if pkg.Directory == "" && info.OriginalFile != "" {
+			pkg.Directory = filepath.Dir(info.OriginalFile)
+		}
+		//log.Println("===", info.OriginalGoFile)
+		//log.Println("   ", info.GeneratedFile, info.GoFileContentOffset)
+		if info.AstFile == nil {
+			continue
 		}
+		d.collectIdentiferFromFile(pkg, info)
 	}
 }
 
@@ -332,51 +329,49 @@ func (d *CodeAnalyzer) cacheSourceFiles() {
 	}
 }
 
-func (d *CodeAnalyzer) collectCodeExamples() {
-	collectExampleFiles := func(pkg *Package) []string {
-		filenames := make([]string, 0, 8)
-		first := true
-		if err := filepath.Walk(pkg.Directory, func(p string, info os.FileInfo, err error) error {
-			if err != nil {
-				return err
-			}
-			if info.IsDir() {
-				if first {
-					first = false
-					return nil
-				}
-				return filepath.SkipDir
-			}
-			name := info.Name()
-			if strings.HasPrefix(name, "example_") && strings.HasSuffix(name, "_test.go") {
-				filenames = append(filenames, name)
+func (d *CodeAnalyzer) collectExampleFiles(pkg *Package) []string {
+	filenames := make([]string, 0, 8)
+	first := true
+	if err := filepath.Walk(pkg.Directory, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if first {
+				first = false
+				return nil
 			}
-			return nil
-		}); err != nil {
-			log.Printf("walk package %s dir %s error: %s", pkg.Path(), pkg.Directory, err)
+			return filepath.SkipDir
+		}
+		name := info.Name()
+		if strings.HasPrefix(name, "example_") && strings.HasSuffix(name, "_test.go") {
+			filenames = append(filenames, name)
 		}
-		return filenames
+		return nil
+	}); err != nil {
+		log.Printf("walk package %s dir %s error: %s", pkg.Path(), pkg.Directory, err)
 	}
+	return filenames
+}
 
-	d.exampleFileSet = token.NewFileSet()
-	for _, pkg := range d.packageList {
-		if pkg.ExampleFiles != nil {
+// d.exampleFileSet must be set before calling this method.
+func (d *CodeAnalyzer) collectCodeExamples(pkg *Package) {
+	if pkg.ExampleFiles != nil {
+		return
+	}
+	filenames := d.collectExampleFiles(pkg)
+	pkg.ExampleFiles = make([]*ast.File, 0, len(filenames))
+	for _, f := range filenames {
+		f := filepath.Join(pkg.Directory, f)
+		astFile, err := parser.ParseFile(d.exampleFileSet, f, nil, parser.ParseComments)
+		if err != nil {
+			fmt.Printf("parse file %s error: %s", f, err)
 			continue
 		}
-		filenames := collectExampleFiles(pkg)
-		pkg.ExampleFiles = make([]*ast.File, 0, len(filenames))
-		for _, f := range filenames {
-			f := filepath.Join(pkg.Directory, f)
-			astFile, err := parser.ParseFile(d.exampleFileSet, f, nil, parser.ParseComments)
-			if err != nil {
-				fmt.Printf("parse file %s error: %s", f, err)
-				continue
-			}
-			pkg.ExampleFiles = append(pkg.ExampleFiles, astFile)
-		}
-		pkg.Examples = doc.Examples(pkg.ExampleFiles...)
-		//if !d.IsStandardPackage(pkg) && len(pkg.Examples) > 0 {
-		//	log.Println("======= has examples:", pkg.Path())
-		//}
+		pkg.ExampleFiles = append(pkg.ExampleFiles, astFile)
 	}
+	pkg.Examples = doc.Examples(pkg.ExampleFiles...)
+	//if !d.IsStandardPackage(pkg) && len(pkg.Examples) > 0 {
+	//	log.Println("======= has examples:", pkg.Path())
+	//}
 }
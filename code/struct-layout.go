@@ -0,0 +1,143 @@
+package code
+
+import (
+	"go/build"
+	"go/types"
+	"sort"
+)
+
+// FieldLayout describes the memory layout of a struct field.
+type FieldLayout struct {
+	Name     string
+	Embedded bool
+	Type     types.Type
+
+	// Offset is relative to the start of the outermost struct.
+	Offset, Size, Align int64
+
+	// The padding bytes between the field and the next one
+	// (or the end of the struct) at the same depth.
+	Padding int64
+
+	// 0 for the direct fields. Fields promoted from an embedded
+	// (non-pointer) struct field are listed right after the
+	// embedded field, with their depths increased by one.
+	Depth int
+}
+
+// StructLayout describes the memory layout of a struct type.
+type StructLayout struct {
+	Fields []FieldLayout
+
+	Size, Align int64
+	Padding     int64 // the sum of the paddings of the direct fields
+
+	// The minimum size achievable by reordering the direct fields,
+	// and the order (indexes in Fields) to achieve it.
+	OptimalSize  int64
+	OptimalOrder []int
+}
+
+// Wasted returns the number of bytes which could be saved by reordering fields.
+func (sl *StructLayout) Wasted() int64 {
+	return sl.Size - sl.OptimalSize
+}
+
+// StructLayoutOf calculates the memory layout of the struct type denoted
+// by a type name, by using the types.Sizes of the package declaring it.
+// The result is nil if the type is not a struct type or is parameterized.
+func (d *CodeAnalyzer) StructLayoutOf(tn *TypeName) *StructLayout {
+	tt := tn.Denoting().TT
+	st, ok := tt.Underlying().(*types.Struct)
+	if !ok || isParameterizedType(tt) {
+		return nil
+	}
+
+	var sizes types.Sizes
+	if tn.Pkg.PPkg != nil {
+		sizes = tn.Pkg.PPkg.TypesSizes
+	}
+	if sizes == nil {
+		// Use the architecture of the primary platform,
+		// which the analysis is made for.
+		goarch := build.Default.GOARCH
+		if len(d.platforms) > 0 {
+			goarch = d.platforms[0].GOARCH
+		}
+		sizes = types.SizesFor("gc", goarch)
+	}
+	return structLayout(st, sizes)
+}
+
+func structLayout(st *types.Struct, sizes types.Sizes) *StructLayout {
+	sl := &StructLayout{
+		Size:  sizes.Sizeof(st),
+		Align: sizes.Alignof(st),
+	}
+
+	var direct []int
+	var collectFields func(st *types.Struct, base, end int64, depth int)
+	collectFields = func(st *types.Struct, base, end int64, depth int) {
+		vars := make([]*types.Var, st.NumFields())
+		for i := range vars {
+			vars[i] = st.Field(i)
+		}
+		offsets := sizes.Offsetsof(vars)
+		for i, v := range vars {
+			size := sizes.Sizeof(v.Type())
+			next := end
+			if i+1 < len(vars) {
+				next = base + offsets[i+1]
+			}
+			if depth == 0 {
+				direct = append(direct, len(sl.Fields))
+			}
+			sl.Fields = append(sl.Fields, FieldLayout{
+				Name:     v.Name(),
+				Embedded: v.Embedded(),
+				Type:     v.Type(),
+				Offset:   base + offsets[i],
+				Size:     size,
+				Align:    sizes.Alignof(v.Type()),
+				Padding:  next - base - offsets[i] - size,
+				Depth:    depth,
+			})
+			if depth == 0 {
+				sl.Padding += sl.Fields[len(sl.Fields)-1].Padding
+			}
+			if v.Embedded() {
+				if est, ok := v.Type().Underlying().(*types.Struct); ok {
+					collectFields(est, base+offsets[i], base+offsets[i]+size, depth+1)
+				}
+			}
+		}
+	}
+	collectFields(st, 0, sl.Size, 0)
+
+	// Zero-size fields are put firstly, for a zero-size final
+	// field causes extra padding. Then sort by alignments.
+	order := make([]int, len(direct))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		fa, fb := &sl.Fields[direct[order[a]]], &sl.Fields[direct[order[b]]]
+		if za, zb := fa.Size == 0, fb.Size == 0; za != zb {
+			return za
+		}
+		return fa.Align > fb.Align
+	})
+	vars := make([]*types.Var, len(order))
+	sl.OptimalOrder = make([]int, len(order))
+	for i, k := range order {
+		vars[i] = st.Field(k)
+		sl.OptimalOrder[i] = direct[k]
+	}
+	sl.OptimalSize = sizes.Sizeof(types.NewStruct(vars, nil))
+	if sl.OptimalSize >= sl.Size {
+		sl.OptimalSize = sl.Size
+		sl.OptimalOrder = direct
+	}
+
+	return sl
+}
@@ -0,0 +1,150 @@
+package app
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"go101.org/golds/internal/util"
+)
+
+func newTestFlagSet(t *testing.T, dir string, config string, args ...string) *flag.FlagSet {
+	flags := flag.NewFlagSet("golds", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	flags.Bool("tolerant", false, "")
+	flags.Bool("nouses", false, "")
+	flags.String("footer", "verbose+qrcode", "")
+	flags.String("tags", "", "")
+	flags.String("port", "", "")
+	flags.String("code-hosts", "", "")
+	flags.Bool("version", false, "")
+	if err := flags.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+
+	oldDir := util.WorkingDirectory()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(oldDir) })
+	if config != "" {
+		if err := os.WriteFile(".golds.toml", []byte(config), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return flags
+}
+
+func TestLoadProjectConfig(t *testing.T) {
+	flags := newTestFlagSet(t, t.TempDir(), `
+packages = ["./...", "std", "./with space"]
+tolerant = true
+footer = "simple"
+tags = ["a", "b"]
+port = 8080
+
+[[code-hosts]]
+modulePathPrefix = "git.example.com/"
+repositoryURLPattern = "https://git.example.com/{1}/{2}"
+sourceLinkTemplate = "/-/blob/{commit}{path}[#L{line}[-{endLine}]]"
+`, "-footer=none", "-nouses")
+
+	config, err := loadProjectConfig(flags)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.File != ".golds.toml" {
+		t.Errorf("config file: %s", config.File)
+	}
+	if got := strings.Join(config.Packages, "|"); got != "./...|std|./with space" {
+		t.Errorf("packages: %s", got)
+	}
+	for name, want := range map[string]string{
+		"tolerant": "true",  // from file
+		"footer":   "none",  // command line overrides file
+		"nouses":   "true",  // command line only
+		"tags":     "a,b",   // arrays are joined
+		"port":     "8080",  // integers are converted
+		"version":  "false", // default
+	} {
+		if got := flags.Lookup(name).Value.String(); got != want {
+			t.Errorf("option %s: got %s, want %s", name, got, want)
+		}
+	}
+	if !config.fromFile["tolerant"] || config.fromFile["footer"] || !config.setInCommandLine["footer"] {
+		t.Errorf("wrong option sources: %v, %v", config.fromFile, config.setInCommandLine)
+	}
+	if len(config.CodeHosts) != 1 || config.CodeHosts[0].ModulePathPrefix != "git.example.com/" {
+		t.Errorf("code hosts: %+v", config.CodeHosts)
+	}
+
+	var buf bytes.Buffer
+	config.print(&buf, flags, []string{`a"b\c`, "./..."})
+	output := buf.String()
+	for _, line := range []string{
+		`packages = ["a\"b\\c", "./..."]`,
+		`tolerant = true # .golds.toml`,
+		`footer = "none" # command line`,
+		`tags = "a,b" # .golds.toml`,
+		`port = "8080" # .golds.toml`,
+	} {
+		if !strings.Contains(output, line+"\n") {
+			t.Errorf("line %q is not printed in:\n%s", line, output)
+		}
+	}
+	if strings.Contains(output, "version") {
+		t.Errorf("non-configurable options should not be printed:\n%s", output)
+	}
+	// The printed configuration is a valid config file.
+	values, err := util.ParseTOML(buf.Bytes())
+	if err != nil {
+		t.Fatalf("parse printed configuration error: %s\n%s", err, output)
+	}
+	if packages, _ := values["packages"].([]interface{}); len(packages) != 2 || packages[0] != `a"b\c` {
+		t.Errorf("printed packages: %v", values["packages"])
+	}
+
+	// A single package string is not split.
+	flags = newTestFlagSet(t, t.TempDir(), "")
+	if err := os.WriteFile(".golds.json", []byte(`{"packages": "./a b/..."}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if config, err := loadProjectConfig(flags); err != nil {
+		t.Error(err)
+	} else if len(config.Packages) != 1 || config.Packages[0] != "./a b/..." {
+		t.Errorf("packages: %q", config.Packages)
+	}
+}
+
+func TestLoadProjectConfigErrors(t *testing.T) {
+	for _, c := range []struct {
+		config string
+		err    string
+	}{
+		{`unknown = 1`, "unknown option: unknown"},
+		{`version = true`, "unknown option: version"},
+		{`tolerant = "maybe"`, "tolerant"},
+		{`tags = [["a"]]`, "nested arrays are not supported"},
+		{`footer = "a\qb"`, "invalid escape sequence"},
+		{`packages = ["./...", 1]`, "packages: 1 is not a string"},
+		{`packages = true`, "packages: unsupported value"},
+	} {
+		flags := newTestFlagSet(t, t.TempDir(), c.config)
+		if _, err := loadProjectConfig(flags); err == nil || !strings.Contains(err.Error(), c.err) {
+			t.Errorf("config %s: error %v, want %q", c.config, err, c.err)
+		}
+	}
+
+	// Both .golds.toml and .golds.json exist.
+	dir := t.TempDir()
+	flags := newTestFlagSet(t, dir, `tolerant = true`)
+	if err := os.WriteFile(".golds.json", []byte(`{"tolerant": true}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadProjectConfig(flags); err == nil || !strings.Contains(err.Error(), "please only keep one") {
+		t.Errorf("two config files: error %v", err)
+	}
+}
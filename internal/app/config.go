@@ -0,0 +1,254 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go101.org/golds/internal/server"
+	"go101.org/golds/internal/util"
+)
+
+// The project config files, which are looked up in the working directory.
+// The keys in them are the option names (without the leading "-"), plus
+// "packages" for the default arguments. For example, in .golds.toml:
+//
+//	packages = ["./..."]
+//	wdpkgs-listing = "promoted"
+//	source-code-reading = "external"
+//	tags = ["integration"]
+//
+//	[[code-hosts]]
+//	modulePathPrefix = "git.example.com/"
+//	repositoryURLPattern = "https://git.example.com/{1}/{2}"
+//	sourceLinkTemplate = "/-/blob/{commit}{path}[#L{line}[-{endLine}]]"
+//
+// The options specified in command lines override the ones in config files.
+var projectConfigFiles = []string{".golds.toml", ".golds.json"}
+
+// The options which make no sense in config files.
+var nonConfigurableOptions = map[string]bool{
+	"h":                true,
+	"help":             true,
+	"version":          true,
+	"rough-build-time": true,
+}
+
+type projectConfig struct {
+	File string // blank for no config files
+
+	Packages  []string
+	CodeHosts []server.CodeHostConfig // the ones declared inline
+
+	fromFile         map[string]bool // the options set by the config file
+	setInCommandLine map[string]bool
+}
+
+// loadProjectConfig loads the config file in the working directory (if it
+// exists) and sets the options in the flag set which are not specified in
+// the command line. The flag set should have parsed the command line.
+func loadProjectConfig(flags *flag.FlagSet) (*projectConfig, error) {
+	config := &projectConfig{
+		fromFile:         make(map[string]bool),
+		setInCommandLine: make(map[string]bool),
+	}
+	flags.Visit(func(f *flag.Flag) {
+		config.setInCommandLine[f.Name] = true
+	})
+
+	var data []byte
+	for _, filename := range projectConfigFiles {
+		d, err := os.ReadFile(filename)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if config.File != "" {
+			return nil, fmt.Errorf("both %s and %s exist, please only keep one", config.File, filename)
+		}
+		config.File, data = filename, d
+	}
+	if config.File == "" {
+		return config, nil
+	}
+
+	var values map[string]interface{}
+	var err error
+	if strings.HasSuffix(config.File, ".toml") {
+		values, err = util.ParseTOML(data)
+	} else {
+		err = json.Unmarshal(data, &values)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse %s error: %w", config.File, err)
+	}
+
+	setInCommandLine := config.setInCommandLine
+	var keys = make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := values[key]
+		switch key {
+		case "packages":
+			packages, err := configPackages(value)
+			if err != nil {
+				return nil, fmt.Errorf("%s: packages: %w", config.File, err)
+			}
+			config.Packages = packages
+			continue
+		case "code-hosts":
+			if _, ok := value.([]interface{}); !ok {
+				break // a file path
+			}
+			if setInCommandLine[key] {
+				continue
+			}
+			data, err := json.Marshal(value)
+			if err == nil {
+				err = json.Unmarshal(data, &config.CodeHosts)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("%s: code-hosts: %w", config.File, err)
+			}
+			config.fromFile[key] = true
+			continue
+		}
+
+		if nonConfigurableOptions[key] || flags.Lookup(key) == nil {
+			return nil, fmt.Errorf("%s: unknown option: %s", config.File, key)
+		}
+		s, err := configValueString(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", config.File, key, err)
+		}
+		if setInCommandLine[key] {
+			continue
+		}
+		if err := flags.Set(key, s); err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", config.File, key, err)
+		}
+		config.fromFile[key] = true
+	}
+
+	return config, nil
+}
+
+// configPackages converts the "packages" config value, which is
+// either an array of strings or a single string, to arguments.
+// The strings are not split, so that paths may contain spaces.
+func configPackages(value interface{}) ([]string, error) {
+	switch v := value.(type) {
+	case string:
+		return []string{v}, nil
+	case []interface{}:
+		var packages = make([]string, len(v))
+		for i, e := range v {
+			s, ok := e.(string)
+			if !ok {
+				return nil, fmt.Errorf("%v is not a string", e)
+			}
+			packages[i] = s
+		}
+		return packages, nil
+	}
+	return nil, fmt.Errorf("unsupported value: %v", value)
+}
+
+// configValueString converts a config value to an option value.
+// The elements of an array are joined with commas.
+func configValueString(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64: // numbers in JSON
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case []interface{}:
+		var items = make([]string, len(v))
+		for i, e := range v {
+			if _, ok := e.([]interface{}); ok {
+				return "", errors.New("nested arrays are not supported")
+			}
+			s, err := configValueString(e)
+			if err != nil {
+				return "", err
+			}
+			items[i] = s
+		}
+		return strings.Join(items, ","), nil
+	}
+	return "", fmt.Errorf("unsupported value: %v", value)
+}
+
+// print writes the effective configuration in the TOML format,
+// so that it could be used as the content of a .golds.toml file.
+func (config *projectConfig) print(out io.Writer, flags *flag.FlagSet, args []string) {
+	if config.File != "" {
+		fmt.Fprintf(out, "# The effective configuration (config file: %s).\n\n", config.File)
+	} else {
+		fmt.Fprint(out, "# The effective configuration (no config files).\n\n")
+	}
+
+	setInCommandLine := config.setInCommandLine
+	source := func(name string) string {
+		switch {
+		case setInCommandLine[name]:
+			return "command line"
+		case config.fromFile[name]:
+			return config.File
+		}
+		return "default"
+	}
+
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = util.QuoteTOML(arg)
+	}
+	fmt.Fprintf(out, "packages = [%s]\n", strings.Join(quoted, ", "))
+
+	flags.VisitAll(func(f *flag.Flag) {
+		if nonConfigurableOptions[f.Name] {
+			return
+		}
+		// Skip the unused aliases and the inline code hosts.
+		if (f.Name == "s" || f.Name == "v") && source(f.Name) == "default" {
+			return
+		}
+		if f.Name == "code-hosts" && len(config.CodeHosts) > 0 && !setInCommandLine[f.Name] {
+			return
+		}
+		value := util.QuoteTOML(f.Value.String())
+		if b, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && b.IsBoolFlag() {
+			value = f.Value.String()
+		}
+		fmt.Fprintf(out, "%s = %s # %s\n", f.Name, value, source(f.Name))
+	})
+
+	for _, host := range config.CodeHosts {
+		fmt.Fprintf(out, "\n[[code-hosts]] # %s\n", config.File)
+		fmt.Fprintf(out, "modulePathPrefix = %s\n", util.QuoteTOML(host.ModulePathPrefix))
+		fmt.Fprintf(out, "repositoryURLPattern = %s\n", util.QuoteTOML(host.RepositoryURLPattern))
+		if len(host.RepositoryAliases) > 0 {
+			quoted := make([]string, len(host.RepositoryAliases))
+			for i, alias := range host.RepositoryAliases {
+				quoted[i] = util.QuoteTOML(alias)
+			}
+			fmt.Fprintf(out, "repositoryAliases = [%s]\n", strings.Join(quoted, ", "))
+		}
+		fmt.Fprintf(out, "sourceLinkTemplate = %s\n", util.QuoteTOML(host.SourceLinkTemplate))
+	}
+}
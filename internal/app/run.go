@@ -13,6 +13,7 @@ import (
 	"strings"
 	"time"
 
+	"go101.org/golds/code"
 	"go101.org/golds/internal/server"
 	"go101.org/golds/internal/util"
 )
@@ -58,6 +59,26 @@ func run() {
 		return
 	}
 
+	config, err := loadProjectConfig(flag.CommandLine)
+	if err != nil {
+		log.Fatalln(err)
+		//return
+	}
+	args := flag.Args()
+	// "config" is reserved as the first argument. The remaining
+	// arguments are the packages shown in the printed configuration.
+	printConfig := len(args) > 0 && args[0] == "config"
+	if printConfig {
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		args = config.Packages
+	}
+	if printConfig {
+		config.print(os.Stdout, flag.CommandLine, args)
+		return
+	}
+
 	flag.CommandLine.Usage = func() {
 		printUsage(os.Stdout)
 	}
@@ -84,7 +105,7 @@ func run() {
 	var validateDir = func(dir string, forGenerating bool) string {
 		if dir == "" {
 			if forGenerating {
-				dir = "generated-" + time.Now().Format("20060102150405")
+				dir = "generated"
 			} else {
 				dir = "."
 			}
@@ -104,7 +125,7 @@ func run() {
 	verboseMode := *verboseFlag || *vFlag
 
 	// files serving mode
-	if flag.NArg() == 0 && !*genFlag {
+	if len(args) == 0 && !*genFlag {
 		log.SetFlags(0)
 
 		if *dirFlag == "" {
@@ -198,6 +219,67 @@ func run() {
 		log.Println()
 	}
 
+	var platforms []code.Platform
+	if *platformsFlag != "" {
+		if *goosFlag != "" || *goarchFlag != "" {
+			log.Fatalln("platforms and goos/goarch options conflict")
+			//return
+		}
+		for _, s := range strings.Split(*platformsFlag, ",") {
+			p, err := code.ParsePlatform(strings.TrimSpace(s))
+			if err != nil {
+				log.Fatalln(err)
+				//return
+			}
+			platforms = append(platforms, p)
+		}
+	} else if *goosFlag != "" || *goarchFlag != "" {
+		platforms = []code.Platform{{GOOS: *goosFlag, GOARCH: *goarchFlag}}
+	}
+
+	var buildTags []string
+	for _, tag := range strings.FieldsFunc(*tagsFlag, func(r rune) bool { return r == ',' || r == ' ' }) {
+		buildTags = append(buildTags, tag)
+	}
+
+	var codeHosts []server.CodeHost
+	if *codeHostsFlag != "" {
+		hosts, err := server.LoadCodeHosts(*codeHostsFlag)
+		if err != nil {
+			log.Fatalln(err)
+			//return
+		}
+		codeHosts = hosts
+	}
+	for i := range config.CodeHosts {
+		host, err := config.CodeHosts[i].CodeHost()
+		if err != nil {
+			log.Fatalf("%s: code host #%d: %s", config.File, i, err)
+			//return
+		}
+		codeHosts = append(codeHosts, host)
+	}
+
+	var coverProfile *server.CoverProfile
+	if len(coverProfilesFlag) > 0 {
+		profile, err := server.LoadCoverProfiles(coverProfilesFlag)
+		if err != nil {
+			log.Fatalln(err)
+			//return
+		}
+		coverProfile = profile
+	}
+
+	var cpuProfile *server.CPUProfile
+	if *profileFlag != "" {
+		profile, err := server.LoadCPUProfile(*profileFlag)
+		if err != nil {
+			log.Fatalln(err)
+			//return
+		}
+		cpuProfile = profile
+	}
+
 	if *compact {
 		*nouses = true
 		//*plainsrc = true
@@ -215,6 +297,23 @@ func run() {
 		WdPkgsListingManner:    wdPkgsListingManner,
 		FooterShowingManner:    footerShowingManner,
 		VerboseLogs:            verboseMode,
+		Platforms:              platforms,
+		BuildTags:              buildTags,
+		Tolerant:               *tolerantFlag,
+		CodeHosts:              codeHosts,
+		Incremental:            *incrementalFlag,
+		EmbedPackage:           *genEmbedPackageFlag,
+		GitAnnotations:         *gitAnnotationsFlag,
+		CoverProfile:           coverProfile,
+		CPUProfile:             cpuProfile,
+	}
+
+	// docs checking mode
+	if *checkDocsFlag {
+		if !server.CheckDocs(options, args, silentMode, printUsage) {
+			os.Exit(1)
+		}
+		return
 	}
 
 	// static docs generating mode
@@ -225,13 +324,22 @@ func run() {
 			log.Println("Unknown gen intent:", intent)
 			//printUsage(os.Stdout)
 		case "testdata":
-			server.GenTestData(flag.Args(), outputDir, silentMode, printUsage)
+			server.GenTestData(args, outputDir, silentMode, printUsage)
+		case "onepage":
+			server.GenOnePageDocs(options, args, outputDir, *genOnePageUnitFlag, silentMode, printUsage)
+		case "markdown":
+			server.GenMarkdownDocs(options, args, outputDir, silentMode, printUsage)
 		case "docs":
 			viewDocsCommand := func(docsDir string) string {
 				return os.Args[0] + " -dir=" + docsDir
 			}
 			// ToDo: also support json format output
-			server.GenDocs(options, flag.Args(), outputDir, silentMode, printUsage, *moregcFlag, viewDocsCommand)
+			if *genVersionsFlag != "" {
+				versions := strings.FieldsFunc(*genVersionsFlag, func(r rune) bool { return r == ',' || r == ' ' })
+				server.GenVersionedDocs(options, args, outputDir, versions, silentMode, printUsage, *moregcFlag, viewDocsCommand)
+			} else {
+				server.GenDocs(options, args, outputDir, silentMode, printUsage, *moregcFlag, viewDocsCommand)
+			}
 		}
 
 		return
@@ -252,7 +360,7 @@ func run() {
 		*portFlag = "56789"
 	}
 
-	server.Run(options, flag.Args(), *portFlag, silentMode, printUsage, appPkgPath, getRoughBuildTime)
+	server.Run(options, args, *portFlag, silentMode, printUsage, appPkgPath, getRoughBuildTime)
 }
 
 var hFlag = flag.Bool("h", false, "show help")
@@ -264,7 +372,11 @@ var verboseFlag = flag.Bool("verbose", false, "verbose mode")
 //var updateFlag = flag.Bool("update", false, "update self")
 var versionFlag = flag.Bool("version", false, "show version info")
 var genFlag = flag.Bool("gen", false, "HTML generation mode")
-var genIntentFlag = flag.String("gen-intent", "docs", "docs | testdata")
+var genIntentFlag = flag.String("gen-intent", "docs", "docs | testdata | onepage | markdown")
+var genOnePageUnitFlag = flag.String("gen-onepage-unit", "package", "package | module")
+var genVersionsFlag = flag.String("gen-versions", "", "comma-separated git tags or module versions to generate docs for")
+var incrementalFlag = flag.Bool("incremental", false, "only write changed files in docs generation")
+var genEmbedPackageFlag = flag.String("gen-embed-package", "", "generate docs as an embeddable Go package with this name")
 var langFlag = flag.String("lang", "", "docs generation language tag")
 var dirFlag = flag.String("dir", "", "directory for file serving or HTML generation")
 var portFlag = flag.String("port", "", "preferred server port [1024, 65536]. Default: 56789 or 9999")
@@ -288,6 +400,38 @@ var footerShowingMannerFlag = flag.String("footer", "verbose+qrcode", "verbose+q
 var emphasizeWdPackagesFlag = flag.Bool("emphasize-wdpkgs", false, "promote working directory packages")
 var wdPkgsListingMannerFlag = flag.String("wdpkgs-listing", "", "specify how to list working directory packages")
 
+var goosFlag = flag.String("goos", "", "the GOOS to analyze packages for")
+var goarchFlag = flag.String("goarch", "", "the GOARCH to analyze packages for")
+var platformsFlag = flag.String("platforms", "", "comma-separated GOOS/GOARCH list to compare packages across")
+var tagsFlag = flag.String("tags", "", "comma-separated build tags")
+var tolerantFlag = flag.Bool("tolerant", false, "go on analyzing packages with errors")
+var codeHostsFlag = flag.String("code-hosts", "", "a JSON file declaring extra code hosts")
+var checkDocsFlag = flag.Bool("check-docs", false, "check the docs of the argument packages")
+var gitAnnotationsFlag = flag.Bool("git-annotations", false, "annotate working directory source files with local git info")
+var coverProfilesFlag stringListFlag
+var profileFlag = flag.String("profile", "", "a pprof profile (such as a CPU profile) to show costs in source code")
+
+func init() {
+	flag.Var(&coverProfilesFlag, "coverprofile", "coverage profiles generated by go test (multiple allowed)")
+}
+
+// stringListFlag collects the values of a flag which
+// may be specified multiple times or comma-separated.
+type stringListFlag []string
+
+func (f *stringListFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *stringListFlag) Set(value string) error {
+	for _, s := range strings.Split(value, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			*f = append(*f, s)
+		}
+	}
+	return nil
+}
+
 func printVersion(out io.Writer) {
 	fmt.Fprintf(out, "Golds %s\n", Version)
 }
@@ -326,11 +470,48 @@ Options:
 	-gen
 		Static HTML docs generation mode.
 		"memory" means not to save (for testing).
-	-dir=<ContentDirectory>|memory
+	-gen-intent=onepage
+		Generate one self-contained HTML file
+		(with CSS and JavaScript inlined) for each
+		of the packages matching the arguments,
+		instead of a whole docs site.
+	-gen-onepage-unit=package|module
+		For -gen-intent=onepage only. Whether to
+		generate one file per package (default)
+		or per module.
+	-gen-intent=markdown
+		Generate one Markdown file for each of the
+		packages matching the arguments, at the
+		import path of the package under the -dir
+		folder. Source locations are linked to the
+		external code hosts (see -code-hosts).
+	-gen-versions=<Version>[,<Version>...]
+		Generate docs for each of the versions
+		into a subfolder of the -dir folder,
+		with a version switcher in each page.
+		The versions are git tags (or other git
+		revisions) if any argument is a local
+		path, otherwise they are module versions
+		(which must be in the module cache).
+	-incremental
+		For docs generation mode only. Only write
+		the files which contents are changed and
+		remove the orphaned ones generated before.
+		The changes are listed in the manifest
+		file golds-manifest.json in the -dir folder.
+	-gen-embed-package=<PackageName>
+		For docs generation mode only. Generate
+		the docs as a Go package in the -dir folder,
+		which embeds the docs files and provides
+		an http.Handler to serve them.
+	-dir=<ContentDirectory>|<Archive>|memory
 		Specify the docs generation or file
-		serving diretory. A new created subfolder
-		with a random name under the current directory
-		will be used if this option is not specified.
+		serving diretory. In docs generation mode,
+		the "generated" subfolder under the current
+		directory will be used if this option is
+		not specified. If the option value ends with
+		.zip, .tar.gz or .tgz, the docs are generated
+		into an archive file instead.
 		"memory" means not to save (for testing).
 	-nostats
 		Disable the statistics feature.
@@ -347,6 +528,21 @@ Options:
 		* rich: rich experience.
 		* external: read code on external code hosting
 		  websites. Do its best, use highlight on fails.
+	-code-hosts=<file.json>
+		Declare extra code hosts (such as self-hosted
+		GitLab, Gitea and cgit servers) for
+		-source-code-reading=external. For example:
+		{"codeHosts": [{
+		  "modulePathPrefix": "git.example.com/",
+		  "repositoryURLPattern":
+		    "https://git.example.com/{1}/{2}",
+		  "repositoryAliases": ["@git.example.com:"],
+		  "sourceLinkTemplate":
+		    "/src/commit/{commit}{path}[#L{line}[-L{endLine}]]"
+		}]}
+		{n} is the nth path element following the
+		module path prefix. A part in [] is omitted
+		if a placeholder in it is blank.
 	-allow-network-connection
 		When enabled,
 		* source files of the packages which external
@@ -372,6 +568,10 @@ Options:
 		* solo: list them without others.
 		* general: list them with others by
 		  alphabetical order.
+		In workspace (go.work) mode, the packages
+		in all the used modules are viewed as ones
+		in the working directory, and they are
+		grouped by modules when being promoted.
 	-footer-showing=verbose+qrcode|verbose|simple|none
 		Specify how page footers should be shown.
 		Available values (default is verbose+qrcode):
@@ -381,12 +581,100 @@ Options:
 		  promotion info.
 		* verbose+qrcode: include verbose content
 		  and a qr-code.
+	-goos=<GOOS> -goarch=<GOARCH>
+		Analyze packages for the specified target
+		platform. The host ones are used by default.
+	-platforms=<GOOS/GOARCH>[,<GOOS/GOARCH>...]
+		Analyze packages for the first platform,
+		and mark the files and declarations which
+		are not available on all the platforms.
+		It conflicts with -goos and -goarch.
+	-tags=<tag1>[,<tag2>...]
+		The build tags used to load packages,
+		the same as the -tags option of go build.
+	-tolerant
+		Go on analyzing packages with compile
+		errors. The errors are listed in the
+		analysis problems page.
+	-check-docs
+		Check the docs of the argument packages
+		(but not their dependencies) and exit
+		with a non-zero status if any issue is
+		found. The issues include undocumented
+		exported identifiers and selectors, docs
+		not starting with the identifier names,
+		broken doc links and missing package
+		docs. The report of the packages in the
+		working directory is shown in the docs
+		check page.
+	-git-annotations
+		Annotate the source files of the packages
+		in the working directory with the info
+		retrieved by the local git command: the
+		last change of each declaration, the blame
+		info of each line (shown on hovering), the
+		commit history of each function, the
+		recently changed files (in overview page)
+		and the uncommitted changes, including
+		the changed lines in source pages and the
+		exported API changes since HEAD.
+	-coverprofile=<file1>[,<file2>...]
+		Load the coverage profiles generated by
+		"go test -coverprofile" (this option may
+		be specified multiple times; the profiles
+		are merged). Covered and uncovered code is
+		colored in source pages. The coverage
+		percentages are shown for packages (in
+		overview and package details pages) and
+		functions. The least covered functions are
+		listed in the statistics page.
+	-profile=<cpu.pprof>
+		Load a pprof profile file, such as the one
+		generated by "go test -cpuprofile". The
+		samples are mapped to the analyzed functions
+		and source lines. The flat and cumulative
+		costs are shown in function listings and
+		source pages (the hottest lines are
+		highlighted). A hot functions page (linked
+		from the overview page) lists the functions
+		sorted by costs, with their callers.
+
+Config Files:
+	A .golds.toml or .golds.json file in the
+	working directory may specify the options
+	(keyed by their names without "-") and the
+	default arguments (keyed by "packages").
+	Code hosts may be declared inline as an
+	array of tables keyed by "code-hosts".
+	Options in command lines override the ones
+	in config files. The "config" subcommand
+	prints the effective configuration.
+	For example (.golds.toml):
+		packages = ["./..."]
+		wdpkgs-listing = "promoted"
+		source-code-reading = "external"
+		tags = ["integration"]
 
 Examples:
+	%[1]v config [Arguments]
+		Print the effective configuration
+		(in the TOML format). "config" is a
+		reserved word when it is the first
+		argument, so use "./config" to specify
+		the package in the config subfolder.
 	%[1]v std
 		Show docs of standard packages.
 	%[1]v x.y.z/myapp
 		Show docs of package x.y.z/myapp.
+	%[1]v x.y.z/mymod@v1.2.3
+		Show docs of the packages in module
+		x.y.z/mymod of version v1.2.3, which must
+		be in the module cache (no network needed).
+	%[1]v x.y.z/mymod@v1.2.3 x.y.z/mymod@v1.3.0
+		Show docs of the two versions side by
+		side, each served on its own port. In
+		the -gen mode, each version is generated
+		into a subfolder of the -dir folder.
 	%[1]v .
 		Show docs of the package in the
 		current directory.
@@ -0,0 +1,25 @@
+//go:build !go1.19
+// +build !go1.19
+
+package server
+
+import (
+	"go101.org/golds/code"
+)
+
+// writeDocComment is not supported before Go 1.19 (go/doc/comment is
+// unavailable), so callers should write doc comment texts as they are.
+func (ds *docServer) writeDocComment(page *htmlPage, pkg *code.Package, indent, text, idPrefix string, withTOC bool) bool {
+	return false
+}
+
+// brokenDocLinks is not supported before Go 1.19, so no doc links are reported.
+func (ds *docServer) brokenDocLinks(pkg *code.Package, text string) []string {
+	return nil
+}
+
+// docsMarkdown returns the doc text as it is before Go 1.19. The indented
+// lines in the text are code blocks in Markdown too.
+func (md *markdownWriter) docsMarkdown(text string) []byte {
+	return []byte(text)
+}
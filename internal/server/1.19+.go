@@ -0,0 +1,359 @@
+//go:build go1.19
+// +build go1.19
+
+package server
+
+import (
+	"fmt"
+	"go/doc/comment"
+	"go/types"
+	"strings"
+
+	"go101.org/golds/code"
+	"go101.org/golds/internal/util"
+)
+
+// writeDocComment writes the doc comment text of a resource in pkg as
+// structured HTML (paragraphs, headings, lists and code blocks). The doc
+// links in the comment are resolved to the anchors of the referenced
+// resources. The ids of the headings are prefixed with idPrefix. If withTOC
+// is true, a table of contents is written before the comment if the comment
+// contains headings.
+//
+// It returns false if structured rendering is not supported, in which case
+// nothing is written and the callers should write the text as it is.
+func (ds *docServer) writeDocComment(page *htmlPage, pkg *code.Package, indent, text, idPrefix string, withTOC bool) bool {
+	doc := newDocCommentParser(pkg).Parse(text)
+
+	r := &docCommentRenderer{ds: ds, page: page, pkg: pkg, idPrefix: idPrefix}
+	fmt.Fprintf(page, `<div class="doc-comment" style="margin-left: %dch;">`, indentWidth(indent))
+	if withTOC {
+		r.writeTOC(doc)
+	}
+	r.writeBlocks(doc.Content)
+	page.WriteString(`</div>`)
+	return true
+}
+
+func newDocCommentParser(pkg *code.Package) *comment.Parser {
+	return &comment.Parser{
+		LookupPackage: func(name string) (importPath string, ok bool) {
+			return lookupDocLinkPackage(pkg, name)
+		},
+		// Same as go/doc, unresolved unqualified names are not viewed as
+		// links, so that bracketed texts like "[Note]" are kept as they are.
+		// Links qualified by resolved package names are always viewed as
+		// links, so unresolved ones are shown (and reported) as broken links.
+		LookupSym: func(recv, name string) bool {
+			return lookupDocLinkSymbol(pkg, recv, name)
+		},
+	}
+}
+
+// brokenDocLinks returns the texts of the doc links in the
+// doc comment text of a resource in pkg which can't be resolved.
+func (ds *docServer) brokenDocLinks(pkg *code.Package, text string) []string {
+	var broken []string
+	var check func(texts []comment.Text)
+	check = func(texts []comment.Text) {
+		for _, t := range texts {
+			if link, ok := t.(*comment.DocLink); ok {
+				if _, ok := ds.resolveDocLink(pkg, link); !ok {
+					broken = append(broken, "["+docLinkText(link)+"]")
+				}
+			}
+		}
+	}
+	var walk func(blocks []comment.Block)
+	walk = func(blocks []comment.Block) {
+		for _, b := range blocks {
+			switch b := b.(type) {
+			case *comment.Paragraph:
+				check(b.Text)
+			case *comment.Heading:
+				check(b.Text)
+			case *comment.List:
+				for _, item := range b.Items {
+					walk(item.Content)
+				}
+			}
+		}
+	}
+	walk(newDocCommentParser(pkg).Parse(text).Content)
+	return broken
+}
+
+func docLinkText(link *comment.DocLink) string {
+	var b strings.Builder
+	for _, t := range link.Text {
+		if p, ok := t.(comment.Plain); ok {
+			b.WriteString(string(p))
+		}
+	}
+	return b.String()
+}
+
+// resolveDocLink finds the package containing the resource referenced by a
+// doc link in the docs of a resource in pkg. The returned package is nil if
+// the link references a standard package which is not analyzed.
+func (ds *docServer) resolveDocLink(pkg *code.Package, link *comment.DocLink) (target *code.Package, ok bool) {
+	target = pkg
+	if link.ImportPath != "" && link.ImportPath != pkg.Path() {
+		target = ds.analyzer.PackageByPath(link.ImportPath)
+	}
+	switch {
+	case target == nil:
+		return nil, isStandardPackagePath(link.ImportPath)
+	case link.Name == "":
+		return target, true
+	}
+	return target, lookupDocLinkSymbol(target, link.Recv, link.Name)
+}
+
+// indentWidth returns the width (in characters) of the indent in the docs pages.
+func indentWidth(indent string) int {
+	n := 0
+	for _, c := range indent {
+		if c == '\t' {
+			n += 8 - n%8
+		} else {
+			n++
+		}
+	}
+	return n
+}
+
+// lookupDocLinkPackage finds the import path of the package with the
+// specified name, which is either the package itself or one of its imports.
+// Same as go/doc, a name shared by several imports is viewed as unresolved.
+func lookupDocLinkPackage(pkg *code.Package, name string) (string, bool) {
+	if name == pkg.PPkg.Name {
+		return pkg.Path(), true
+	}
+	var found string
+	for path, p := range pkg.PPkg.Imports {
+		if p.Name == name {
+			if found != "" {
+				return "", false // ambiguous
+			}
+			found = path
+		}
+	}
+	return found, found != ""
+}
+
+// lookupDocLinkSymbol returns whether or not the package-level resource
+// name or the field/method recv.name is declared in pkg.
+func lookupDocLinkSymbol(pkg *code.Package, recv, name string) bool {
+	if pkg.PPkg.Types == nil {
+		return false
+	}
+	scope := pkg.PPkg.Types.Scope()
+	if recv == "" {
+		return scope.Lookup(name) != nil
+	}
+	tn, ok := scope.Lookup(recv).(*types.TypeName)
+	if !ok {
+		return false
+	}
+	obj, _, _ := types.LookupFieldOrMethod(tn.Type(), true, pkg.PPkg.Types, name)
+	return obj != nil
+}
+
+type docCommentRenderer struct {
+	ds       *docServer
+	page     *htmlPage
+	pkg      *code.Package
+	idPrefix string
+}
+
+func (r *docCommentRenderer) headingID(h *comment.Heading) string {
+	return r.idPrefix + h.DefaultID()
+}
+
+func (r *docCommentRenderer) writeTOC(doc *comment.Doc) {
+	var headings []*comment.Heading
+	for _, b := range doc.Content {
+		if h, ok := b.(*comment.Heading); ok {
+			headings = append(headings, h)
+		}
+	}
+	if len(headings) == 0 {
+		return
+	}
+
+	page := r.page
+	page.WriteString(`<div class="doc-toc"><span class="title">`)
+	page.WriteString(page.Translation().Text_DocContents())
+	page.WriteString(`</span><ul>`)
+	for _, h := range headings {
+		fmt.Fprintf(page, `<li><a href="#%s">`, r.headingID(h))
+		r.writeTexts(h.Text, false)
+		page.WriteString(`</a></li>`)
+	}
+	page.WriteString(`</ul></div>`)
+}
+
+func (r *docCommentRenderer) writeBlocks(blocks []comment.Block) {
+	page := r.page
+	for _, b := range blocks {
+		switch b := b.(type) {
+		case *comment.Paragraph:
+			page.WriteString(`<p>`)
+			r.writeTexts(b.Text, true)
+			page.WriteString(`</p>`)
+		case *comment.Heading:
+			fmt.Fprintf(page, `<h4 id="%s">`, r.headingID(b))
+			r.writeTexts(b.Text, true)
+			page.WriteString(`</h4>`)
+		case *comment.Code:
+			page.WriteString(`<pre>`)
+			util.WriteHtmlEscapedString(page, strings.TrimSuffix(b.Text, "\n"))
+			page.WriteString(`</pre>`)
+		case *comment.List:
+			tag := "ul"
+			if b.Items[0].Number != "" {
+				tag = "ol"
+			}
+			fmt.Fprintf(page, `<%s>`, tag)
+			for _, item := range b.Items {
+				page.WriteString(`<li>`)
+				if len(item.Content) == 1 && !b.BlankBetween() {
+					// Avoid the paragraph margins of tight lists.
+					if p, ok := item.Content[0].(*comment.Paragraph); ok {
+						r.writeTexts(p.Text, true)
+						page.WriteString(`</li>`)
+						continue
+					}
+				}
+				r.writeBlocks(item.Content)
+				page.WriteString(`</li>`)
+			}
+			fmt.Fprintf(page, `</%s>`, tag)
+		}
+	}
+}
+
+// writeTexts writes the texts. Links are not written as links if withLinks is false.
+func (r *docCommentRenderer) writeTexts(texts []comment.Text, withLinks bool) {
+	page := r.page
+	for _, t := range texts {
+		switch t := t.(type) {
+		case comment.Plain:
+			util.WriteHtmlEscapedString(page, string(t))
+		case comment.Italic:
+			page.WriteString(`<i>`)
+			util.WriteHtmlEscapedString(page, string(t))
+			page.WriteString(`</i>`)
+		case *comment.Link:
+			if !withLinks {
+				r.writeTexts(t.Text, false)
+				continue
+			}
+			page.WriteString(`<a href="`)
+			util.WriteHtmlEscapedString(page, t.URL)
+			page.WriteString(`">`)
+			r.writeTexts(t.Text, false)
+			page.WriteString(`</a>`)
+		case *comment.DocLink:
+			if !withLinks {
+				r.writeTexts(t.Text, false)
+				continue
+			}
+			r.writeDocLink(t)
+		}
+	}
+}
+
+// writeDocLink writes a doc link. A link to a resource in an analyzed
+// package is linked to the resource anchor in the package details page.
+// A link to a standard package which is not analyzed is linked to pkg.go.dev.
+// Other links are shown as broken links.
+func (r *docCommentRenderer) writeDocLink(link *comment.DocLink) {
+	page := r.page
+
+	var href string
+	switch target, ok := r.ds.resolveDocLink(r.pkg, link); {
+	case !ok:
+	case target == nil:
+		href = link.DefaultURL("https://pkg.go.dev")
+	case link.Name == "":
+		href = buildPageHref(page.PathInfo, createPagePathInfo1(ResTypePackage, target.Path()), nil, "")
+	default:
+		// Fields and methods have no anchors, so they are linked to their owner types.
+		name := link.Name
+		if link.Recv != "" {
+			name = link.Recv
+		}
+		if target == r.pkg {
+			href = "#name-" + name
+		} else {
+			href = buildPageHref(page.PathInfo, createPagePathInfo1(ResTypePackage, target.Path()), nil, "", "name-", name)
+		}
+	}
+
+	if href == "" {
+		page.WriteString(`<span class="broken-link" title="`)
+		page.WriteString(page.Translation().Text_BrokenDocLink())
+		page.WriteString(`">[`)
+		r.writeTexts(link.Text, false)
+		page.WriteString(`]</span>`)
+		return
+	}
+
+	page.WriteString(`<a href="`)
+	page.WriteString(href)
+	page.WriteString(`">`)
+	r.writeTexts(link.Text, false)
+	page.WriteString(`</a>`)
+}
+
+// docsMarkdown converts the doc text to Markdown. Doc links are linked to
+// the anchors in the generated Markdown files or to pkg.go.dev (for
+// standard packages). Other doc links are written as plain texts.
+func (md *markdownWriter) docsMarkdown(text string) []byte {
+	p := &comment.Printer{
+		// The doc headings are under the "###" declaration headings.
+		HeadingLevel: 4,
+		// The "{#id}" heading attributes are not supported by many Markdown renderers.
+		HeadingID:  func(*comment.Heading) string { return "" },
+		DocLinkURL: md.docLinkURL,
+	}
+	return p.Markdown(newDocCommentParser(md.pkg).Parse(text))
+}
+
+func (md *markdownWriter) docLinkURL(link *comment.DocLink) string {
+	target, ok := md.ds.resolveDocLink(md.pkg, link)
+	if !ok {
+		return ""
+	}
+	if target == nil || md.ds.analyzer.IsStandardPackage(target) && !md.generated[target.Path()] {
+		return link.DefaultURL("https://pkg.go.dev")
+	}
+
+	// Fields and methods have no anchors, so they are linked to their owner types.
+	anchor := link.Name
+	if link.Recv != "" {
+		anchor = link.Recv
+	}
+	if anchor != "" {
+		anchor = "#" + anchor
+	}
+	switch {
+	case target == md.pkg:
+		return anchor
+	case md.generated[target.Path()]:
+		return RelativePath(md.filePath, markdownFilePath(target.Path())) + anchor
+	}
+	return ""
+}
+
+// isStandardPackagePath guesses whether or not path is the path of a standard
+// package. The first element of the path of a non-standard package contains a dot.
+func isStandardPackagePath(path string) bool {
+	first := path
+	if i := strings.IndexByte(path, '/'); i >= 0 {
+		first = path[:i]
+	}
+	return first != "" && !strings.Contains(first, ".")
+}
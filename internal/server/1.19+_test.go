@@ -0,0 +1,154 @@
+//go:build go1.19
+// +build go1.19
+
+package server
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+	"testing"
+
+	"go101.org/golds/code"
+	"go101.org/golds/internal/server/translations"
+	"golang.org/x/tools/go/packages"
+)
+
+// newDocLinkTestPackage returns a package which imports
+// a standard package fmt, a non-standard package x and
+// two packages with the same name y.
+func newDocLinkTestPackage(t *testing.T) *code.Package {
+	const src = `package a
+
+type T struct{ F int }
+
+func (T) M() {}
+
+func G() {}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "a.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpkg, err := (&types.Config{}).Check("a.com/a", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &code.Package{
+		PPkg: &packages.Package{
+			PkgPath: "a.com/a",
+			Name:    "a",
+			Fset:    fset,
+			Types:   tpkg,
+			Imports: map[string]*packages.Package{
+				"fmt":           {PkgPath: "fmt", Name: "fmt"},
+				"example.com/x": {PkgPath: "example.com/x", Name: "x"},
+				"example.com/y": {PkgPath: "example.com/y", Name: "y"},
+				"other.com/y":   {PkgPath: "other.com/y", Name: "y"},
+			},
+		},
+	}
+}
+
+func TestWriteDocComment(t *testing.T) {
+	pkg := newDocLinkTestPackage(t)
+	ds := &docServer{analyzer: &code.CodeAnalyzer{}}
+
+	const doc = `Package a does things.
+
+# Overview
+
+See [G], [T.M], [T.F], [a.G], [fmt.Println], [fmt], [x.Y], [y.Z] and [Missing].
+
+  - item one
+  - item two
+
+Code:
+
+	x := 1 < 2
+`
+	page := &htmlPage{
+		PathInfo:    createPagePathInfo1(ResTypePackage, "a.com/a"),
+		translation: &translations.English{},
+	}
+	if !ds.writeDocComment(page, pkg, "\t", doc, "pkg-", true) {
+		t.Fatal("writeDocComment returns false")
+	}
+	var b strings.Builder
+	for _, bs := range page.content {
+		b.Write(bs)
+	}
+	html := b.String()
+
+	for _, want := range []string{
+		`<div class="doc-comment" style="margin-left: 8ch;">`,
+		`<div class="doc-toc"><span class="title">`,
+		`<li><a href="#pkg-hdr-Overview">Overview</a></li>`,
+		`<h4 id="pkg-hdr-Overview">Overview</h4>`,
+		`<a href="#name-G">G</a>`,
+		`<a href="#name-T">T.M</a>`,
+		`<a href="#name-T">T.F</a>`,
+		`<a href="#name-G">a.G</a>`,
+		`<a href="https://pkg.go.dev/fmt#Println">fmt.Println</a>`,
+		`<a href="https://pkg.go.dev/fmt">fmt</a>`,
+		`">[x.Y]</span>`,
+		// Unresolved unqualified names and ambiguous package names are not links.
+		`, [y.Z] and [Missing].</p>`,
+		`<ul><li>item one</li><li>item two</li></ul>`,
+		`<pre>x := 1 &lt; 2</pre>`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("%s is not found in the rendered doc comment:\n%s", want, html)
+		}
+	}
+	if strings.Index(html, `class="doc-toc"`) > strings.Index(html, `<h4 `) {
+		t.Errorf("the table of contents should be written before the headings:\n%s", html)
+	}
+
+	broken := ds.brokenDocLinks(pkg, doc)
+	if got, want := strings.Join(broken, " "), "[x.Y]"; got != want {
+		t.Errorf("brokenDocLinks: got %s, want %s", got, want)
+	}
+}
+
+func TestMarkdownDocs(t *testing.T) {
+	md := &markdownWriter{
+		ds:        &docServer{analyzer: &code.CodeAnalyzer{}},
+		pkg:       newDocLinkTestPackage(t),
+		filePath:  "a.com/a.md",
+		generated: map[string]bool{"a.com/a": true},
+	}
+	md.writeDocs(`Package a does [things].
+
+# Usage
+
+Call [G] or [T.M], then [fmt.Println] the result of [x.Y].
+
+  - one
+  - two
+
+Example:
+
+	a := 1 < 2
+`)
+	const want = `Package a does \[things].
+
+#### Usage
+
+Call [G](#G) or [T.M](#T), then [fmt.Println](https://pkg.go.dev/fmt#Println) the result of x.Y.
+
+  - one
+  - two
+
+Example:
+
+	a := 1 < 2
+
+`
+	if got := md.String(); got != want {
+		t.Errorf("writeDocs:\n got: %q\nwant: %q", got, want)
+	}
+}
@@ -1,12 +1,27 @@
 package server
 
 import (
+	"bytes"
+	"compress/gzip"
+	"container/list"
+	"encoding/binary"
 	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
 	"io/ioutil"
+	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
 	"testing"
 
+	"go101.org/golds/code"
 	"go101.org/golds/internal/util"
+	"golang.org/x/tools/go/packages"
 )
 
 func init() {
@@ -170,6 +185,544 @@ func TestPreviousVersion(t *testing.T) {
 	}
 }
 
+func TestDocsVersionDir(t *testing.T) {
+	for version, want := range map[string]string{
+		"v1.2.3":           "v1.2.3",
+		"release/1.0":      "release_1.0",
+		`release\1.0`:      "release_1.0",
+		"../v1":            ".._v1",
+		"":                 "",
+		".":                "",
+		"..":               "",
+		"css":              "",
+		"index.html":       "",
+		"docs-versions.js": "",
+	} {
+		dir, err := docsVersionDir(version)
+		if dir != want || (err != nil) != (want == "") {
+			t.Errorf("docsVersionDir(%q) = %q, %v; want %q", version, dir, err, want)
+		}
+	}
+}
+
+func TestCodeHostConfig(t *testing.T) {
+	config := CodeHostConfig{
+		ModulePathPrefix:     "git.example.com/",
+		RepositoryURLPattern: "https://git.example.com/{1}/{2}",
+		RepositoryAliases:    []string{"@git.example.com:"},
+		SourceLinkTemplate:   "/src/commit/{commit}{path}[#L{line}[-L{endLine}]]",
+	}
+	host, err := config.CodeHost()
+	if err != nil {
+		t.Fatalf("CodeHost error: %s", err)
+	}
+
+	repo, extra := host.GuessRepositoryFromModulePath("team/project/sub/mod")
+	if repo != "https://git.example.com/team/project" || extra != "/sub/mod" {
+		t.Errorf("wrong guessed repository: %s %s", repo, extra)
+	}
+	if repo, _ := host.GuessRepositoryFromModulePath("team"); repo != "" {
+		t.Errorf("should not guess repository: %s", repo)
+	}
+	repo, _ = host.GuessRepositryFromSourceURL("https://git.example.com/team/project/src/branch/main", host.RepositryCharacteristics[0])
+	if repo != "https://git.example.com/team/project" {
+		t.Errorf("wrong guessed repository from source URL: %s", repo)
+	}
+
+	type testCase struct {
+		line, endLine, link string
+	}
+	var testCases = []testCase{
+		{"", "", "/src/commit/abc/sub/mod/a.go"},
+		{"5", "", "/src/commit/abc/sub/mod/a.go#L5"},
+		{"5", "9", "/src/commit/abc/sub/mod/a.go#L5-L9"},
+	}
+	for _, tc := range testCases {
+		var b strings.Builder
+		host.BuildSourceLink(&b, "abc", "/sub/mod", "/a.go", tc.line, tc.endLine)
+		if b.String() != tc.link {
+			t.Errorf("wrong source link for (%s, %s): %s vs. %s", tc.line, tc.endLine, b.String(), tc.link)
+		}
+	}
+
+	for _, template := range []string{"/{commit", "/[{line}", "/{line}]", "/{unknown}"} {
+		config.SourceLinkTemplate = template
+		if _, err := config.CodeHost(); err == nil {
+			t.Errorf("invalid template %s is not detected", template)
+		}
+	}
+}
+
+func TestGenManifest(t *testing.T) {
+	parent := t.TempDir()
+	dir := filepath.Join(parent, "out")
+	generateInto := func(baseDir string, files map[string]string, old *genManifest, incremental bool) *genManifest {
+		m := newGenManifest(baseDir, incremental)
+		for filePath, content := range files {
+			path := filepath.Join(dir, filepath.FromSlash(baseDir), filepath.FromSlash(filePath))
+			if m.needWrite(filePath, path, Content{[]byte(content)}) {
+				os.MkdirAll(filepath.Dir(path), 0700)
+				os.WriteFile(path, []byte(content), 0644)
+			}
+		}
+		if err := m.removeOrphans(dir, old); err != nil {
+			t.Fatalf("removeOrphans error: %s", err)
+		}
+		return m
+	}
+	generate := func(files map[string]string, old *genManifest, incremental bool) *genManifest {
+		return generateInto("", files, old, incremental)
+	}
+
+	m1 := generate(map[string]string{"index.html": "a", "pkg/x.html": "x", "pkg/y/z.html": "z"}, nil, true)
+	if len(m1.Added) != 3 || len(m1.Changed) != 0 || m1.Unchanged != 0 {
+		t.Errorf("first generation: added %v, changed %v, unchanged %d", m1.Added, m1.Changed, m1.Unchanged)
+	}
+
+	m2 := generate(map[string]string{"index.html": "b", "pkg/x.html": "x", "pkg/w.html": "w"}, m1, true)
+	if len(m2.Added) != 1 || len(m2.Changed) != 1 || m2.Unchanged != 1 || len(m2.Removed) != 1 || m2.Removed[0] != "pkg/y/z.html" {
+		t.Errorf("second generation: added %v, changed %v, removed %v, unchanged %d", m2.Added, m2.Changed, m2.Removed, m2.Unchanged)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "pkg", "y")); !os.IsNotExist(err) {
+		t.Errorf("empty directory pkg/y is not removed")
+	}
+
+	// Non-incremental generations also remove the orphaned
+	// files recorded in the manifest written last time.
+	if err := m2.write(dir); err != nil {
+		t.Fatalf("write manifest error: %s", err)
+	}
+	m3 := generate(map[string]string{"index.html": "c", "pkg/x.html": "x"}, readGenManifest(dir), false)
+	if len(m3.Removed) != 1 || m3.Removed[0] != "pkg/w.html" {
+		t.Errorf("third generation: removed %v", m3.Removed)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "pkg", "w.html")); !os.IsNotExist(err) {
+		t.Errorf("orphaned file pkg/w.html is not removed")
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "index.html")); err != nil || string(data) != "c" {
+		t.Errorf("index.html is not rewritten: %s, %v", data, err)
+	}
+
+	// The files out of the output directory are never removed.
+	outside := filepath.Join(parent, "outside.txt")
+	if err := os.WriteFile(outside, []byte("o"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m3.Files["../outside.txt"] = ""
+	m3.Files["pkg/../../outside.txt"] = ""
+	m3.Files[filepath.ToSlash(outside)] = ""
+	m3.Files[".."] = ""
+	m3.Files["."] = ""
+	m4 := generate(map[string]string{"index.html": "c", "pkg/x.html": "x"}, m3, false)
+	if _, err := os.Stat(outside); err != nil {
+		t.Errorf("the file out of the output directory is removed: %v", err)
+	}
+	for _, filePath := range m4.Removed {
+		if strings.HasPrefix(filePath, "../") || filePath == ".." || filePath == "." {
+			t.Errorf("fourth generation: %s is removed", filePath)
+		}
+	}
+
+	// The old files are resolved against the base directory
+	// recorded in the old manifest (embed mode switched on).
+	m5 := generateInto(embeddedDocsFolder, map[string]string{"index.html": "c"}, m4, false)
+	sort.Strings(m5.Removed)
+	if got := strings.Join(m5.Removed, " "); got != "../index.html ../pkg/x.html" {
+		t.Errorf("fifth generation: removed %v", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, embeddedDocsFolder, "index.html")); err != nil {
+		t.Errorf("the newly generated file is removed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "index.html")); !os.IsNotExist(err) {
+		t.Errorf("orphaned file index.html is not removed")
+	}
+}
+
+func TestDocsArchiveFormat(t *testing.T) {
+	var testCases = map[string]string{
+		"generated":         "",
+		"docs.zip":          "zip",
+		"out/DOCS.ZIP":      "zip",
+		"docs.tar.gz":       "tar.gz",
+		"docs.tgz":          "tar.gz",
+		"docs.gz":           "",
+		"docs.zip/children": "",
+	}
+	for path, format := range testCases {
+		if f := docsArchiveFormat(path); f != format {
+			t.Errorf("archive format of %s should be %q, but got %q", path, format, f)
+		}
+	}
+
+	for name, valid := range map[string]bool{"docs": true, "api_docs2": true, "main": false, "2docs": false, "Docs": false, "": false} {
+		if isValidPackageName(name) != valid {
+			t.Errorf("isValidPackageName(%q) should be %v", name, valid)
+		}
+	}
+}
+
+func TestRewriteOnePageLinks(t *testing.T) {
+	ds := &docServer{}
+	included := map[string]string{"a.com/x": "a.com/x:", "a.com/y": "a.com/y:"}
+	content := `<div id="name-T"><a href="#name-T">T</a> <a href="y.html#name-U">U</a> <a href="../../dep/a.com/x.html">deps</a>` +
+		` <a href="https://a.com">home</a><input type='checkbox' class="fold" id='T-fold-content'><label for="T-fold-content"></label></div>`
+	want := `<div id="a.com/x:name-T"><a href="#a.com/x:name-T">T</a> <a href="#a.com/y:name-U">U</a> <a>deps</a>` +
+		` <a href="https://a.com">home</a><input type='checkbox' checked class="fold" id="a.com/x:T-fold-content"><label for="a.com/x:T-fold-content"></label></div>`
+	if got := ds.rewriteOnePageLinks(content, "pkg/a.com/x.html", "a.com/x:", included); got != want {
+		t.Errorf("rewriteOnePageLinks:\n got: %s\nwant: %s", got, want)
+	}
+}
+
+func TestDocStartsWithName(t *testing.T) {
+	var testCases = []struct {
+		doc, name string
+		starts    bool
+	}{
+		{"Foo does something.", "Foo", true},
+		{"A Foo is a thing.", "Foo", true},
+		{"The Foo's value.", "Foo", true},
+		{"Foo, Bar and Baz.", "Foo", true},
+		{"FooBar does something.", "Foo", false},
+		{"Foo_2 does something.", "Foo", false},
+		{"Does something.", "Foo", false},
+		{"A", "A", true},
+		{"", "Foo", false},
+	}
+	for _, tc := range testCases {
+		if starts := docStartsWithName(tc.doc, tc.name); starts != tc.starts {
+			t.Errorf("docStartsWithName(%q, %q) should be %v", tc.doc, tc.name, tc.starts)
+		}
+	}
+}
+
+func TestCollectPackageNotes(t *testing.T) {
+	const src = `package a
+
+// BUG(bob): it panics
+// on nil inputs.
+func F() {
+	// TODO: be faster.
+	// FIXME(alice) handle errors
+
+	/* Nothing to do.
+	   XXX reconsider this. */
+}
+
+// This is not a TODO note.
+var V int
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "a.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg := &code.Package{
+		PPkg:        &packages.Package{PkgPath: "a.com/a", Name: "a", Fset: fset},
+		SourceFiles: []code.SourceFileInfo{{AstFile: f}},
+	}
+
+	var got []string
+	for _, n := range collectPackageNotes(pkg) {
+		got = append(got, fmt.Sprintf("%d %s(%s) %s", n.Pos.Line, n.Marker, n.Author, n.Body))
+	}
+	want := []string{
+		"3 BUG(bob) it panics on nil inputs.",
+		"6 TODO() be faster.",
+		"7 FIXME(alice) handle errors",
+		"10 XXX() reconsider this.",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("collectPackageNotes:\n got: %q\nwant: %q", got, want)
+	}
+}
+
+func TestParseGitOutputs(t *testing.T) {
+	const hashA = "1111111111111111111111111111111111111111"
+	const hashB = "2222222222222222222222222222222222222222"
+	blame := hashA + ` 1 1 2
+author Alice
+author-time 1700000000
+summary Add F
+filename a.go
+	package a
+
+` + hashA + ` 2 2
+	func F() {
+` + hashB + ` 5 3 1
+author Bob
+author-time 1710000000
+summary Fix F
+previous ` + hashA + ` a.go
+filename a.go
+	return
+` + gitUncommittedHash + ` 4 4 1
+author Not Committed Yet
+author-time 1720000000
+summary Version of a.go from a.go
+filename a.go
+	}
+`
+	var got []string
+	for i, c := range parseGitBlame([]byte(blame)) {
+		got = append(got, fmt.Sprintf("%d %s %s %s %v", i+1, c.ShortHash(), c.Author, c.Summary, c.Uncommitted()))
+	}
+	want := []string{
+		"1 1111111 Alice Add F false",
+		"2 1111111 Alice Add F false",
+		"3 2222222 Bob Fix F false",
+		"4 0000000 Not Committed Yet Version of a.go from a.go true",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("parseGitBlame:\n got: %q\nwant: %q", got, want)
+	}
+
+	gitLog := "\x01" + hashB + "\x00Bob\x001710000000\x00Fix F\n\na.go\nb/c.go\n" +
+		"\x01" + hashA + "\x00Alice\x001700000000\x00Add F\n\na.go\n"
+	entries := parseGitLog([]byte(gitLog), true)
+	if len(entries) != 2 {
+		t.Fatalf("parseGitLog: %d entries, want 2", len(entries))
+	}
+	if c := entries[0].Commit; c.Hash != hashB || c.Author != "Bob" || c.Summary != "Fix F" || c.Time.Unix() != 1710000000 {
+		t.Errorf("parseGitLog: wrong commit %+v", c)
+	}
+	if files := strings.Join(entries[0].Files, ","); files != "a.go,b/c.go" {
+		t.Errorf("parseGitLog: wrong files %s", files)
+	}
+	if entries := parseGitLog([]byte(gitLog), false); len(entries[1].Files) != 0 {
+		t.Errorf("parseGitLog: files should not be collected")
+	}
+}
+
+func TestParseGitChanges(t *testing.T) {
+	status := " M a/x.go\x00A  a/y.go\x00R  a/new.go\x00a/old.go\x00?? b/z.go\x00 D a/w.go\x00MM c.go\x00"
+	statuses, headPaths := parseGitStatus([]byte(status))
+	want := map[string]string{
+		"a/x.go":   GitFileStatus_Modified,
+		"a/y.go":   GitFileStatus_Added,
+		"a/new.go": GitFileStatus_Added,
+		"a/old.go": GitFileStatus_Deleted,
+		"b/z.go":   GitFileStatus_Untracked,
+		"a/w.go":   GitFileStatus_Deleted,
+		"c.go":     GitFileStatus_Modified,
+	}
+	if fmt.Sprint(statuses) != fmt.Sprint(want) {
+		t.Errorf("parseGitStatus:\n got: %v\nwant: %v", statuses, want)
+	}
+	if got := fmt.Sprint(headPaths); got != "map[a/new.go:a/old.go]" {
+		t.Errorf("parseGitStatus: head paths: %s", got)
+	}
+
+	diff := `diff --git a/a.go b/a.go
+--- a/a.go
++++ b/a.go
+@@ -3 +3 @@ func F() {
+-	return 1
++	return 2
+@@ -10,2 +9,0 @@ func G() {
+@@ -20,0 +19,3 @@ func H() {
+`
+	hunks := parseGitDiffHunks([]byte(diff))
+	if got, want := fmt.Sprint(hunks), "[{3 1 3 1} {9 0 10 2} {19 3 20 0}]"; got != want {
+		t.Errorf("parseGitDiffHunks: got %s, want %s", got, want)
+	}
+
+	// Working tree line ranges are mapped to HEAD line ranges.
+	for _, c := range []struct {
+		start, end         int
+		wantStart, wantEnd int
+	}{
+		{1, 2, 1, 2},     // before all hunks
+		{2, 5, 2, 5},     // containing a changed line
+		{3, 3, 3, 3},     // a changed line
+		{5, 9, 5, 9},     // ending at the line before removed lines
+		{10, 12, 12, 14}, // after removed lines
+		{15, 19, 17, 20}, // ending in added lines
+		{19, 21, 21, 20}, // all added lines
+		{22, 25, 21, 24}, // after added lines
+	} {
+		start, end := headLineRange(hunks, c.start, c.end)
+		if start != c.wantStart || end != c.wantEnd {
+			t.Errorf("headLineRange(%d, %d): got %d, %d, want %d, %d", c.start, c.end, start, end, c.wantStart, c.wantEnd)
+		}
+	}
+}
+
+func TestDiffExportedAPIs(t *testing.T) {
+	const oldSrc = `package a
+
+// F does something.
+func F(x int) {}
+
+func G() {}
+
+type T struct {
+	A int
+	b int
+}
+
+func (T) M() {}
+
+func (t *T) m() {}
+
+func (t T) N(a, b int) (n int, err error) { return }
+
+type I interface {
+	M(x int) bool
+}
+
+const C, d = 1, 2
+`
+	const newSrc = `package a
+
+// F does something else.
+func F(x int) {
+	println(x)
+}
+
+func H() {}
+
+// T is a type.
+type T struct {
+	A int // a field
+
+	c string
+}
+
+func (T) M(y int) {}
+
+// Renaming receivers, parameters and results is not an API change.
+func (T) N(x int, y int) (int, error) { return 0, nil }
+
+type I interface {
+	M(y int) (ok bool)
+}
+
+const C, D = 1, 2
+`
+	apis := func(src string) map[string]string {
+		fset := token.NewFileSet()
+		f, err := parser.ParseFile(fset, "a.go", src, parser.ParseComments)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return collectExportedAPIs(fset, []*ast.File{f})
+	}
+
+	var got []string
+	for _, c := range diffExportedAPIs(apis(oldSrc), apis(newSrc)) {
+		got = append(got, c.Kind+" "+c.Name)
+	}
+	want := []string{
+		"added D",
+		"removed G",
+		"added H",
+		"changed T.M",
+	}
+	if strings.Join(got, ", ") != strings.Join(want, ", ") {
+		t.Errorf("diffExportedAPIs:\n got: %q\nwant: %q", got, want)
+	}
+}
+
+func TestCoverProfile(t *testing.T) {
+	dir := t.TempDir()
+	profiles := []string{
+		`mode: set
+example.com/a/a.go:3.14,4.9 2 0
+example.com/a/a.go:4.9,5.2 1 0
+example.com/a/b.go:3.10,3.20 1 1
+`,
+		`mode: set
+example.com/a/a.go:3.14,4.9 2 1
+example.com/a/a.go:4.9,5.2 1 0
+`,
+	}
+	var filenames []string
+	for i, p := range profiles {
+		filename := filepath.Join(dir, fmt.Sprintf("%d.out", i))
+		if err := os.WriteFile(filename, []byte(p), 0644); err != nil {
+			t.Fatal(err)
+		}
+		filenames = append(filenames, filename)
+	}
+	profile, err := LoadCoverProfiles(filenames)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats, ok := profile.PackageCoverage("example.com/a"); !ok || stats != (CoverStats{NumStmts: 4, NumCovered: 3}) {
+		t.Errorf("PackageCoverage: got %v (%v)", stats, ok)
+	}
+	if _, ok := profile.PackageCoverage("example.com/b"); ok {
+		t.Errorf("PackageCoverage: example.com/b should not be in the profile")
+	}
+
+	line := "\t<span class=\"keyword\">if</span> x &lt; 1 {"
+	segs := []coverSegment{{start: 2, end: 8, covered: true}, {start: 10, end: -1}}
+	want := "\t<span class=\"covered\"><span class=\"keyword\">if</span> x &lt;</span> 1<span class=\"uncovered\"> {</span>"
+	if got := applyCoverSegments(line, segs); got != want {
+		t.Errorf("applyCoverSegments:\n got: %s\nwant: %s", got, want)
+	}
+}
+
+func TestLoadCPUProfile(t *testing.T) {
+	uvarint := func(b []byte, v uint64) []byte {
+		var buf [binary.MaxVarintLen64]byte
+		return append(b, buf[:binary.PutUvarint(buf[:], v)]...)
+	}
+	varint := func(b []byte, num int, v uint64) []byte {
+		return uvarint(uvarint(b, uint64(num)<<3|0), v)
+	}
+	bytesField := func(b []byte, num int, data []byte) []byte {
+		b = uvarint(uvarint(b, uint64(num)<<3|2), uint64(len(data)))
+		return append(b, data...)
+	}
+	message := func(fields ...[]byte) []byte {
+		return bytes.Join(fields, nil)
+	}
+
+	var data []byte
+	// sample types: samples/count and cpu/nanoseconds
+	data = bytesField(data, 1, message(varint(nil, 1, 1), varint(nil, 2, 2)))
+	data = bytesField(data, 1, message(varint(nil, 1, 3), varint(nil, 2, 4)))
+	// samples with packed and unpacked location ids
+	data = bytesField(data, 2, message(bytesField(nil, 1, []byte{1, 2}), varint(nil, 2, 3), varint(nil, 2, 30000000)))
+	data = bytesField(data, 2, message(varint(nil, 1, 2), varint(nil, 2, 1), varint(nil, 2, 10000000)))
+	data = bytesField(data, 4, message(varint(nil, 1, 1), bytesField(nil, 4, message(varint(nil, 1, 1), varint(nil, 2, 12)))))
+	data = bytesField(data, 4, message(varint(nil, 1, 2), bytesField(nil, 4, message(varint(nil, 1, 2), varint(nil, 2, 34)))))
+	data = bytesField(data, 5, message(varint(nil, 1, 1), varint(nil, 2, 5), varint(nil, 4, 7)))
+	data = bytesField(data, 5, message(varint(nil, 1, 2), varint(nil, 2, 6), varint(nil, 4, 7)))
+	for _, s := range []string{"", "samples", "count", "cpu", "nanoseconds", "example.com/a.f", "example.com/a.g", "/src/a/a.go"} {
+		data = bytesField(data, 6, []byte(s))
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write(data)
+	zw.Close()
+	filename := filepath.Join(t.TempDir(), "cpu.pprof")
+	if err := os.WriteFile(filename, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	profile, err := LoadCPUProfile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.SampleType != "cpu" || profile.SampleUnit != "nanoseconds" {
+		t.Errorf("sample type: got %s/%s", profile.SampleType, profile.SampleUnit)
+	}
+	if profile.Total != 40000000 || len(profile.Samples) != 2 {
+		t.Fatalf("got total %d and %d samples", profile.Total, len(profile.Samples))
+	}
+	if frames := profile.Samples[0].Frames; len(frames) != 2 ||
+		frames[0] != (ProfileFrame{"example.com/a.f", "/src/a/a.go", 12}) ||
+		frames[1] != (ProfileFrame{"example.com/a.g", "/src/a/a.go", 34}) {
+		t.Errorf("frames of sample 0: got %v", frames)
+	}
+	if got, want := profile.FormatValueWithPercent(10000000), "10.00ms (25.0%)"; got != want {
+		t.Errorf("FormatValueWithPercent: got %s, want %s", got, want)
+	}
+}
+
 func TestDocsForStandardPackages(t *testing.T) {
 	// ...
 	data, err := ioutil.ReadFile(filepath.Join("..", "testing", "data", "testdata.json.tar.gz"))
@@ -264,3 +817,206 @@ func TestGenerateDocsOfStandardPackages(t *testing.T) {
 	GenDocs(opts, []string{"std"}, "", true, nil, false, nil)
 	GenTestData([]string{"std"}, "", true, nil)
 }
+
+func TestMarkdownValues(t *testing.T) {
+	const src = `package a
+
+type Weekday int
+
+const (
+	Sunday Weekday = iota
+	Monday
+	_
+	Wednesday
+)
+
+const (
+	A, B = 1 << (10 * (iota + 1)), 1 << (20 * (iota + 1))
+	C, D
+	Name = "a"
+	Alias
+)
+
+var X, Y = 1, "y"
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "a.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpkg, err := (&types.Config{}).Check("a.com/a", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg := &code.Package{PPkg: &packages.Package{PkgPath: "a.com/a", Name: "a", Fset: fset, Types: tpkg}}
+	md := &markdownWriter{ds: &docServer{analyzer: &code.CodeAnalyzer{}}, pkg: pkg, filePath: "a.com/a.md"}
+
+	var want []string
+	for _, decl := range f.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok == token.TYPE {
+			continue
+		}
+		for _, spec := range gd.Specs {
+			vs := spec.(*ast.ValueSpec)
+			for _, n := range vs.Names {
+				if n.Name == "_" {
+					continue
+				}
+				if gd.Tok == token.CONST {
+					md.writeValue(&code.Constant{Const: tpkg.Scope().Lookup(n.Name).(*types.Const), Pkg: pkg, AstDecl: gd, AstSpec: vs})
+				} else {
+					md.writeValue(&code.Variable{Var: tpkg.Scope().Lookup(n.Name).(*types.Var), Pkg: pkg, AstDecl: gd, AstSpec: vs})
+				}
+			}
+		}
+	}
+	for _, c := range []struct{ name, code string }{
+		{"Sunday", "const Sunday Weekday = iota"},
+		{"Monday", "const Monday Weekday = 1"},
+		{"Wednesday", "const Wednesday Weekday = 3"},
+		{"A", "const A = 1 << (10 * (iota + 1))"},
+		{"B", "const B = 1 << (20 * (iota + 1))"},
+		{"C", "const C = 1048576"},
+		{"D", "const D = 1099511627776"},
+		{"Name", `const Name = "a"`},
+		{"Alias", `const Alias = "a"`},
+		{"X", "var X = 1"},
+		{"Y", `var Y = "y"`},
+	} {
+		want = append(want, fmt.Sprintf("<a name=\"%s\"></a>\n### %s %s\n\n```go\n%s\n```\n\n", c.name, c.code[:strings.IndexByte(c.code, ' ')], c.name, c.code))
+	}
+	if got := md.String(); got != strings.Join(want, "") {
+		t.Errorf("markdown of values:\n got: %s\nwant: %s", got, strings.Join(want, ""))
+	}
+}
+
+func TestSourceCodePageComments(t *testing.T) {
+	const src = `// Copyright line.
+
+// Package a is a test package.
+//
+// It has docs.
+package a
+
+// T is a type.
+type T struct {
+	X int // a line comment
+}
+
+/*
+   A block comment
+   of four lines.
+*/
+
+func F() {
+	// a comment line in the body
+	println() /* trailing */
+}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "a.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg := &code.Package{PPkg: &packages.Package{PkgPath: "a.com/a", Name: "a", Fset: fset}}
+
+	// Only the comments are handled, the other code is written as plain texts.
+	specialAstNodes := list.New()
+	for _, cg := range f.Comments {
+		specialAstNodes.PushBack(cg)
+	}
+	av := &astVisitor{
+		currentPathInfo:  createPagePathInfo2b(ResTypeSource, pkg.Path(), "/", "a.go"),
+		dataAnalyzer:     &code.CodeAnalyzer{},
+		pkg:              pkg,
+		fset:             fset,
+		file:             fset.File(f.Pos()),
+		info:             &types.Info{},
+		content:          []byte(src),
+		lineNumber:       1,
+		specialAstNodes:  specialAstNodes,
+		sameFileObjects:  make(map[types.Object]int32),
+		docCommentGroups: collectDocCommentGroups(f),
+		result: &SourceFileAnalyzeResult{
+			PkgPath:      pkg.Path(),
+			BareFilename: "a.go",
+			DocStartLine: 3,
+			DocEndLine:   5,
+		},
+	}
+	av.finish()
+	result := av.result
+
+	if got, want := fmt.Sprint(result.CommentBlocks), "[{1 1 false} {3 5 true} {8 8 true} {13 16 false} {19 19 false}]"; got != want {
+		t.Errorf("comment blocks: got %s, want %s", got, want)
+	}
+
+	ds := &docServer{analyzer: &code.CodeAnalyzer{}}
+	ds.initSettings("en")
+	w := &docGenResponseWriter{}
+	ds.buildSourceCodePage(w, result)
+	var html string
+	for _, bs := range w.content {
+		html += string(bs)
+	}
+	start := strings.Index(html, `<pre class="line-numbers">`)
+	end := strings.LastIndex(html, `</pre>`)
+	if start < 0 || end < start {
+		t.Fatalf("code lines are not found:\n%s", html)
+	}
+	html = html[start:end]
+
+	// The line ids are in order, and the line counter is
+	// reset for the lines following hidable comment lines.
+	var lines []string
+	for _, m := range regexp.MustCompile(`<span class="codeline"( style="counter-reset: line (\d+);")? id="line-(\d+)">`).FindAllStringSubmatch(html, -1) {
+		if m[1] != "" {
+			lines = append(lines, m[3]+"("+m[2]+")")
+		} else {
+			lines = append(lines, m[3])
+		}
+	}
+	if got, want := strings.Join(lines, " "), "1 2(1) 3 4(3) 5(4) 6(5) 7 8 9(8) 10 11 12 13 14(13) 15(14) 16(15) 17(16) 18 19 20(19) 21"; got != want {
+		t.Errorf("code lines:\n got: %s\nwant: %s", got, want)
+	}
+
+	// The span and div tags are well nested.
+	var stack []string
+	for _, m := range regexp.MustCompile(`<(/?)(span|div)\b`).FindAllStringSubmatch(html, -1) {
+		if m[1] == "" {
+			stack = append(stack, m[2])
+		} else if len(stack) == 0 || stack[len(stack)-1] != m[2] {
+			t.Fatalf("unmatched </%s> in:\n%s", m[2], html)
+		} else {
+			stack = stack[:len(stack)-1]
+		}
+	}
+	if len(stack) > 0 {
+		t.Fatalf("unclosed tags %v in:\n%s", stack, html)
+	}
+
+	// The doc div encloses the whole doc comment block (lines 3-5).
+	docStart := strings.Index(html, `<div class="anchor" id="doc">`)
+	docEnd := strings.Index(html, `</div>`)
+	if docStart < 0 || docEnd < docStart {
+		t.Fatalf("the doc div is not found in:\n%s", html)
+	}
+	doc := html[docStart:docEnd]
+	for _, line := range []string{"3", "4", "5"} {
+		if !strings.Contains(doc, `id="line-`+line+`"`) {
+			t.Errorf("line %s is not in the doc div:\n%s", line, doc)
+		}
+	}
+	for _, line := range []string{"2", "6"} {
+		if strings.Contains(doc, `id="line-`+line+`"`) {
+			t.Errorf("line %s is in the doc div:\n%s", line, doc)
+		}
+	}
+	if !strings.Contains(doc, `<span class="comment-line doc comment-block-start">`) || !strings.Contains(doc, `<span class="comment-block-rest">`) {
+		t.Errorf("the doc comment block is not foldable:\n%s", doc)
+	}
+	if strings.Count(html, `<span class="comment-block-rest">`) != 2 {
+		t.Errorf("there should be 2 foldable comment blocks:\n%s", html)
+	}
+}
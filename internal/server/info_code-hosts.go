@@ -0,0 +1,213 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// A CodeHostConfig declares a code host which is not built in,
+// such as a self-hosted GitLab, Gitea or cgit server.
+type CodeHostConfig struct {
+	// The prefix of the paths of the modules hosted on the code host,
+	// such as "git.example.com/". It might be blank if the paths of
+	// the hosted modules are vanity ones.
+	ModulePathPrefix string `json:"modulePathPrefix"`
+
+	// The pattern of repository URLs, such as "https://git.example.com/{1}/{2}",
+	// in which {n} is the nth path element following ModulePathPrefix in a
+	// module path. The remaining path elements are viewed as the directory
+	// of the module in the repository.
+	RepositoryURLPattern string `json:"repositoryURLPattern"`
+
+	// Some substrings to recognize the non-https repository URLs
+	// (used by git remotes), such as "@git.example.com:".
+	RepositoryAliases []string `json:"repositoryAliases"`
+
+	// The template of source links, which are relative to repository URLs,
+	// such as "/src/commit/{commit}{path}[#L{line}[-L{endLine}]]".
+	// The placeholders are {commit}, {path}, {line} and {endLine}.
+	// A part enclosed in square brackets is omitted if any placeholder
+	// in it is blank.
+	SourceLinkTemplate string `json:"sourceLinkTemplate"`
+}
+
+type codeHostsConfigFile struct {
+	CodeHosts []CodeHostConfig `json:"codeHosts"`
+}
+
+// LoadCodeHosts loads the code hosts declared in a JSON file, in the form of
+//
+//	{"codeHosts": [{"modulePathPrefix": ..., "repositoryURLPattern": ..., ...}]}
+func LoadCodeHosts(filename string) ([]CodeHost, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var file codeHostsConfigFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse %s error: %w", filename, err)
+	}
+	hosts := make([]CodeHost, 0, len(file.CodeHosts))
+	for i := range file.CodeHosts {
+		host, err := file.CodeHosts[i].CodeHost()
+		if err != nil {
+			return nil, fmt.Errorf("code host #%d in %s: %w", i, filename, err)
+		}
+		hosts = append(hosts, host)
+	}
+	return hosts, nil
+}
+
+// CodeHost converts the config to a CodeHost.
+func (c *CodeHostConfig) CodeHost() (CodeHost, error) {
+	var host = CodeHost{ModulePathPrefix: c.ModulePathPrefix}
+
+	u, err := url.Parse(c.RepositoryURLPattern)
+	if err != nil || u.Scheme != "https" && u.Scheme != "http" || u.Host == "" {
+		return host, fmt.Errorf("invalid repository URL pattern: %q", c.RepositoryURLPattern)
+	}
+	hostPrefix := u.Scheme + "://" + u.Host + "/"
+	if !strings.HasPrefix(c.RepositoryURLPattern, hostPrefix) {
+		return host, fmt.Errorf("invalid repository URL pattern: %q", c.RepositoryURLPattern)
+	}
+	repoPattern := strings.TrimSuffix(c.RepositoryURLPattern[len(hostPrefix):], "/")
+	if repoPattern == "" {
+		return host, fmt.Errorf("no repository paths in repository URL pattern: %q", c.RepositoryURLPattern)
+	}
+	maxElement := 0
+	if _, err := expandTemplate(repoPattern, func(name string) (string, bool) {
+		n, err := strconv.Atoi(name)
+		if err != nil || n <= 0 {
+			return "", false
+		}
+		if n > maxElement {
+			maxElement = n
+		}
+		return "x", true
+	}); err != nil {
+		return host, fmt.Errorf("invalid repository URL pattern: %q: %w", c.RepositoryURLPattern, err)
+	}
+	if maxElement > 0 && c.ModulePathPrefix == "" {
+		return host, errors.New("module path prefix is required for the path element placeholders in repository URL pattern")
+	}
+
+	host.RepositryCharacteristics = append([]string{hostPrefix}, c.RepositoryAliases...)
+	numRepoElements := strings.Count(repoPattern, "/") + 1
+	host.GuessRepositryFromSourceURL = func(url, prefix string) (string, string) {
+		items := strings.SplitN(url[len(prefix):], "/", numRepoElements+1)
+		if len(items) <= numRepoElements {
+			return url, ""
+		}
+		return prefix + strings.Join(items[:numRepoElements], "/"), ""
+	}
+	if c.ModulePathPrefix != "" {
+		host.GuessRepositoryFromModulePath = func(moduleRelativePath string) (string, string) {
+			elements := strings.Split(moduleRelativePath, "/")
+			if len(elements) < maxElement {
+				return "", ""
+			}
+			repo, _ := expandTemplate(repoPattern, func(name string) (string, bool) {
+				n, _ := strconv.Atoi(name)
+				return elements[n-1], true
+			})
+			extraPath := ""
+			if len(elements) > maxElement {
+				extraPath = "/" + strings.Join(elements[maxElement:], "/")
+			}
+			return hostPrefix + repo, extraPath
+		}
+	}
+
+	if c.SourceLinkTemplate == "" {
+		return host, errors.New("source link template is not specified")
+	}
+	template := c.SourceLinkTemplate
+	if _, err := expandTemplate(template, sourceLinkPlaceholderValues("x", "x", "x", "x")); err != nil {
+		return host, fmt.Errorf("invalid source link template: %q: %w", template, err)
+	}
+	host.BuildSourceLink = func(w writer, commit, extraPath, sourcePath, line, endLine string) error {
+		link, _ := expandTemplate(template, sourceLinkPlaceholderValues(commit, extraPath+sourcePath, line, endLine))
+		_, err := w.WriteString(link)
+		return err
+	}
+
+	return host, nil
+}
+
+func sourceLinkPlaceholderValues(commit, path, line, endLine string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		switch name {
+		case "commit":
+			return commit, true
+		case "path":
+			return path, true
+		case "line":
+			return line, true
+		case "endLine":
+			return endLine, true
+		}
+		return "", false
+	}
+}
+
+// expandTemplate replaces the {name} placeholders in a template with the
+// values returned by the value function, which returns false for unknown
+// names. A part enclosed in square brackets is omitted if any placeholder
+// directly in it is replaced with blank.
+func expandTemplate(template string, value func(name string) (string, bool)) (string, error) {
+	var b strings.Builder
+	if _, _, err := expandTemplatePart(&b, template, value, false); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// expandTemplatePart expands the template until its end or an unmatched "]",
+// which is the start of the returned rest if nested is true.
+func expandTemplatePart(b *strings.Builder, template string, value func(string) (string, bool), nested bool) (rest string, hasBlank bool, err error) {
+	for len(template) > 0 {
+		switch c := template[0]; c {
+		case '[':
+			var part strings.Builder
+			rest, blank, err := expandTemplatePart(&part, template[1:], value, true)
+			if err != nil {
+				return "", false, err
+			}
+			if !blank {
+				b.WriteString(part.String())
+			}
+			template = rest[1:]
+		case ']':
+			if !nested {
+				return "", false, errors.New("unmatched ]")
+			}
+			return template, hasBlank, nil
+		case '{':
+			end := strings.IndexByte(template, '}')
+			if end < 0 {
+				return "", false, errors.New("unclosed {")
+			}
+			v, ok := value(template[1:end])
+			if !ok {
+				return "", false, fmt.Errorf("unknown placeholder %s", template[:end+1])
+			}
+			if v == "" {
+				hasBlank = true
+			}
+			b.WriteString(v)
+			template = template[end+1:]
+		default:
+			b.WriteByte(c)
+			template = template[1:]
+		}
+	}
+	if nested {
+		return "", false, errors.New("unclosed [")
+	}
+	return "", hasBlank, nil
+}
@@ -0,0 +1,412 @@
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go101.org/golds/code"
+)
+
+// A CoverBlock is a statement block in a coverage profile.
+// The columns are byte offsets (starting from 1) in lines.
+type CoverBlock struct {
+	StartLine, StartCol int
+	EndLine, EndCol     int
+	NumStmts            int
+	Count               int
+}
+
+// CoverStats is the statement coverage of a code range.
+type CoverStats struct {
+	NumStmts   int
+	NumCovered int
+}
+
+func (s *CoverStats) add(b CoverBlock) {
+	s.NumStmts += b.NumStmts
+	if b.Count > 0 {
+		s.NumCovered += b.NumStmts
+	}
+}
+
+// Percent returns the coverage percentage. It must
+// not be called if there are no statements.
+func (s CoverStats) Percent() float64 {
+	return float64(s.NumCovered) * 100 / float64(s.NumStmts)
+}
+
+// CoverProfile is the merged result of some coverage
+// profiles generated by "go test -coverprofile".
+type CoverProfile struct {
+	Mode string // set | count | atomic
+
+	files    map[string][]CoverBlock // "import/path/file.go" -> blocks sorted by positions
+	pkgFiles map[string][]string     // package paths -> "import/path/file.go" keys
+}
+
+// LoadCoverProfiles loads and merges the coverage profiles. The counts
+// of the same blocks in different profiles are summed up.
+func LoadCoverProfiles(filenames []string) (*CoverProfile, error) {
+	var blocks = make(map[string]map[CoverBlock]int) // the counts are zero in keys
+	var mode string
+	for _, filename := range filenames {
+		f, err := os.Open(filename)
+		if err != nil {
+			return nil, err
+		}
+		m, err := parseCoverProfile(f, blocks)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("parse %s error: %w", filename, err)
+		}
+		if mode != "" && m != mode {
+			return nil, fmt.Errorf("the mode (%s) of %s is different from the previous ones (%s)", m, filename, mode)
+		}
+		mode = m
+	}
+
+	profile := &CoverProfile{
+		Mode:     mode,
+		files:    make(map[string][]CoverBlock, len(blocks)),
+		pkgFiles: make(map[string][]string),
+	}
+	for file, counts := range blocks {
+		list := make([]CoverBlock, 0, len(counts))
+		for b, count := range counts {
+			b.Count = count
+			list = append(list, b)
+		}
+		sort.Slice(list, func(a, b int) bool {
+			if list[a].StartLine != list[b].StartLine {
+				return list[a].StartLine < list[b].StartLine
+			}
+			return list[a].StartCol < list[b].StartCol
+		})
+		profile.files[file] = list
+		pkgPath := path.Dir(file)
+		profile.pkgFiles[pkgPath] = append(profile.pkgFiles[pkgPath], file)
+	}
+	return profile, nil
+}
+
+var coverBlockRegexp = regexp.MustCompile(`^(.+):([0-9]+)\.([0-9]+),([0-9]+)\.([0-9]+) ([0-9]+) ([0-9]+)$`)
+
+// parseCoverProfile parses a coverage profile and merges its blocks into the
+// blocks map (keyed by files). The mode of the profile is returned.
+func parseCoverProfile(r io.Reader, blocks map[string]map[CoverBlock]int) (mode string, err error) {
+	scanner := bufio.NewScanner(r)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if lineNumber == 1 {
+			if !strings.HasPrefix(line, "mode: ") {
+				return "", fmt.Errorf("bad mode line: %s", line)
+			}
+			mode = line[len("mode: "):]
+			continue
+		}
+		if line == "" {
+			continue
+		}
+
+		m := coverBlockRegexp.FindStringSubmatch(line)
+		if m == nil {
+			return "", fmt.Errorf("line %d: bad block: %s", lineNumber, line)
+		}
+		var nums [6]int
+		for i := range nums {
+			nums[i], _ = strconv.Atoi(m[i+2])
+		}
+		b := CoverBlock{
+			StartLine: nums[0], StartCol: nums[1],
+			EndLine: nums[2], EndCol: nums[3],
+			NumStmts: nums[4],
+		}
+
+		counts := blocks[m[1]]
+		if counts == nil {
+			counts = make(map[CoverBlock]int)
+			blocks[m[1]] = counts
+		}
+		if mode == "set" {
+			if nums[5] > 0 {
+				counts[b] = 1
+			} else if _, ok := counts[b]; !ok {
+				counts[b] = 0
+			}
+		} else {
+			counts[b] += nums[5]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	if mode == "" {
+		return "", fmt.Errorf("empty profile")
+	}
+	return mode, nil
+}
+
+// FileBlocks returns the blocks in a source file of a package.
+func (p *CoverProfile) FileBlocks(pkgPath, bareFilename string) []CoverBlock {
+	return p.files[pkgPath+"/"+bareFilename]
+}
+
+// PackageCoverage returns false if the package is not in the profile.
+// p may be nil.
+func (p *CoverProfile) PackageCoverage(pkgPath string) (stats CoverStats, ok bool) {
+	if p == nil {
+		return stats, false
+	}
+	files := p.pkgFiles[pkgPath]
+	for _, file := range files {
+		for _, b := range p.files[file] {
+			stats.add(b)
+		}
+	}
+	return stats, len(files) > 0 && stats.NumStmts > 0
+}
+
+// RangeCoverage returns the coverage of the blocks located
+// in the specified range in a source file of a package.
+func (p *CoverProfile) RangeCoverage(pkgPath, bareFilename string, start, end token.Position) (stats CoverStats) {
+	for _, b := range p.FileBlocks(pkgPath, bareFilename) {
+		if b.StartLine < start.Line || b.StartLine == start.Line && b.StartCol < start.Column {
+			continue
+		}
+		if b.EndLine > end.Line || b.EndLine == end.Line && b.EndCol > end.Column {
+			continue
+		}
+		stats.add(b)
+	}
+	return
+}
+
+// FuncDeclCoverage returns false if the function is not in the profile.
+// p may be nil.
+func (p *CoverProfile) FuncDeclCoverage(pkg *code.Package, fd *ast.FuncDecl) (stats CoverStats, ok bool) {
+	if p == nil || pkg == nil || fd == nil || fd.Body == nil {
+		return stats, false
+	}
+	fset := pkg.PPkg.Fset
+	start := fset.PositionFor(fd.Pos(), false)
+	end := fset.PositionFor(fd.End(), false)
+	info := pkg.SourceFileInfoByFilePath(start.Filename)
+	if info == nil || info.GeneratedFile != "" && info.GeneratedFile != info.OriginalFile {
+		return stats, false
+	}
+	stats = p.RangeCoverage(pkg.Path(), info.BareFilename, start, end)
+	return stats, stats.NumStmts > 0
+}
+
+type FunctionCoverage struct {
+	Function *code.Function
+	CoverStats
+}
+
+// leastCoveredFunctions returns the functions (including methods) with the
+// lowest coverage percentages and the total coverage of all the packages.
+func (ds *docServer) leastCoveredFunctions(max int) (list []FunctionCoverage, total CoverStats) {
+	if coverProfile == nil {
+		return nil, total
+	}
+	for i := 0; i < ds.analyzer.NumPackages(); i++ {
+		pkg := ds.analyzer.PackageAt(i)
+		stats, ok := coverProfile.PackageCoverage(pkg.Path())
+		if !ok {
+			continue
+		}
+		total.NumStmts += stats.NumStmts
+		total.NumCovered += stats.NumCovered
+		for _, f := range pkg.PackageAnalyzeResult.AllFunctions {
+			if stats, ok := coverProfile.FuncDeclCoverage(f.Pkg, f.AstDecl); ok {
+				list = append(list, FunctionCoverage{Function: f, CoverStats: stats})
+			}
+		}
+	}
+	sort.Slice(list, func(a, b int) bool {
+		pa, pb := list[a].Percent(), list[b].Percent()
+		if pa != pb {
+			return pa < pb
+		}
+		ua, ub := list[a].NumStmts-list[a].NumCovered, list[b].NumStmts-list[b].NumCovered
+		if ua != ub {
+			return ua > ub
+		}
+		return list[a].Function.Position().String() < list[b].Function.Position().String()
+	})
+	if len(list) > max {
+		list = list[:max]
+	}
+	return list, total
+}
+
+// A coverSegment is a column range in a source line.
+type coverSegment struct {
+	start, end  int // [start, end), end is -1 for the line end
+	covered     bool
+	trimLeading bool // whether or not to skip the leading white spaces
+}
+
+// coverSegmentsByLines splits the blocks into segments in lines.
+// The segments in each line are sorted and not overlapped.
+func coverSegmentsByLines(blocks []CoverBlock) map[int][]coverSegment {
+	var lines = make(map[int][]coverSegment)
+	for _, b := range blocks {
+		for line := b.StartLine; line <= b.EndLine; line++ {
+			seg := coverSegment{start: 1, end: -1, covered: b.Count > 0}
+			if line == b.StartLine {
+				seg.start = b.StartCol
+			} else {
+				seg.trimLeading = true
+			}
+			if line == b.EndLine {
+				seg.end = b.EndCol
+			}
+			lines[line] = append(lines[line], seg)
+		}
+	}
+	for line, segs := range lines {
+		sort.SliceStable(segs, func(a, b int) bool {
+			return segs[a].start < segs[b].start
+		})
+		k := 0
+		for _, s := range segs {
+			if k > 0 {
+				last := segs[k-1]
+				if last.end < 0 {
+					continue
+				}
+				if s.start < last.end {
+					if s.end >= 0 && s.end <= last.end {
+						continue
+					}
+					s.start = last.end
+				}
+			}
+			segs[k] = s
+			k++
+		}
+		lines[line] = segs[:k]
+	}
+	return lines
+}
+
+// applyCoverSegments encloses the segments of an HTML-escaped source line
+// in "covered" and "uncovered" spans. The segment boundaries are assumed
+// to be not inside tokens, so that the spans are well nested with the
+// tags in the line.
+func applyCoverSegments(line string, segs []coverSegment) string {
+	if len(segs) == 0 {
+		return line
+	}
+
+	var b strings.Builder
+	b.Grow(len(line) + len(segs)*32)
+	var i, col, k = 0, 1, 0
+	var open = false
+	copyClosingTags := func() {
+		for strings.HasPrefix(line[i:], "</") {
+			j := strings.IndexByte(line[i:], '>')
+			if j < 0 {
+				return
+			}
+			b.WriteString(line[i : i+j+1])
+			i += j + 1
+		}
+	}
+	for {
+		if open {
+			if s := segs[k]; s.end >= 0 && col >= s.end {
+				// The closing tags of the token ending here are enclosed.
+				copyClosingTags()
+				b.WriteString(`</span>`)
+				open = false
+				k++
+				continue
+			}
+		} else if k < len(segs) && col >= segs[k].start && i < len(line) {
+			s := segs[k]
+			if s.end >= 0 && s.end <= col {
+				k++
+				continue
+			}
+			copyClosingTags()
+			if i < len(line) && !(s.trimLeading && (line[i] == ' ' || line[i] == '\t')) {
+				if s.covered {
+					b.WriteString(`<span class="covered">`)
+				} else {
+					b.WriteString(`<span class="uncovered">`)
+				}
+				open = true
+				continue
+			}
+		}
+
+		if i >= len(line) {
+			break
+		}
+		switch c := line[i]; c {
+		case '<':
+			j := strings.IndexByte(line[i:], '>')
+			if j < 0 {
+				j = len(line) - i - 1
+			}
+			b.WriteString(line[i : i+j+1])
+			i += j + 1
+		case '&': // an escaped byte
+			j := strings.IndexByte(line[i:], ';')
+			if j < 0 {
+				j = 0
+			}
+			b.WriteString(line[i : i+j+1])
+			i += j + 1
+			col++
+		default:
+			b.WriteByte(c)
+			i++
+			col++
+		}
+	}
+	if open {
+		b.WriteString(`</span>`)
+	}
+	return b.String()
+}
+
+// applyCoverageToSourceLines colors the covered and uncovered
+// statements in the source lines of a file if the file is in
+// the coverage profile. The file coverage is returned.
+func applyCoverageToSourceLines(result *SourceFileAnalyzeResult) (stats CoverStats, ok bool) {
+	if coverProfile == nil || result.GeneratedPath != "" {
+		return stats, false
+	}
+	blocks := coverProfile.FileBlocks(result.PkgPath, result.BareFilename)
+	if len(blocks) == 0 {
+		return stats, false
+	}
+	for line, segs := range coverSegmentsByLines(blocks) {
+		if line >= 1 && line <= len(result.Lines) {
+			result.Lines[line-1] = applyCoverSegments(result.Lines[line-1], segs)
+		}
+	}
+	for _, b := range blocks {
+		stats.add(b)
+	}
+	return stats, stats.NumStmts > 0
+}
+
+func writeCoverageBadge(page *htmlPage, stats CoverStats) {
+	fmt.Fprintf(page, ` <span class="coverage" title="%s">%s</span>`,
+		page.Translation().Text_CoverageStats(stats.NumCovered, stats.NumStmts),
+		page.Translation().Text_CoveragePercent(stats.Percent()),
+	)
+}
@@ -0,0 +1,772 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"log"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go101.org/golds/code"
+	"go101.org/golds/internal/util"
+)
+
+// A GitCommit is the brief info of a commit in a local git repository.
+type GitCommit struct {
+	Hash    string
+	Author  string
+	Time    time.Time
+	Summary string
+}
+
+// The hash of the lines not committed yet in "git blame" outputs.
+const gitUncommittedHash = "0000000000000000000000000000000000000000"
+
+func (c *GitCommit) Uncommitted() bool {
+	return c.Hash == gitUncommittedHash
+}
+
+func (c *GitCommit) ShortHash() string {
+	if len(c.Hash) > 7 {
+		return c.Hash[:7]
+	}
+	return c.Hash
+}
+
+func (c *GitCommit) Date() string {
+	return c.Time.Format("2006-01-02")
+}
+
+// The format used in "git log" commands. Each commit starts with a \x01 byte,
+// so that the commit lines can be distinguished from the other output lines.
+const gitLogFormat = "--format=%x01%H%x00%an%x00%at%x00%s"
+
+type GitLogEntry struct {
+	Commit *GitCommit
+	Files  []string // only collected for "git log --name-only" outputs
+}
+
+// parseGitLog parses the outputs of "git log" commands using gitLogFormat.
+// If withFiles is true, the non-blank lines following a commit line are
+// viewed as the paths of the files changed in the commit.
+func parseGitLog(output []byte, withFiles bool) []GitLogEntry {
+	var entries []GitLogEntry
+	for _, line := range strings.Split(string(output), "\n") {
+		if strings.HasPrefix(line, "\x01") {
+			fields := strings.Split(line[1:], "\x00")
+			if len(fields) < 4 {
+				continue
+			}
+			c := &GitCommit{Hash: fields[0], Author: fields[1], Summary: fields[3]}
+			if t, err := strconv.ParseInt(fields[2], 10, 64); err == nil {
+				c.Time = time.Unix(t, 0)
+			}
+			entries = append(entries, GitLogEntry{Commit: c})
+		} else if withFiles && len(entries) > 0 {
+			if line = strings.TrimSpace(line); line != "" {
+				e := &entries[len(entries)-1]
+				e.Files = append(e.Files, line)
+			}
+		}
+	}
+	return entries
+}
+
+// parseGitBlame parses the output of a "git blame --porcelain" command.
+// The returned slice is indexed by line numbers (starting from 0).
+func parseGitBlame(output []byte) []*GitCommit {
+	var lines []*GitCommit
+	var commits = make(map[string]*GitCommit)
+	var current *GitCommit
+	var currentLine int
+	for _, line := range strings.Split(string(output), "\n") {
+		if line == "" {
+			continue
+		}
+		if line[0] == '\t' {
+			if current != nil && currentLine > 0 {
+				for len(lines) < currentLine {
+					lines = append(lines, nil)
+				}
+				lines[currentLine-1] = current
+			}
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) >= 3 && len(fields[0]) == 40 && isHexString(fields[0]) {
+			current = commits[fields[0]]
+			if current == nil {
+				current = &GitCommit{Hash: fields[0]}
+				commits[fields[0]] = current
+			}
+			currentLine, _ = strconv.Atoi(fields[2])
+			continue
+		}
+		if current == nil {
+			continue
+		}
+
+		key, value := line, ""
+		if i := strings.IndexByte(line, ' '); i >= 0 {
+			key, value = line[:i], line[i+1:]
+		}
+		switch key {
+		case "author":
+			current.Author = value
+		case "author-time":
+			if t, err := strconv.ParseInt(value, 10, 64); err == nil {
+				current.Time = time.Unix(t, 0)
+			}
+		case "summary":
+			current.Summary = value
+		}
+	}
+	return lines
+}
+
+func isHexString(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func gitBlameFile(filePath string) ([]*GitCommit, error) {
+	output, err := util.RunShellCommand(time.Second*15, filepath.Dir(filePath), nil, "git", "blame", "--porcelain", "--", filepath.Base(filePath))
+	if err != nil {
+		return nil, err
+	}
+	return parseGitBlame(output), nil
+}
+
+// gitLineRangeHistory returns the commits touching the specified
+// line range of a file. The latest commits are put first.
+// The line numbers are for the working tree version of the file.
+// They are mapped to the HEAD version, which "git log -L" reads.
+func gitLineRangeHistory(filePath string, startLine, endLine int) ([]*GitCommit, error) {
+	hunks, err := gitDiffHunks(filePath)
+	if err != nil {
+		return nil, err
+	}
+	startLine, endLine = headLineRange(hunks, startLine, endLine)
+	if startLine > endLine {
+		return nil, nil // all the lines are not committed yet
+	}
+
+	lineRange := fmt.Sprintf("%d,%d:%s", startLine, endLine, filepath.Base(filePath))
+	output, err := util.RunShellCommand(time.Second*30, filepath.Dir(filePath), nil, "git", "log", "--no-patch", gitLogFormat, "-L", lineRange)
+	if err != nil {
+		return nil, err
+	}
+	entries := parseGitLog(output, false)
+	commits := make([]*GitCommit, len(entries))
+	for i, e := range entries {
+		commits[i] = e.Commit
+	}
+	return commits, nil
+}
+
+// headLineRange maps a working tree line range to the HEAD version
+// of a file, by the diff hunks of the file. A changed line is mapped to
+// the first (for startLine) or the last (for endLine) line of the old
+// lines of its hunk. If all the lines in the range are newly added,
+// the returned start line is larger than the returned end line.
+func headLineRange(hunks []GitDiffHunk, startLine, endLine int) (int, int) {
+	mapLine := func(line int, isEnd bool) int {
+		delta := 0
+		for _, h := range hunks {
+			switch {
+			case h.Count == 0: // lines are removed after line h.Start
+				if line <= h.Start {
+					return line + delta
+				}
+				delta += h.OldCount
+			case line >= h.Start+h.Count:
+				delta += h.OldCount - h.Count
+			case line >= h.Start:
+				// For added lines, OldStart is the line before them.
+				if h.OldCount == 0 {
+					if isEnd {
+						return h.OldStart
+					}
+					return h.OldStart + 1
+				}
+				if isEnd {
+					return h.OldStart + h.OldCount - 1
+				}
+				return h.OldStart
+			default:
+				return line + delta
+			}
+		}
+		return line + delta
+	}
+	return mapLine(startLine, false), mapLine(endLine, true)
+}
+func gitRepositoryTopLevelDir(dir string) (string, error) {
+	output, err := util.RunShellCommand(time.Second*5, dir, nil, "git", "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", err
+	}
+	return filepath.FromSlash(string(bytes.TrimSpace(output))), nil
+}
+
+// A GitDiffHunk is a changed line range (against HEAD) of a file.
+// Count is zero for the lines removed after the line Start.
+// OldStart and OldCount are the corresponding range in HEAD.
+// OldCount is zero for the lines added after the line OldStart.
+type GitDiffHunk struct {
+	Start, Count       int
+	OldStart, OldCount int
+}
+
+// parseGitDiffHunks parses the hunk headers in the output
+// of a "git diff --unified=0" command for one file.
+func parseGitDiffHunks(output []byte) []GitDiffHunk {
+	var hunks []GitDiffHunk
+	for _, line := range strings.Split(string(output), "\n") {
+		if !strings.HasPrefix(line, "@@ ") {
+			continue
+		}
+		// @@ -oldStart[,oldCount] +newStart[,newCount] @@
+		fields := strings.Fields(line)
+		if len(fields) < 3 || !strings.HasPrefix(fields[2], "+") {
+			continue
+		}
+		if !strings.HasPrefix(fields[1], "-") {
+			continue
+		}
+		var h GitDiffHunk
+		var err1, err2 error
+		h.Start, h.Count, err1 = parseGitDiffRange(fields[2][1:])
+		h.OldStart, h.OldCount, err2 = parseGitDiffRange(fields[1][1:])
+		if err1 == nil && err2 == nil {
+			hunks = append(hunks, h)
+		}
+	}
+	return hunks
+}
+
+// parseGitDiffRange parses a "start[,count]" range in a hunk header.
+func parseGitDiffRange(r string) (start, count int, err error) {
+	count = 1
+	if i := strings.IndexByte(r, ','); i >= 0 {
+		if count, err = strconv.Atoi(r[i+1:]); err != nil {
+			return
+		}
+		r = r[:i]
+	}
+	start, err = strconv.Atoi(r)
+	return
+}
+
+func gitDiffHunks(filePath string) ([]GitDiffHunk, error) {
+	output, err := util.RunShellCommand(time.Second*15, filepath.Dir(filePath), nil, "git", "diff", "HEAD", "--unified=0", "--no-color", "--no-ext-diff", "--", filepath.Base(filePath))
+	if err != nil {
+		return nil, err
+	}
+	return parseGitDiffHunks(output), nil
+}
+
+// GitFileAnnotations holds the git blame info of a source file.
+type GitFileAnnotations struct {
+	Status string // the uncommitted change status of the file
+
+	// Keyed by line numbers. Values: changed | deleted-below.
+	ChangedLines map[int]string
+
+	LineCommits []*GitCommit // indexed by line numbers (starting from 0)
+
+	// Keyed by the lines of declaration names.
+	Declarations map[int]*GitDeclarationChange
+}
+
+// GitDeclarationChange is the last change of a declaration.
+type GitDeclarationChange struct {
+	Commit *GitCommit
+
+	// Both are blank for non-function declarations.
+	RecvTypeName string
+	FuncName     string
+}
+
+// gitFileAnnotations returns nil if the git annotations are not
+// enabled or the file is not in a working directory package.
+func (ds *docServer) gitFileAnnotations(pkg *code.Package, fileInfo *code.SourceFileInfo) *GitFileAnnotations {
+	// The lines of generated files don't match the original ones.
+	if fileInfo.OriginalFile == "" || fileInfo.GeneratedFile != "" && fileInfo.GeneratedFile != fileInfo.OriginalFile {
+		return nil
+	}
+	wt := ds.gitWorkingTree()
+	if wt == nil || !ds.isWorkingDirectoryPackage(pkg) {
+		return nil
+	}
+
+	annotations := &GitFileAnnotations{
+		Status:       wt.FileStatus(pkg, fileInfo.BareFilename),
+		ChangedLines: make(map[int]string),
+		Declarations: make(map[int]*GitDeclarationChange),
+	}
+	if annotations.Status == GitFileStatus_Untracked {
+		return annotations
+	}
+
+	if annotations.Status == GitFileStatus_Modified {
+		hunks, err := gitDiffHunks(fileInfo.OriginalFile)
+		if err != nil && verboseLogs {
+			log.Printf("git diff %s error: %s", fileInfo.OriginalFile, err)
+		}
+		for _, h := range hunks {
+			if h.Count == 0 {
+				annotations.ChangedLines[h.Start] = "deleted-below"
+			}
+			for line := h.Start; line < h.Start+h.Count; line++ {
+				annotations.ChangedLines[line] = "changed"
+			}
+		}
+	}
+
+	lineCommits, err := gitBlameFile(fileInfo.OriginalFile)
+	if err != nil {
+		if verboseLogs {
+			log.Printf("git blame %s error: %s", fileInfo.OriginalFile, err)
+		}
+		return annotations
+	}
+	annotations.LineCommits = lineCommits
+
+	if fileInfo.AstFile == nil {
+		return annotations
+	}
+
+	fset := pkg.PPkg.Fset
+	// lastChange finds the latest commit touching the lines of a node and its doc.
+	lastChange := func(doc *ast.CommentGroup, node ast.Node) *GitCommit {
+		start, end := fset.PositionFor(node.Pos(), false).Line, fset.PositionFor(node.End(), false).Line
+		if doc != nil {
+			start = fset.PositionFor(doc.Pos(), false).Line
+		}
+		var latest *GitCommit
+		for line := start; line <= end && line <= len(lineCommits); line++ {
+			c := lineCommits[line-1]
+			switch {
+			case c == nil:
+			case latest == nil, c.Uncommitted():
+				latest = c
+			case latest.Uncommitted():
+			case c.Time.After(latest.Time):
+				latest = c
+			}
+		}
+		return latest
+	}
+	register := func(name *ast.Ident, change *GitDeclarationChange) {
+		if change.Commit != nil {
+			annotations.Declarations[fset.PositionFor(name.Pos(), false).Line] = change
+		}
+	}
+
+	for _, decl := range fileInfo.AstFile.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			register(decl.Name, &GitDeclarationChange{
+				Commit:       lastChange(decl.Doc, decl),
+				RecvTypeName: funcDeclRecvTypeName(decl),
+				FuncName:     decl.Name.Name,
+			})
+		case *ast.GenDecl:
+			if !decl.Lparen.IsValid() {
+				for _, spec := range decl.Specs {
+					if name := specFirstName(spec); name != nil {
+						register(name, &GitDeclarationChange{Commit: lastChange(decl.Doc, decl)})
+					}
+				}
+				continue
+			}
+			for _, spec := range decl.Specs {
+				name := specFirstName(spec)
+				if name == nil {
+					continue
+				}
+				var doc *ast.CommentGroup
+				switch spec := spec.(type) {
+				case *ast.ValueSpec:
+					doc = spec.Doc
+				case *ast.TypeSpec:
+					doc = spec.Doc
+				}
+				register(name, &GitDeclarationChange{Commit: lastChange(doc, spec)})
+			}
+		}
+	}
+	return annotations
+}
+
+// specFirstName returns the first declared identifier in a non-import spec.
+func specFirstName(spec ast.Spec) *ast.Ident {
+	switch spec := spec.(type) {
+	case *ast.ValueSpec:
+		if len(spec.Names) > 0 {
+			return spec.Names[0]
+		}
+	case *ast.TypeSpec:
+		return spec.Name
+	}
+	return nil
+}
+
+// writeGitCommitBrief writes the brief of a commit as plain text.
+func writeGitCommitBrief(page *htmlPage, c *GitCommit) {
+	if c.Uncommitted() {
+		page.WriteString(page.Translation().Text_GitUncommitted())
+		return
+	}
+	fmt.Fprintf(page, "%s %s ", c.ShortHash(), c.Date())
+	util.WriteHtmlEscapedString(page, c.Author)
+}
+
+// writeGitStatusBadge writes nothing for unchanged files.
+func writeGitStatusBadge(page *htmlPage, status string) {
+	if status == "" {
+		return
+	}
+	fmt.Fprintf(page, ` <span class="git-status %s" title="%s">%s</span>`,
+		status,
+		page.Translation().Text_UncommittedChanges(),
+		page.Translation().Text_GitFileStatus(status),
+	)
+}
+
+// writeGitLineTitle writes the title attribute (hover blame) of a code line.
+func writeGitLineTitle(page *htmlPage, c *GitCommit) {
+	page.WriteString(` title="`)
+	writeGitCommitBrief(page, c)
+	if !c.Uncommitted() {
+		page.WriteString(page.Translation().Text_Colon(false))
+		util.WriteHtmlEscapedString(page, c.Summary)
+	}
+	page.WriteString(`"`)
+}
+
+// writeGitDeclarationChange writes the last change of a declaration
+// (following the declaration name line), with a history link for functions.
+func writeGitDeclarationChange(page *htmlPage, pkg string, change *GitDeclarationChange) {
+	page.WriteString(`<span class="git-decl">`)
+	writeGitCommitBrief(page, change.Commit)
+	if change.FuncName != "" {
+		var pathInfo pagePathInfo
+		if change.RecvTypeName == "" {
+			pathInfo = createPagePathInfo2(ResTypeHistory, pkg, "..", change.FuncName)
+		} else {
+			pathInfo = createPagePathInfo3(ResTypeHistory, pkg, "..", change.RecvTypeName, change.FuncName)
+		}
+		page.WriteString(" ")
+		buildPageHref(page.PathInfo, pathInfo, page, page.Translation().Text_GitHistory())
+	}
+	page.WriteString(`</span>`)
+}
+
+// A GitSourceFile is a source file of a working directory package.
+type GitSourceFile struct {
+	Package      *code.Package
+	BareFilename string
+	Status       string // the uncommitted change status, blank for unchanged files
+
+	// For the files renamed (or copied) from another file in the same
+	// directory (whose status is "added"), the file name in HEAD.
+	HeadBareFilename string
+}
+
+// The uncommitted change statuses (against HEAD) of files.
+const (
+	GitFileStatus_Modified  = "modified"
+	GitFileStatus_Added     = "added"
+	GitFileStatus_Untracked = "untracked"
+	GitFileStatus_Deleted   = "deleted"
+)
+
+// GitWorkingTree is a snapshot of the git repositories containing the
+// working directory packages. It is taken when it is used the first time.
+type GitWorkingTree struct {
+	TopDirs []string                  // the top-level directories of the repositories
+	Files   map[string]*GitSourceFile // full paths -> files
+
+	// The packages containing changed (including deleted) source files,
+	// sorted by package paths.
+	DirtyPackages []*GitDirtyPackage
+	dirtyPackages map[*code.Package]*GitDirtyPackage
+}
+
+type GitDirtyPackage struct {
+	Package *code.Package
+	Files   []*GitSourceFile // sorted by filenames
+}
+
+// FileStatus returns the uncommitted change status of a source file.
+func (wt *GitWorkingTree) FileStatus(pkg *code.Package, bareFilename string) string {
+	if dp := wt.dirtyPackages[pkg]; dp != nil {
+		for _, f := range dp.Files {
+			if f.BareFilename == bareFilename {
+				return f.Status
+			}
+		}
+	}
+	return ""
+}
+
+// DirtyPackage returns nil if the package has no uncommitted changes.
+func (wt *GitWorkingTree) DirtyPackage(pkg *code.Package) *GitDirtyPackage {
+	return wt.dirtyPackages[pkg]
+}
+
+// gitWorkingTree returns nil if the git annotations are not enabled.
+func (ds *docServer) gitWorkingTree() *GitWorkingTree {
+	if !gitAnnotations {
+		return nil
+	}
+	ds.gitWorkingTreeOnce.Do(func() {
+		ds.theGitWorkingTree = ds.buildGitWorkingTree()
+	})
+	return ds.theGitWorkingTree
+}
+
+func (ds *docServer) buildGitWorkingTree() *GitWorkingTree {
+	wt := &GitWorkingTree{
+		Files:         make(map[string]*GitSourceFile),
+		dirtyPackages: make(map[*code.Package]*GitDirtyPackage),
+	}
+
+	var dirs = make(map[string]*code.Package) // directories -> packages
+	for i := 0; i < ds.analyzer.NumPackages(); i++ {
+		pkg := ds.analyzer.PackageAt(i)
+		if !ds.isWorkingDirectoryPackage(pkg) {
+			continue
+		}
+		dirs[pkg.Directory] = pkg
+		for k := range pkg.SourceFiles {
+			info := &pkg.SourceFiles[k]
+			if info.OriginalFile != "" {
+				wt.Files[info.OriginalFile] = &GitSourceFile{Package: pkg, BareFilename: info.BareFilename}
+			}
+		}
+	}
+
+	// Packages in the same repository share the same top-level directory.
+	var topDirs = make(map[string]bool)
+	for dir := range dirs {
+		top, err := gitRepositoryTopLevelDir(dir)
+		if err != nil {
+			if verboseLogs {
+				log.Printf("git rev-parse --show-toplevel (in %s) error: %s", dir, err)
+			}
+			continue
+		}
+		if !topDirs[top] {
+			topDirs[top] = true
+			wt.TopDirs = append(wt.TopDirs, top)
+		}
+	}
+	sort.Strings(wt.TopDirs)
+
+	for _, top := range wt.TopDirs {
+		output, err := util.RunShellCommand(time.Second*15, top, nil, "git", "status", "--porcelain", "-z", "--untracked-files=all")
+		if err != nil {
+			if verboseLogs {
+				log.Printf("git status (in %s) error: %s", top, err)
+			}
+			continue
+		}
+		statuses, headPaths := parseGitStatus(output)
+		for relPath, status := range statuses {
+			path := filepath.Join(top, filepath.FromSlash(relPath))
+			f := wt.Files[path]
+			if f == nil {
+				// Deleted files are not source files any more.
+				pkg := dirs[filepath.Dir(path)]
+				if status != GitFileStatus_Deleted || pkg == nil || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+					continue
+				}
+				f = &GitSourceFile{Package: pkg, BareFilename: filepath.Base(path)}
+			}
+			f.Status = status
+			if headPath, ok := headPaths[relPath]; ok {
+				headPath := filepath.Join(top, filepath.FromSlash(headPath))
+				if filepath.Dir(headPath) == filepath.Dir(path) {
+					f.HeadBareFilename = filepath.Base(headPath)
+				}
+			}
+
+			dp := wt.dirtyPackages[f.Package]
+			if dp == nil {
+				dp = &GitDirtyPackage{Package: f.Package}
+				wt.dirtyPackages[f.Package] = dp
+				wt.DirtyPackages = append(wt.DirtyPackages, dp)
+			}
+			dp.Files = append(dp.Files, f)
+		}
+	}
+
+	sort.Slice(wt.DirtyPackages, func(a, b int) bool {
+		return wt.DirtyPackages[a].Package.Path() < wt.DirtyPackages[b].Package.Path()
+	})
+	for _, dp := range wt.DirtyPackages {
+		sort.Slice(dp.Files, func(a, b int) bool {
+			return dp.Files[a].BareFilename < dp.Files[b].BareFilename
+		})
+	}
+	return wt
+}
+
+// parseGitStatus parses the output of a "git status --porcelain -z" command.
+// The keys of the returned maps are the file paths relative to the top-level
+// directory of the repository. Renamed and copied files are viewed as added
+// ones, and their original paths are returned in headPaths. The original
+// paths of renamed files are viewed as deleted.
+func parseGitStatus(output []byte) (statuses, headPaths map[string]string) {
+	statuses = make(map[string]string)
+	headPaths = make(map[string]string)
+	entries := strings.Split(string(output), "\x00")
+	for i := 0; i < len(entries); i++ {
+		e := entries[i]
+		if len(e) < 4 {
+			continue
+		}
+		x, y, path := e[0], e[1], e[3:]
+		switch {
+		case x == '?':
+			statuses[path] = GitFileStatus_Untracked
+		case x == 'R', x == 'C':
+			statuses[path] = GitFileStatus_Added
+			if i++; i < len(entries) {
+				orig := entries[i]
+				headPaths[path] = orig
+				if _, ok := statuses[orig]; !ok && x == 'R' {
+					statuses[orig] = GitFileStatus_Deleted
+				}
+			}
+		case x == 'A':
+			statuses[path] = GitFileStatus_Added
+		case x == 'D', y == 'D':
+			statuses[path] = GitFileStatus_Deleted
+		case x == '!':
+		default:
+			statuses[path] = GitFileStatus_Modified
+		}
+	}
+	return statuses, headPaths
+}
+
+type GitRecentChange struct {
+	Commit *GitCommit
+	Files  []*GitSourceFile
+}
+
+// gitRecentChanges returns the latest commits changing the source files
+// of the working directory packages. The latest commits are put first.
+func (ds *docServer) gitRecentChanges(maxCommits int) []GitRecentChange {
+	wt := ds.gitWorkingTree()
+	if wt == nil {
+		return nil
+	}
+
+	var changes []GitRecentChange
+	for _, top := range wt.TopDirs {
+		output, err := util.RunShellCommand(time.Second*15, top, nil, "git", "log", "-n", strconv.Itoa(maxCommits*4), "--name-only", gitLogFormat)
+		if err != nil {
+			if verboseLogs {
+				log.Printf("git log (in %s) error: %s", top, err)
+			}
+			continue
+		}
+		for _, e := range parseGitLog(output, true) {
+			var change = GitRecentChange{Commit: e.Commit}
+			for _, f := range e.Files {
+				if sf := wt.Files[filepath.Join(top, filepath.FromSlash(f))]; sf != nil {
+					change.Files = append(change.Files, sf)
+				}
+			}
+			if len(change.Files) > 0 {
+				changes = append(changes, change)
+			}
+		}
+	}
+
+	sort.SliceStable(changes, func(a, b int) bool {
+		return changes[a].Commit.Time.After(changes[b].Commit.Time)
+	})
+	if len(changes) > maxCommits {
+		changes = changes[:maxCommits]
+	}
+	return changes
+}
+
+func writeGitRecentChanges(page *htmlPage, changes []GitRecentChange) {
+	fmt.Fprintf(page, `
+<pre><code><span class="title">%s</span></code>`,
+		page.Translation().Text_RecentlyChanged(),
+	)
+	for _, change := range changes {
+		page.WriteString("\n\t")
+		writeGitCommitBrief(page, change.Commit)
+		page.WriteString(page.Translation().Text_Colon(false))
+		util.WriteHtmlEscapedString(page, change.Commit.Summary)
+		for _, f := range change.Files {
+			page.WriteString("\n\t\t")
+			buildPageHref(page.PathInfo, createPagePathInfo2b(ResTypeSource, f.Package.Path(), "/", f.BareFilename), page, f.Package.Path()+"/"+f.BareFilename)
+		}
+	}
+	page.WriteString("\n</pre>")
+}
+
+// writeGitDirtyPackageBadge writes nothing for
+// the packages without uncommitted changes.
+func (ds *docServer) writeGitDirtyPackageBadge(page *htmlPage, pkg *code.Package) {
+	wt := ds.gitWorkingTree()
+	if wt == nil {
+		return
+	}
+	dp := wt.DirtyPackage(pkg)
+	if dp == nil {
+		return
+	}
+	fmt.Fprintf(page, ` <a class="git-status" href="%s#changes-%s" title="%s">%s</a>`,
+		buildPageHref(page.PathInfo, createPagePathInfo(ResTypeNone, ""), nil, ""),
+		pkg.Path(),
+		page.Translation().Text_UncommittedChanges(),
+		page.Translation().Text_NumChangedFiles(len(dp.Files)),
+	)
+}
+
+func writeGitUncommittedChanges(page *htmlPage, wt *GitWorkingTree) {
+	fmt.Fprintf(page, `
+<pre><code><span class="title">%s</span></code>
+	<a href="%s">%s</a>
+`,
+		page.Translation().Text_UncommittedChanges(),
+		buildPageHref(page.PathInfo, createPagePathInfo(ResTypeNone, "api-changes"), nil, ""),
+		page.Translation().Text_ViewAPIChanges(),
+	)
+	for _, dp := range wt.DirtyPackages {
+		pkgPath := dp.Package.Path()
+		fmt.Fprintf(page, `<div class="anchor" id="changes-%s">	`, pkgPath)
+		buildPageHref(page.PathInfo, createPagePathInfo1(ResTypePackage, pkgPath), page, pkgPath)
+		for _, f := range dp.Files {
+			page.WriteString("\n\t\t")
+			if f.Status == GitFileStatus_Deleted {
+				page.WriteString(f.BareFilename)
+			} else {
+				buildPageHref(page.PathInfo, createPagePathInfo2b(ResTypeSource, pkgPath, "/", f.BareFilename), page, f.BareFilename)
+			}
+			writeGitStatusBadge(page, f.Status)
+		}
+		page.WriteString("</div>")
+	}
+	page.WriteString("</pre>")
+}
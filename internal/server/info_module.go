@@ -36,7 +36,10 @@ type CodeHost struct {
 	BuildSourceLink BuildSourceLinkFunc
 }
 
-var codeHosts = []CodeHost{
+// The code hosts declared in config files are prepended to the built-in ones.
+var codeHosts = builtinCodeHosts
+
+var builtinCodeHosts = []CodeHost{
 	{
 		ModulePathPrefix: "github.com/",
 		RepositryCharacteristics: []string{
@@ -286,7 +289,7 @@ func (ds *docServer) tryToCompleteModuleInfo(m *code.Module) {
 	// ToDo: handle modules feature off case in which module versions will always blank?
 	//       Or best not to generate any modules in this case.
 	//if m.ActualVersion() == "" && m.Replace.Path == "" { // wd module
-	if m == ds.analyzer.WorkingDirectoryModule() {
+	if ds.analyzer.IsWorkingDirectoryModule(m) {
 		//if !strings.HasPrefix(ds.initialWorkingDirectory, m.Dir) {
 		//	log.Printf("working directory module dir is not correct:\n\t%s\n\t%s", m.Dir, ds.initialWorkingDirectory)
 		//	return
@@ -491,31 +494,37 @@ func splitByNthSlash(path string, n int) (string, string) {
 const sep = string(filepath.Separator)
 const sepVendorSep = sep + "vendor" + sep
 
+// inVendor returns whether or not a package directory is in the vendor
+// directory of one of the working directory modules (there may be several
+// ones in workspace mode). If it is, the path in the vendor directory is
+// also returned.
 func (ds *docServer) inVendor(pkgDir string) (bool, string) {
-	dir := pkgDir
-	wdModule := ds.analyzer.WorkingDirectoryModule()
-	if wdModule == nil {
+	wdModules := ds.analyzer.WorkingDirectoryModules()
+	if len(wdModules) == 0 {
 		panic("should not")
 	}
-	if !strings.HasPrefix(dir, wdModule.Dir) {
-		return false, ""
-	}
-	dir = dir[len(wdModule.Dir):]
-	if !strings.HasPrefix(dir, sepVendorSep) {
-		return false, ""
+	for _, m := range wdModules {
+		if !strings.HasPrefix(pkgDir, m.Dir) {
+			continue
+		}
+		dir := pkgDir[len(m.Dir):]
+		if strings.HasPrefix(dir, sepVendorSep) {
+			return true, dir[len(sepVendorSep):]
+		}
 	}
-	dir = dir[len(sepVendorSep):]
-	return true, dir
+	return false, ""
 }
 
 var dotgit = []byte(".git")
 var slash = []byte("/")
 
-// Make sure d.wdModule is conirmed before call this method.
+// Make sure d.wdModules are conirmed before call this method.
+// In workspace mode, the used modules might be in different repositories,
+// so the git commands are run in the directory of the module.
 func (ds *docServer) tryRetrievingWorkdingDirectoryModuleInfo(m *code.Module) {
 
 	// ...
-	output, err := util.RunShellCommand(time.Second*5, "", nil, "git", "rev-parse", "--show-toplevel")
+	output, err := util.RunShellCommand(time.Second*5, m.Dir, nil, "git", "rev-parse", "--show-toplevel")
 	if err != nil {
 		if verboseLogs {
 			log.Println("unable to confirm wording diretory module: not in a CVS (only supports git now) directory")
@@ -525,7 +534,7 @@ func (ds *docServer) tryRetrievingWorkdingDirectoryModuleInfo(m *code.Module) {
 	projectLocalDir := string(bytes.TrimSpace(output))
 
 	// ...
-	output, err = util.RunShellCommand(time.Second*5, "", nil, "git", "rev-parse", "HEAD")
+	output, err = util.RunShellCommand(time.Second*5, m.Dir, nil, "git", "rev-parse", "HEAD")
 	if err != nil {
 		if verboseLogs {
 			log.Printf("unable to confirm wording diretory module: git rev-parse HEAD error: %s", err)
@@ -536,7 +545,7 @@ func (ds *docServer) tryRetrievingWorkdingDirectoryModuleInfo(m *code.Module) {
 
 	// ...
 	var remoteName string
-	output, err = util.RunShellCommand(time.Second*5, "", nil, "git", "remote")
+	output, err = util.RunShellCommand(time.Second*5, m.Dir, nil, "git", "remote")
 	if err != nil {
 		if verboseLogs {
 			log.Printf("unable to confirm wording diretory module: git remote error: %s", err)
@@ -550,7 +559,7 @@ func (ds *docServer) tryRetrievingWorkdingDirectoryModuleInfo(m *code.Module) {
 		firstRemote := string(bytes.TrimSpace(output[:i]))
 
 		// output: remote-name/remote-branch
-		output, err = util.RunShellCommand(time.Second*5, "", nil, "git", "rev-parse", "--abbrev-ref", "--symbolic-full-name", "@{upstream}")
+		output, err = util.RunShellCommand(time.Second*5, m.Dir, nil, "git", "rev-parse", "--abbrev-ref", "--symbolic-full-name", "@{upstream}")
 		if err != nil {
 			if verboseLogs {
 				log.Printf("unable to confirm wording diretory module: git rev-parse --abbrev-ref --symbolic-full-name @{upstream} error: %s", err)
@@ -566,7 +575,7 @@ func (ds *docServer) tryRetrievingWorkdingDirectoryModuleInfo(m *code.Module) {
 			remoteName = firstRemote
 		}
 	}
-	output, err = util.RunShellCommand(time.Second*5, "", nil, "git", "remote", "get-url", remoteName)
+	output, err = util.RunShellCommand(time.Second*5, m.Dir, nil, "git", "remote", "get-url", remoteName)
 	if err != nil {
 		if verboseLogs {
 			log.Printf("unable to confirm wording diretory module: git remote get-url origin %s error: %s", remoteName, output)
@@ -584,7 +593,7 @@ func (ds *docServer) tryRetrievingWorkdingDirectoryModuleInfo(m *code.Module) {
 
 	// ...
 	var warnings []string
-	output, err = util.RunShellCommand(time.Second*15, "", nil, "git", "status", "-s")
+	output, err = util.RunShellCommand(time.Second*15, m.Dir, nil, "git", "status", "-s")
 	output = bytes.TrimSpace(output)
 	if err != nil {
 		warnings = append(warnings, "unable to get project CVS commit status.")
@@ -621,7 +630,7 @@ func (ds *docServer) tryRetrievingWorkdingDirectoryModuleInfo(m *code.Module) {
 		m.Version = string(commitHash)
 		m.RepositoryDir = projectLocalDir
 		m.RepositoryURL = ensureHttpsRepositoryURL(projectRemoteURL)
-		ds.wdRepositoryWarnings = warnings
+		ds.addRepositoryWarnings(warnings)
 	}
 
 	if verboseLogs {
@@ -629,6 +638,22 @@ func (ds *docServer) tryRetrievingWorkdingDirectoryModuleInfo(m *code.Module) {
 	}
 }
 
+// addRepositoryWarnings is concurrency safe. Duplicated warnings
+// (from the workspace modules in the same repository) are ignored.
+func (ds *docServer) addRepositoryWarnings(warnings []string) {
+	ds.wdRepositoryWarningsMutex.Lock()
+	defer ds.wdRepositoryWarningsMutex.Unlock()
+NextWarning:
+	for _, w := range warnings {
+		for _, old := range ds.wdRepositoryWarnings {
+			if old == w {
+				continue NextWarning
+			}
+		}
+		ds.wdRepositoryWarnings = append(ds.wdRepositoryWarnings, w)
+	}
+}
+
 // ToDo: not a perfect implementation.
 func findSourceRepository(forModule string) (repoURL, extraPath string, err error) {
 	gogetURL := "https://" + forModule + "?go-get=1"
@@ -0,0 +1,575 @@
+package server
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"go/ast"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"go101.org/golds/code"
+)
+
+// CPUProfile is a simplified pprof profile (generated by "go test -cpuprofile",
+// runtime/pprof or net/http/pprof). Only the locations of samples are kept.
+type CPUProfile struct {
+	SampleType string // such as "cpu"
+	SampleUnit string // such as "nanoseconds"
+	Total      int64
+
+	Samples []ProfileSample
+}
+
+type ProfileSample struct {
+	Value  int64
+	Frames []ProfileFrame // the leaf frame is the first
+}
+
+type ProfileFrame struct {
+	Function string // such as "example.com/pkg.(*T).M"
+	Filename string
+	Line     int
+}
+
+// LoadCPUProfile loads a (gzipped or not) pprof profile file.
+func LoadCPUProfile(filename string) (*CPUProfile, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b {
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("read %s error: %w", filename, err)
+		}
+		data, err = io.ReadAll(r)
+		if err != nil {
+			return nil, fmt.Errorf("read %s error: %w", filename, err)
+		}
+	}
+	profile, err := parseCPUProfile(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s error: %w", filename, err)
+	}
+	return profile, nil
+}
+
+// The wire types of protobuf fields.
+const (
+	protoVarint  = 0
+	protoFixed64 = 1
+	protoBytes   = 2
+	protoFixed32 = 5
+)
+
+// forEachProtoField calls f for each field in a protobuf message. For fields
+// of the bytes wire type, b is the field content. Otherwise, v is the value.
+func forEachProtoField(data []byte, f func(num, wireType int, v uint64, b []byte) error) error {
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return errors.New("bad field key")
+		}
+		data = data[n:]
+		num, wireType := int(key>>3), int(key&7)
+
+		var v uint64
+		var b []byte
+		switch wireType {
+		default:
+			return fmt.Errorf("unsupported wire type: %d", wireType)
+		case protoVarint:
+			v, n = binary.Uvarint(data)
+			if n <= 0 {
+				return errors.New("bad varint")
+			}
+		case protoFixed64:
+			if n = 8; len(data) < n {
+				return errors.New("bad fixed64")
+			}
+			v = binary.LittleEndian.Uint64(data)
+		case protoFixed32:
+			if n = 4; len(data) < n {
+				return errors.New("bad fixed32")
+			}
+			v = uint64(binary.LittleEndian.Uint32(data))
+		case protoBytes:
+			length, k := binary.Uvarint(data)
+			if k <= 0 || uint64(len(data)-k) < length {
+				return errors.New("bad length-delimited field")
+			}
+			b, n = data[k:k+int(length)], k+int(length)
+		}
+		data = data[n:]
+
+		if err := f(num, wireType, v, b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appendProtoInts appends the values of a packed or unpacked repeated integer field.
+func appendProtoInts(list []uint64, wireType int, v uint64, b []byte) ([]uint64, error) {
+	if wireType != protoBytes {
+		return append(list, v), nil
+	}
+	for len(b) > 0 {
+		v, n := binary.Uvarint(b)
+		if n <= 0 {
+			return list, errors.New("bad packed varint")
+		}
+		list = append(list, v)
+		b = b[n:]
+	}
+	return list, nil
+}
+
+// parseCPUProfile parses an uncompressed pprof profile.
+// See https://github.com/google/pprof/blob/main/proto/profile.proto
+func parseCPUProfile(data []byte) (*CPUProfile, error) {
+	type valueType struct{ typ, unit uint64 }
+	type sample struct{ locations, values []uint64 }
+	type line struct{ function, line uint64 }
+	type function struct{ name, filename uint64 }
+
+	var sampleTypes []valueType
+	var samples []sample
+	var locations = make(map[uint64][]line)
+	var functions = make(map[uint64]function)
+	var stringTable []string
+	var defaultSampleType uint64
+
+	err := forEachProtoField(data, func(num, wireType int, v uint64, b []byte) error {
+		switch num {
+		case 1: // sample_type
+			var vt valueType
+			err := forEachProtoField(b, func(num, _ int, v uint64, _ []byte) error {
+				switch num {
+				case 1:
+					vt.typ = v
+				case 2:
+					vt.unit = v
+				}
+				return nil
+			})
+			sampleTypes = append(sampleTypes, vt)
+			return err
+		case 2: // sample
+			var s sample
+			err := forEachProtoField(b, func(num, wireType int, v uint64, b []byte) (err error) {
+				switch num {
+				case 1:
+					s.locations, err = appendProtoInts(s.locations, wireType, v, b)
+				case 2:
+					s.values, err = appendProtoInts(s.values, wireType, v, b)
+				}
+				return
+			})
+			samples = append(samples, s)
+			return err
+		case 4: // location
+			var id uint64
+			var lines []line
+			err := forEachProtoField(b, func(num, _ int, v uint64, b []byte) error {
+				switch num {
+				case 1:
+					id = v
+				case 4:
+					lines = append(lines, line{})
+					return forEachProtoField(b, func(num, _ int, v uint64, _ []byte) error {
+						switch num {
+						case 1:
+							lines[len(lines)-1].function = v
+						case 2:
+							lines[len(lines)-1].line = v
+						}
+						return nil
+					})
+				}
+				return nil
+			})
+			locations[id] = lines
+			return err
+		case 5: // function
+			var id uint64
+			var f function
+			err := forEachProtoField(b, func(num, _ int, v uint64, _ []byte) error {
+				switch num {
+				case 1:
+					id = v
+				case 2:
+					f.name = v
+				case 4:
+					f.filename = v
+				}
+				return nil
+			})
+			functions[id] = f
+			return err
+		case 6: // string_table
+			stringTable = append(stringTable, string(b))
+		case 14: // default_sample_type
+			defaultSampleType = v
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	str := func(i uint64) string {
+		if i < uint64(len(stringTable)) {
+			return stringTable[i]
+		}
+		return ""
+	}
+
+	if len(sampleTypes) == 0 {
+		return nil, errors.New("no sample types")
+	}
+	// The last sample type is used by default, the same as pprof.
+	valueIndex := len(sampleTypes) - 1
+	if defaultSampleType != 0 {
+		for i, vt := range sampleTypes {
+			if vt.typ == defaultSampleType {
+				valueIndex = i
+			}
+		}
+	}
+
+	profile := &CPUProfile{
+		SampleType: str(sampleTypes[valueIndex].typ),
+		SampleUnit: str(sampleTypes[valueIndex].unit),
+		Samples:    make([]ProfileSample, 0, len(samples)),
+	}
+	for _, s := range samples {
+		if valueIndex >= len(s.values) {
+			continue
+		}
+		ps := ProfileSample{Value: int64(s.values[valueIndex])}
+		if ps.Value == 0 {
+			continue
+		}
+		for _, id := range s.locations {
+			// The lines of a location are the inlined calls,
+			// the innermost one is the first.
+			for _, l := range locations[id] {
+				f := functions[l.function]
+				ps.Frames = append(ps.Frames, ProfileFrame{
+					Function: str(f.name),
+					Filename: str(f.filename),
+					Line:     int(l.line),
+				})
+			}
+		}
+		profile.Total += ps.Value
+		profile.Samples = append(profile.Samples, ps)
+	}
+	return profile, nil
+}
+
+// FormatValue formats a sample value with the sample unit.
+func (p *CPUProfile) FormatValue(v int64) string {
+	if p.SampleUnit == "nanoseconds" {
+		switch d := time.Duration(v); {
+		case d >= time.Second:
+			return fmt.Sprintf("%.2fs", d.Seconds())
+		case d >= time.Millisecond:
+			return fmt.Sprintf("%.2fms", float64(d)/float64(time.Millisecond))
+		default:
+			return d.String()
+		}
+	}
+	return fmt.Sprint(v)
+}
+
+// FormatValueWithPercent formats a sample value and its percentage of the total.
+func (p *CPUProfile) FormatValueWithPercent(v int64) string {
+	return fmt.Sprintf("%s (%.1f%%)", p.FormatValue(v), float64(v)*100/float64(p.Total))
+}
+
+type ProfileCost struct {
+	Flat int64 // the samples in which the line (or function) is the leaf
+	Cum  int64 // the samples in which the line (or function) is on the stack
+}
+
+type FunctionProfile struct {
+	Function *code.Function
+	ProfileCost
+
+	Callers []ProfileCaller // sorted by costs
+}
+
+type ProfileCaller struct {
+	Function *code.Function
+	Cost     int64
+}
+
+type FileProfile struct {
+	Lines    map[int]*ProfileCost
+	HotLines map[int]bool
+}
+
+// A ProfileOverlay is the result of mapping the samples
+// of a CPU profile to the analyzed functions and lines.
+type ProfileOverlay struct {
+	Profile   *CPUProfile
+	Functions []*FunctionProfile // sorted by flat costs
+	HotLines  []ProfileHotLine   // sorted by flat costs
+
+	functions map[*ast.FuncDecl]*FunctionProfile
+	files     map[string]*FileProfile // "import/path/file.go" keys
+}
+
+type ProfileHotLine struct {
+	Package      *code.Package
+	BareFilename string
+	Line         int
+	ProfileCost
+}
+
+// FunctionProfile returns nil if the function is not in any samples.
+func (o *ProfileOverlay) FunctionProfile(fd *ast.FuncDecl) *FunctionProfile {
+	return o.functions[fd]
+}
+
+// FileProfile returns nil if the file is not in any samples.
+func (o *ProfileOverlay) FileProfile(pkgPath, bareFilename string) *FileProfile {
+	return o.files[pkgPath+"/"+bareFilename]
+}
+
+// profileOverlay returns nil if no profile is specified.
+func (ds *docServer) profileOverlay() *ProfileOverlay {
+	if cpuProfile == nil {
+		return nil
+	}
+	ds.profileOverlayOnce.Do(func() {
+		ds.theProfileOverlay = ds.buildProfileOverlay(cpuProfile, 20)
+	})
+	return ds.theProfileOverlay
+}
+
+// profileSourceFile is used to map sample frames to functions and lines.
+type profileSourceFile struct {
+	pkg   *code.Package
+	bare  string
+	funcs []profileFuncRange // sorted by lines
+}
+
+type profileFuncRange struct {
+	startLine, endLine int
+	function           *code.Function
+}
+
+// function returns the function declared at the line. The closures
+// in a function declaration are viewed as parts of the function.
+func (sf *profileSourceFile) function(line int) *code.Function {
+	i := sort.Search(len(sf.funcs), func(i int) bool {
+		return sf.funcs[i].endLine >= line
+	})
+	if i < len(sf.funcs) && sf.funcs[i].startLine <= line {
+		return sf.funcs[i].function
+	}
+	return nil
+}
+
+// buildProfileOverlay maps the samples to the analyzed functions (by positions).
+// The lines with the most flat costs (at most numHotLines) are the hot lines.
+func (ds *docServer) buildProfileOverlay(profile *CPUProfile, numHotLines int) *ProfileOverlay {
+	// The filenames in profiles are full paths, or "import/path/file.go"
+	// forms if the profiled programs are built with -trimpath.
+	var files = make(map[string]*profileSourceFile)
+	for i := 0; i < ds.analyzer.NumPackages(); i++ {
+		pkg := ds.analyzer.PackageAt(i)
+		fileByPath := make(map[string]*profileSourceFile)
+		for k := range pkg.SourceFiles {
+			info := &pkg.SourceFiles[k]
+			if info.AstFile == nil || info.OriginalFile == "" || info.GeneratedFile != "" && info.GeneratedFile != info.OriginalFile {
+				continue
+			}
+			sf := &profileSourceFile{pkg: pkg, bare: info.BareFilename}
+			files[filepath.ToSlash(info.OriginalFile)] = sf
+			files[pkg.Path()+"/"+info.BareFilename] = sf
+			fileByPath[info.OriginalFile] = sf
+		}
+		fset := pkg.PPkg.Fset
+		for _, f := range pkg.PackageAnalyzeResult.AllFunctions {
+			if f.AstDecl == nil || f.AstDecl.Body == nil {
+				continue
+			}
+			start := fset.PositionFor(f.AstDecl.Pos(), false)
+			if sf := fileByPath[start.Filename]; sf != nil {
+				end := fset.PositionFor(f.AstDecl.End(), false)
+				sf.funcs = append(sf.funcs, profileFuncRange{start.Line, end.Line, f})
+			}
+		}
+		for _, sf := range fileByPath {
+			sort.Slice(sf.funcs, func(a, b int) bool {
+				return sf.funcs[a].startLine < sf.funcs[b].startLine
+			})
+		}
+	}
+
+	overlay := &ProfileOverlay{
+		Profile:   profile,
+		functions: make(map[*ast.FuncDecl]*FunctionProfile),
+		files:     make(map[string]*FileProfile),
+	}
+	type lineKey struct {
+		file *profileSourceFile
+		line int
+	}
+	type callEdge struct{ caller, callee *code.Function }
+	var lineCosts = make(map[lineKey]*ProfileCost)
+	var callerCosts = make(map[callEdge]int64)
+	var seenLines = make(map[lineKey]bool)
+	var seenFuncs = make(map[*code.Function]bool)
+	var seenEdges = make(map[callEdge]bool)
+
+	for _, s := range profile.Samples {
+		// Recursive calls and the lines (or functions) appearing
+		// more than once in a stack are only counted once.
+		for k := range seenLines {
+			delete(seenLines, k)
+		}
+		for k := range seenFuncs {
+			delete(seenFuncs, k)
+		}
+		for k := range seenEdges {
+			delete(seenEdges, k)
+		}
+
+		var callee *code.Function
+		for i, fr := range s.Frames {
+			sf := files[filepath.ToSlash(fr.Filename)]
+			if sf == nil {
+				callee = nil
+				continue
+			}
+
+			key := lineKey{sf, fr.Line}
+			cost := lineCosts[key]
+			if cost == nil {
+				cost = &ProfileCost{}
+				lineCosts[key] = cost
+			}
+			if i == 0 {
+				cost.Flat += s.Value
+			}
+			if !seenLines[key] {
+				seenLines[key] = true
+				cost.Cum += s.Value
+			}
+
+			f := sf.function(fr.Line)
+			if f == nil {
+				callee = nil
+				continue
+			}
+			fp := overlay.functions[f.AstDecl]
+			if fp == nil {
+				fp = &FunctionProfile{Function: f}
+				overlay.functions[f.AstDecl] = fp
+			}
+			if i == 0 {
+				fp.Flat += s.Value
+			}
+			if !seenFuncs[f] {
+				seenFuncs[f] = true
+				fp.Cum += s.Value
+			}
+			if callee != nil && callee != f {
+				if edge := (callEdge{f, callee}); !seenEdges[edge] {
+					seenEdges[edge] = true
+					callerCosts[edge] += s.Value
+				}
+			}
+			callee = f
+		}
+	}
+
+	for edge, cost := range callerCosts {
+		fp := overlay.functions[edge.callee.AstDecl]
+		fp.Callers = append(fp.Callers, ProfileCaller{Function: edge.caller, Cost: cost})
+	}
+	for _, fp := range overlay.functions {
+		sort.Slice(fp.Callers, func(a, b int) bool {
+			if fp.Callers[a].Cost != fp.Callers[b].Cost {
+				return fp.Callers[a].Cost > fp.Callers[b].Cost
+			}
+			return fp.Callers[a].Function.Position().String() < fp.Callers[b].Function.Position().String()
+		})
+		overlay.Functions = append(overlay.Functions, fp)
+	}
+	sort.Slice(overlay.Functions, func(a, b int) bool {
+		fa, fb := overlay.Functions[a], overlay.Functions[b]
+		if fa.Flat != fb.Flat {
+			return fa.Flat > fb.Flat
+		}
+		if fa.Cum != fb.Cum {
+			return fa.Cum > fb.Cum
+		}
+		return fa.Function.Position().String() < fb.Function.Position().String()
+	})
+
+	for key, cost := range lineCosts {
+		k := key.file.pkg.Path() + "/" + key.file.bare
+		fp := overlay.files[k]
+		if fp == nil {
+			fp = &FileProfile{Lines: make(map[int]*ProfileCost), HotLines: make(map[int]bool)}
+			overlay.files[k] = fp
+		}
+		fp.Lines[key.line] = cost
+		if cost.Flat > 0 {
+			overlay.HotLines = append(overlay.HotLines, ProfileHotLine{
+				Package:      key.file.pkg,
+				BareFilename: key.file.bare,
+				Line:         key.line,
+				ProfileCost:  *cost,
+			})
+		}
+	}
+	sort.Slice(overlay.HotLines, func(a, b int) bool {
+		la, lb := &overlay.HotLines[a], &overlay.HotLines[b]
+		if la.Flat != lb.Flat {
+			return la.Flat > lb.Flat
+		}
+		if la.Package.Path() != lb.Package.Path() {
+			return la.Package.Path() < lb.Package.Path()
+		}
+		if la.BareFilename != lb.BareFilename {
+			return la.BareFilename < lb.BareFilename
+		}
+		return la.Line < lb.Line
+	})
+	if len(overlay.HotLines) > numHotLines {
+		overlay.HotLines = overlay.HotLines[:numHotLines]
+	}
+	for _, hl := range overlay.HotLines {
+		overlay.FileProfile(hl.Package.Path(), hl.BareFilename).HotLines[hl.Line] = true
+	}
+
+	return overlay
+}
+
+func writeProfileCost(page *htmlPage, profile *CPUProfile, cost ProfileCost) {
+	page.WriteString(` <span class="profile-cost">`)
+	page.WriteString(page.Translation().Text_ProfileCost(profile.FormatValueWithPercent(cost.Flat), profile.FormatValueWithPercent(cost.Cum)))
+	page.WriteString(`</span>`)
+}
+
+// writeFunctionProfileCost writes nothing if the function is not in any samples.
+func (ds *docServer) writeFunctionProfileCost(page *htmlPage, fd *ast.FuncDecl) {
+	if overlay := ds.profileOverlay(); overlay != nil && fd != nil {
+		if fp := overlay.FunctionProfile(fd); fp != nil {
+			writeProfileCost(page, overlay.Profile, fp.ProfileCost)
+		}
+	}
+}
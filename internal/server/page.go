@@ -9,6 +9,7 @@ import (
 	"strings"
 	"sync"
 
+	"go101.org/golds/code"
 	"go101.org/golds/internal/server/translations"
 )
 
@@ -26,6 +27,39 @@ type PageOutputOptions struct {
 	WdPkgsListingManner    string
 	FooterShowingManner    string
 
+	// The first one is the primary platform. The others
+	// are used to find platform-specific declarations.
+	Platforms []code.Platform
+
+	// The build tags used to load packages.
+	BuildTags []string
+
+	// Whether or not to go on analyzing packages with errors.
+	Tolerant bool
+
+	// Extra code hosts, which take priority over the built-in ones.
+	CodeHosts []CodeHost
+
+	// For docs generation mode only. If it is true, only the changed
+	// files are written and the orphaned ones are removed.
+	Incremental bool
+
+	// For docs generation mode only. If it is not blank, the docs are
+	// generated as an embeddable Go package with this name.
+	EmbedPackage string
+
+	// Whether or not to annotate the source files of the working
+	// directory packages with the info retrieved by the local git.
+	GitAnnotations bool
+
+	// The merged coverage profiles (might be nil). The coverage
+	// info is shown in source code and some other pages.
+	CoverProfile *CoverProfile
+
+	// The CPU profile (might be nil). The costs are shown in
+	// the hot functions page, source code and package pages.
+	CPUProfile *CPUProfile
+
 	// ToDo:
 	//ListUnexportedRes   bool
 }
@@ -51,6 +85,14 @@ var (
 
 	verboseLogs = false
 
+	gitAnnotations = false
+	coverProfile   *CoverProfile
+	cpuProfile     *CPUProfile
+
+	// The primary target platform.
+	targetGOOS, targetGOARCH = build.Default.GOOS, build.Default.GOARCH
+	buildTags                = "" // comma-separated
+
 	// ToDo: use this one to replace the above ones, and put it in docServer (good or bad?).
 	pageOutputOptions PageOutputOptions
 
@@ -68,6 +110,21 @@ func setPageOutputOptions(options PageOutputOptions, forTesting bool) {
 	wdPkgsListingManner = options.WdPkgsListingManner
 	footerShowingManner = options.FooterShowingManner
 	verboseLogs = options.VerboseLogs
+	gitAnnotations = options.GitAnnotations && !forTesting
+	coverProfile = options.CoverProfile
+	cpuProfile = options.CPUProfile
+	targetGOOS, targetGOARCH = build.Default.GOOS, build.Default.GOARCH
+	buildTags = strings.Join(options.BuildTags, ",")
+	codeHosts = append(options.CodeHosts[:len(options.CodeHosts):len(options.CodeHosts)], builtinCodeHosts...)
+	if len(options.Platforms) > 0 {
+		p := options.Platforms[0]
+		if p.GOOS != "" {
+			targetGOOS = p.GOOS
+		}
+		if p.GOARCH != "" {
+			targetGOARCH = p.GOARCH
+		}
+	}
 }
 
 const (
@@ -97,6 +154,8 @@ const (
 	ResTypeImplementation pageResType = "imp"
 	ResTypeSource         pageResType = "src"
 	ResTypeReference      pageResType = "use"
+	ResTypeLayout         pageResType = "lay"
+	ResTypeHistory        pageResType = "his"
 	ResTypeCSS            pageResType = "css"
 	ResTypeJS             pageResType = "jvs"
 	ResTypeSVG            pageResType = "svg"
@@ -116,6 +175,8 @@ func isHTMLPage(res pageResType) bool {
 	case ResTypeImplementation:
 	case ResTypeSource:
 	case ResTypeReference:
+	case ResTypeLayout:
+	case ResTypeHistory:
 	}
 	return true
 }
@@ -216,6 +277,7 @@ func NewHtmlPage(goldsVersion, title string, theme Theme, translation Translatio
 			buildPageHref(currentPageInfo, createPagePathInfo(ResTypeCSS, addVersionToFilename(theme.Name(), goldsVersion)), nil, ""),
 			buildPageHref(currentPageInfo, createPagePathInfo(ResTypeJS, addVersionToFilename("golds", goldsVersion)), nil, ""),
 		)
+		writeDocsVersionSwitcher(&page)
 	}
 
 	return &page
@@ -232,7 +294,7 @@ func (page *htmlPage) Done(w io.Writer) []byte {
 			page.WriteString(`<pre id="footer">`)
 			page.WriteByte('\n')
 			if footerShowingManner == FooterShowingManner_simple {
-				footer = page.translation.Text_GeneratedPageFooterSimple(goldsVersion, build.Default.GOOS, build.Default.GOARCH)
+				footer = page.translation.Text_GeneratedPageFooterSimple(goldsVersion, targetGOOS, targetGOARCH, buildTags)
 			} else { // FooterShowingManner_verbose, FooterShowingManner_verbose_and_qrcode
 				var qrImgLink string
 				if footerShowingManner == FooterShowingManner_verbose_and_qrcode {
@@ -243,7 +305,7 @@ func (page *htmlPage) Done(w io.Writer) []byte {
 						qrImgLink = buildPageHref(page.PathInfo, createPagePathInfo(ResTypePNG, "go101-twitter"), nil, "")
 					}
 				}
-				footer = page.translation.Text_GeneratedPageFooter(goldsVersion, qrImgLink, build.Default.GOOS, build.Default.GOARCH)
+				footer = page.translation.Text_GeneratedPageFooter(goldsVersion, qrImgLink, targetGOOS, targetGOARCH, buildTags)
 			}
 			page.WriteString(footer)
 			page.WriteString(`</pre>`)
@@ -0,0 +1,131 @@
+package server
+
+import (
+	"fmt"
+	"go/token"
+	"html"
+	"net/http"
+	"path/filepath"
+	"strconv"
+
+	"go101.org/golds/code"
+)
+
+func (ds *docServer) analysisProblemsPage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html")
+
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+
+	if ds.phase < Phase_Analyzed {
+		w.WriteHeader(http.StatusTooEarly)
+		ds.loadingPage(w, r)
+		return
+	}
+
+	pageKey := pageCacheKey{
+		resType: ResTypeNone,
+		res:     "problems",
+	}
+	data, ok := ds.cachedPage(pageKey)
+	if !ok {
+		data = ds.buildAnalysisProblemsPage(w, buildAnalysisProblemsData(ds.analyzer))
+		ds.cachePage(pageKey, data)
+	}
+	w.Write(data)
+}
+
+type PackageProblems struct {
+	PkgPath  string
+	Package  *code.Package // nil for the problems not belonging to a package
+	Problems []*code.Problem
+}
+
+func buildAnalysisProblemsData(analyzer *code.CodeAnalyzer) []PackageProblems {
+	var list []PackageProblems
+	for _, p := range analyzer.Problems() { // sorted by package paths
+		if n := len(list); n == 0 || list[n-1].PkgPath != p.PkgPath {
+			list = append(list, PackageProblems{
+				PkgPath: p.PkgPath,
+				Package: analyzer.PackageByPath(p.PkgPath),
+			})
+		}
+		pp := &list[len(list)-1]
+		pp.Problems = append(pp.Problems, p)
+	}
+	return list
+}
+
+func (ds *docServer) buildAnalysisProblemsPage(w http.ResponseWriter, list []PackageProblems) []byte {
+	page := NewHtmlPage(goldsVersion, ds.currentTranslation.Text_AnalysisProblems(), ds.currentTheme, ds.currentTranslation, createPagePathInfo(ResTypeNone, "problems"))
+
+	fmt.Fprintf(page, `<pre><code><span style="font-size:x-large;">%s</span>
+`,
+		page.Translation().Text_AnalysisProblems(),
+	)
+
+	if len(list) == 0 {
+		fmt.Fprintf(page, "\n\t%s\n", page.Translation().Text_NoAnalysisProblems())
+	}
+
+	for _, pp := range list {
+		page.WriteString("\n")
+		if pp.Package != nil {
+			fmt.Fprintf(page, `<div class="anchor" id="pkg-%s">`, pp.PkgPath)
+			fmt.Fprintf(page, `<span class="title"><a href="%s">%s</a>`,
+				buildPageHref(page.PathInfo, createPagePathInfo1(ResTypePackage, pp.PkgPath), nil, ""),
+				pp.PkgPath,
+			)
+		} else {
+			page.WriteString(`<div>`)
+			fmt.Fprintf(page, `<span class="title">%s`, html.EscapeString(pp.PkgPath))
+		}
+		fmt.Fprintf(page, `<span class="title-stat"><i>%s</i></span></span>`,
+			page.Translation().Text_EnclosedInOarentheses(strconv.Itoa(len(pp.Problems))),
+		)
+		for _, p := range pp.Problems {
+			fmt.Fprintf(page, "\n\t<i>%s</i> ", p.Kind)
+			writeProblemPosition(page, pp.Package, p.Pos)
+			page.WriteString(html.EscapeString(p.Msg))
+		}
+		page.WriteString("\n</div>")
+	}
+
+	page.WriteString("</code></pre>")
+	return page.Done(w)
+}
+
+func writeProblemPosition(page *htmlPage, pkg *code.Package, pos token.Position) {
+	if pos.Filename == "" {
+		return
+	}
+
+	text := filepath.Base(pos.Filename)
+	if pos.Line > 0 {
+		text += ":" + strconv.Itoa(pos.Line)
+		if pos.Column > 0 {
+			text += ":" + strconv.Itoa(pos.Column)
+		}
+	}
+	if pkg != nil && pos.Line > 0 && pkg.SourceFileInfoByFilePath(pos.Filename) != nil {
+		writeSrouceCodeLineLink(page, pkg, pos, text, "")
+	} else {
+		page.WriteString(html.EscapeString(text))
+	}
+	page.WriteString(": ")
+}
+
+// writeProblemsBadge writes the number of analysis problems of a package,
+// linking to the analysis problems page. Nothing is written if there are none.
+func (ds *docServer) writeProblemsBadge(page *htmlPage, pkgPath string) {
+	n := len(ds.analyzer.PackageProblems(pkgPath))
+	if n == 0 {
+		return
+	}
+	fmt.Fprintf(page, ` <a class="problems" href="%s#pkg-%s" title="%s">%s</a>`,
+		buildPageHref(page.PathInfo, createPagePathInfo(ResTypeNone, "problems"), nil, ""),
+		pkgPath,
+		page.Translation().Text_AnalysisProblems(),
+		page.Translation().Text_NumProblems(n),
+	)
+}
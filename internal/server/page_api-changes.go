@@ -0,0 +1,361 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"log"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"go101.org/golds/code"
+	"go101.org/golds/internal/util"
+)
+
+func (ds *docServer) apiChangesPage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html")
+
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+
+	if ds.phase < Phase_Analyzed {
+		w.WriteHeader(http.StatusTooEarly)
+		ds.loadingPage(w, r)
+		return
+	}
+
+	pageKey := pageCacheKey{
+		resType: ResTypeNone,
+		res:     "api-changes",
+	}
+	data, ok := ds.cachedPage(pageKey)
+	if !ok {
+		data = ds.buildAPIChangesPage(w, ds.buildAPIChangesData())
+		ds.cachePage(pageKey, data)
+	}
+	w.Write(data)
+}
+
+// The kinds of exported API changes.
+const (
+	APIChange_Added   = "added"
+	APIChange_Removed = "removed"
+	APIChange_Changed = "changed"
+)
+
+// An APIChange is a change of an exported declaration since HEAD.
+type APIChange struct {
+	Kind     string
+	Name     string // "Name" or "TypeName.MethodName"
+	Old, New string // the formatted declarations, blank for the absent ones
+}
+
+type PackageAPIChanges struct {
+	Package *code.Package
+	Changes []APIChange // sorted by names
+}
+
+// buildAPIChangesData compares the exported declarations in the source files
+// of the dirty packages with the ones in the HEAD versions of these files.
+// The HEAD version of a file renamed in the same directory is the original file.
+func (ds *docServer) buildAPIChangesData() []PackageAPIChanges {
+	wt := ds.gitWorkingTree()
+	if wt == nil {
+		return nil
+	}
+
+	var list []PackageAPIChanges
+	for _, dp := range wt.DirtyPackages {
+		pkg := dp.Package
+
+		var files []*ast.File
+		var filenames []string
+		for i := range pkg.SourceFiles {
+			info := &pkg.SourceFiles[i]
+			// The lines of generated files don't match the original ones.
+			if info.AstFile == nil || info.OriginalFile == "" || info.GeneratedFile != "" && info.GeneratedFile != info.OriginalFile {
+				continue
+			}
+			files = append(files, info.AstFile)
+			filenames = append(filenames, info.BareFilename)
+		}
+		for _, f := range dp.Files {
+			if f.Status == GitFileStatus_Deleted {
+				filenames = append(filenames, f.BareFilename)
+			}
+		}
+
+		var headFilenames = make(map[string]string, len(dp.Files))
+		for _, f := range dp.Files {
+			switch f.Status {
+			case GitFileStatus_Added, GitFileStatus_Untracked:
+				headFilenames[f.BareFilename] = f.HeadBareFilename
+			}
+		}
+
+		var oldFset = token.NewFileSet()
+		var oldFiles []*ast.File
+		var loaded = make(map[string]bool, len(filenames))
+		for _, filename := range filenames {
+			if headFilename, ok := headFilenames[filename]; ok {
+				filename = headFilename
+			}
+			// A renamed file and its original (deleted) one
+			// are paired with the same HEAD file.
+			if filename == "" || loaded[filename] {
+				continue
+			}
+			loaded[filename] = true
+
+			output, err := util.RunShellCommand(time.Second*5, pkg.Directory, nil, "git", "show", "HEAD:./"+filename)
+			if err != nil {
+				if verboseLogs {
+					log.Printf("git show HEAD:./%s (in %s) error: %s", filename, pkg.Directory, err)
+				}
+				continue
+			}
+			f, err := parser.ParseFile(oldFset, filepath.Join(pkg.Directory, filename), output, parser.SkipObjectResolution)
+			if err != nil {
+				if verboseLogs {
+					log.Printf("parse HEAD version of %s (in %s) error: %s", filename, pkg.Directory, err)
+				}
+				continue
+			}
+			oldFiles = append(oldFiles, f)
+		}
+
+		oldAPIs := collectExportedAPIs(oldFset, oldFiles)
+		newAPIs := collectExportedAPIs(pkg.PPkg.Fset, files)
+		if changes := diffExportedAPIs(oldAPIs, newAPIs); len(changes) > 0 {
+			list = append(list, PackageAPIChanges{Package: pkg, Changes: changes})
+		}
+	}
+	return list
+}
+
+// collectExportedAPIs returns the formatted exported declarations (without
+// docs, function bodies and unexported fields and methods) in the files.
+// The keys of the returned map are the declaration names. Method names
+// are prefixed with their receiver type names and a dot.
+func collectExportedAPIs(fset *token.FileSet, files []*ast.File) map[string]string {
+	var apis = make(map[string]string)
+	for _, f := range files {
+		if strings.HasSuffix(fset.Position(f.Package).Filename, "_test.go") {
+			continue
+		}
+		for _, decl := range f.Decls {
+			switch decl := decl.(type) {
+			case *ast.FuncDecl:
+				if !decl.Name.IsExported() {
+					continue
+				}
+				name := decl.Name.Name
+				if recv := funcDeclRecvTypeName(decl); recv != "" {
+					if !token.IsExported(recv) {
+						continue
+					}
+					name = recv + "." + name
+				}
+				fd := *decl
+				fd.Doc, fd.Body = nil, nil
+				apis[name] = formatAPINode(fset, &fd)
+			case *ast.GenDecl:
+				for _, spec := range decl.Specs {
+					switch spec := spec.(type) {
+					case *ast.TypeSpec:
+						if !spec.Name.IsExported() {
+							continue
+						}
+						ts := *spec
+						ts.Doc, ts.Comment = nil, nil
+						switch t := ts.Type.(type) {
+						case *ast.StructType:
+							ts.Type = &ast.StructType{Struct: t.Struct, Fields: exportedFieldList(t.Fields)}
+						case *ast.InterfaceType:
+							ts.Type = &ast.InterfaceType{Interface: t.Interface, Methods: exportedFieldList(t.Methods)}
+						}
+						apis[spec.Name.Name] = formatAPINode(fset, &ast.GenDecl{Tok: decl.Tok, Specs: []ast.Spec{&ts}})
+					case *ast.ValueSpec:
+						for i, n := range spec.Names {
+							if !n.IsExported() {
+								continue
+							}
+							vs := &ast.ValueSpec{Names: []*ast.Ident{n}, Type: spec.Type}
+							if len(spec.Values) == len(spec.Names) {
+								vs.Values = []ast.Expr{spec.Values[i]}
+							}
+							apis[n.Name] = formatAPINode(fset, &ast.GenDecl{Tok: decl.Tok, Specs: []ast.Spec{vs}})
+						}
+					}
+				}
+			}
+		}
+	}
+	return apis
+}
+
+// exportedFieldList returns a copy of a field list without
+// the unexported fields or methods. Embedded ones are kept.
+func exportedFieldList(fields *ast.FieldList) *ast.FieldList {
+	if fields == nil {
+		return nil
+	}
+	var list []*ast.Field
+	for _, f := range fields.List {
+		var names []*ast.Ident
+		for _, n := range f.Names {
+			if n.IsExported() {
+				names = append(names, n)
+			}
+		}
+		if len(f.Names) > 0 && len(names) == 0 {
+			continue
+		}
+		fld := *f
+		fld.Names, fld.Doc, fld.Comment = names, nil, nil
+		list = append(list, &fld)
+	}
+	return &ast.FieldList{Opening: fields.Opening, List: list, Closing: fields.Closing}
+}
+
+func formatAPINode(fset *token.FileSet, node ast.Node) string {
+	var b bytes.Buffer
+	config := printer.Config{Mode: printer.UseSpaces | printer.TabIndent, Tabwidth: 8}
+	if err := config.Fprint(&b, fset, node); err != nil {
+		return fmt.Sprintf("/* %s */", err)
+	}
+	return b.String()
+}
+
+// apiSignature returns a declaration formatted by formatAPINode without
+// the names of receivers, parameters and results, which are not parts of
+// the API. The declaration is re-parsed, so that the AST nodes of the
+// analyzed packages are not modified.
+func apiSignature(decl string) string {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", "package p\n"+decl, parser.SkipObjectResolution)
+	if err != nil || len(f.Decls) != 1 {
+		return decl
+	}
+	unnameFields := func(fields *ast.FieldList) {
+		if fields == nil {
+			return
+		}
+		var list = make([]*ast.Field, 0, len(fields.List))
+		for _, fld := range fields.List {
+			n := len(fld.Names)
+			if n == 0 {
+				n = 1
+			}
+			for i := 0; i < n; i++ {
+				list = append(list, &ast.Field{Type: fld.Type})
+			}
+		}
+		fields.List = list
+	}
+	ast.Inspect(f.Decls[0], func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncDecl:
+			unnameFields(n.Recv)
+		case *ast.FuncType:
+			unnameFields(n.Params)
+			unnameFields(n.Results)
+		}
+		return true
+	})
+	return formatAPINode(fset, f.Decls[0])
+}
+
+// diffExportedAPIs compares two results of collectExportedAPIs.
+// The differences of white spaces and receiver, parameter and
+// result names are ignored.
+func diffExportedAPIs(oldAPIs, newAPIs map[string]string) []APIChange {
+	normalize := func(s string) string {
+		return strings.Join(strings.Fields(apiSignature(s)), " ")
+	}
+
+	var changes []APIChange
+	for name, decl := range newAPIs {
+		old, ok := oldAPIs[name]
+		switch {
+		case !ok:
+			changes = append(changes, APIChange{Kind: APIChange_Added, Name: name, New: decl})
+		case normalize(old) != normalize(decl):
+			changes = append(changes, APIChange{Kind: APIChange_Changed, Name: name, Old: old, New: decl})
+		}
+	}
+	for name, decl := range oldAPIs {
+		if _, ok := newAPIs[name]; !ok {
+			changes = append(changes, APIChange{Kind: APIChange_Removed, Name: name, Old: decl})
+		}
+	}
+	sort.Slice(changes, func(a, b int) bool {
+		return changes[a].Name < changes[b].Name
+	})
+	return changes
+}
+
+func (ds *docServer) buildAPIChangesPage(w http.ResponseWriter, list []PackageAPIChanges) []byte {
+	page := NewHtmlPage(goldsVersion, ds.currentTranslation.Text_APIChanges(), ds.currentTheme, ds.currentTranslation, createPagePathInfo(ResTypeNone, "api-changes"))
+
+	fmt.Fprintf(page, `<pre id="api-changes"><code><span style="font-size:x-large;">%s</span>
+`,
+		page.Translation().Text_APIChanges(),
+	)
+
+	if len(list) == 0 {
+		fmt.Fprintf(page, "\n\t%s\n", page.Translation().Text_NoAPIChanges())
+	}
+
+	writeDecl := func(kind, decl string) {
+		mark := "+"
+		if kind == APIChange_Removed {
+			mark = "-"
+		}
+		fmt.Fprintf(page, `<span class="api-%s">%s `, kind, mark)
+		util.WriteHtmlEscapedString(page, strings.ReplaceAll(decl, "\n", "\n\t\t  "))
+		page.WriteString(`</span>`)
+	}
+
+	for _, pc := range list {
+		pkgPath := pc.Package.Path()
+		fmt.Fprintf(page, `<div class="anchor" id="pkg-%s">`, pkgPath)
+		page.WriteString("\n")
+		fmt.Fprintf(page, `<span class="title"><a href="%s">%s</a><span class="title-stat"><i>%s</i></span></span>`,
+			buildPageHref(page.PathInfo, createPagePathInfo1(ResTypePackage, pkgPath), nil, ""),
+			pkgPath,
+			page.Translation().Text_EnclosedInOarentheses(fmt.Sprint(len(pc.Changes))),
+		)
+		for _, c := range pc.Changes {
+			page.WriteString("\n\t")
+			fmt.Fprintf(page, `<i>%s</i> `, page.Translation().Text_APIChangeKind(c.Kind))
+			name := c.Name
+			if i := strings.IndexByte(name, '.'); i >= 0 {
+				name = name[:i]
+			}
+			if c.Kind == APIChange_Removed {
+				page.WriteString(c.Name)
+			} else {
+				buildPageHref(page.PathInfo, createPagePathInfo1(ResTypePackage, pkgPath), page, c.Name, "name-", name)
+			}
+			if c.Old != "" {
+				page.WriteString("\n\t\t")
+				writeDecl(APIChange_Removed, c.Old)
+			}
+			if c.New != "" {
+				page.WriteString("\n\t\t")
+				writeDecl(APIChange_Added, c.New)
+			}
+		}
+		page.WriteString("\n</div>")
+	}
+
+	page.WriteString("</code></pre>")
+	return page.Done(w)
+}
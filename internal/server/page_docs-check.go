@@ -0,0 +1,349 @@
+package server
+
+import (
+	"fmt"
+	"go/token"
+	"go/types"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"go101.org/golds/code"
+	"go101.org/golds/internal/util"
+)
+
+func (ds *docServer) docsCheckPage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html")
+
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+
+	if ds.phase < Phase_Analyzed {
+		w.WriteHeader(http.StatusTooEarly)
+		ds.loadingPage(w, r)
+		return
+	}
+
+	pageKey := pageCacheKey{
+		resType: ResTypeNone,
+		res:     "docs-check",
+	}
+	data, ok := ds.cachedPage(pageKey)
+	if !ok {
+		data = ds.buildDocsCheckPage(w, ds.buildDocsCheckData(ds.workingDirectoryPackages()))
+		ds.cachePage(pageKey, data)
+	}
+	w.Write(data)
+}
+
+// DocsIssueKind is the kind of a DocsIssue.
+type DocsIssueKind string
+
+const (
+	DocsIssueKind_Undocumented DocsIssueKind = "undocumented"
+	DocsIssueKind_BadDocStart  DocsIssueKind = "bad-doc-start"
+	DocsIssueKind_BrokenLink   DocsIssueKind = "broken-link"
+	DocsIssueKind_NoPkgDocs    DocsIssueKind = "no-package-docs"
+)
+
+// A DocsIssue is a documentation problem found in a package.
+type DocsIssue struct {
+	Kind DocsIssueKind
+	Pos  token.Position
+	Msg  string
+}
+
+// PackageDocsReport holds the docs check result of a package.
+type PackageDocsReport struct {
+	Package *code.Package
+
+	// The numbers of exported identifiers and selectors
+	// and how many of them are documented.
+	NumExporteds   int
+	NumDocumenteds int
+
+	Issues []DocsIssue // sorted by positions
+}
+
+// Coverage returns the percentage of documented exported identifiers and selectors.
+func (r *PackageDocsReport) Coverage() float64 {
+	if r.NumExporteds == 0 {
+		return 100
+	}
+	return float64(r.NumDocumenteds) * 100 / float64(r.NumExporteds)
+}
+
+// isWorkingDirectoryPackage returns whether or not p is in the working directory.
+// In workspace mode, the packages in all workspace modules are viewed as
+// working directory ones.
+func (ds *docServer) isWorkingDirectoryPackage(p *code.Package) bool {
+	if util.IsInDirectory(p.Directory, ds.initialWorkingDirectory) {
+		return true
+	}
+	return len(ds.analyzer.WorkingDirectoryModules()) > 1 && p.Module != nil && ds.analyzer.IsWorkingDirectoryModule(p.Module)
+}
+
+// workingDirectoryPackages returns the packages in the working directory.
+func (ds *docServer) workingDirectoryPackages() []*code.Package {
+	var pkgs []*code.Package
+	for i := 0; i < ds.analyzer.NumPackages(); i++ {
+		if p := ds.analyzer.PackageAt(i); ds.isWorkingDirectoryPackage(p) {
+			pkgs = append(pkgs, p)
+		}
+	}
+	return pkgs
+}
+
+// buildDocsCheckData checks the docs of the specified packages.
+// The reports are sorted by package paths.
+func (ds *docServer) buildDocsCheckData(pkgs []*code.Package) []*PackageDocsReport {
+	var reports []*PackageDocsReport
+	for _, p := range pkgs {
+		reports = append(reports, ds.checkPackageDocs(p))
+	}
+	sort.Slice(reports, func(a, b int) bool {
+		return reports[a].Package.Path() < reports[b].Package.Path()
+	})
+	return reports
+}
+
+func (ds *docServer) checkPackageDocs(pkg *code.Package) *PackageDocsReport {
+	report := &PackageDocsReport{Package: pkg}
+
+	addIssue := func(kind DocsIssueKind, pos token.Position, format string, args ...interface{}) {
+		report.Issues = append(report.Issues, DocsIssue{Kind: kind, Pos: pos, Msg: fmt.Sprintf(format, args...)})
+	}
+
+	if pkg.OneLineDoc == "" {
+		var pos token.Position
+		if len(pkg.PPkg.Syntax) > 0 {
+			pos = pkg.PPkg.Fset.PositionFor(pkg.PPkg.Syntax[0].Package, false)
+		}
+		addIssue(DocsIssueKind_NoPkgDocs, pos, "package %s has no package docs", pkg.PPkg.Name)
+	}
+
+	// checkDocs checks the docs of an exported identifier or selector.
+	// The docs should start with the name if startWithName is true.
+	checkDocs := func(kind, name, doc string, pos token.Position, startWithName bool) {
+		report.NumExporteds++
+		if strings.TrimSpace(doc) == "" {
+			addIssue(DocsIssueKind_Undocumented, pos, "exported %s %s is not documented", kind, name)
+			return
+		}
+		report.NumDocumenteds++
+
+		if startWithName {
+			bareName := name[strings.LastIndexByte(name, '.')+1:]
+			if !docStartsWithName(doc, bareName) {
+				addIssue(DocsIssueKind_BadDocStart, pos, "the docs of %s %s should start with %s", kind, name, bareName)
+			}
+		}
+		for _, link := range ds.brokenDocLinks(pkg, doc) {
+			addIssue(DocsIssueKind_BrokenLink, pos, "broken doc link %s in the docs of %s %s", link, kind, name)
+		}
+	}
+
+	if pkg.OneLineDoc != "" {
+		for _, f := range pkg.PPkg.Syntax {
+			if f.Doc != nil {
+				pos := pkg.PPkg.Fset.PositionFor(f.Doc.Pos(), false)
+				for _, link := range ds.brokenDocLinks(pkg, f.Doc.Text()) {
+					addIssue(DocsIssueKind_BrokenLink, pos, "broken doc link %s in the package docs", link)
+				}
+			}
+		}
+	}
+
+	// The exported identifiers in main packages are not APIs.
+	if pkg.PPkg.Name == "main" {
+		sortDocsIssues(report.Issues)
+		return report
+	}
+
+	scope := pkg.PPkg.Types.Scope()
+	for _, c := range pkg.PackageAnalyzeResult.AllConstants {
+		if c.Exported() && c.Parent() == scope {
+			// The docs of a grouped constant might be the group docs.
+			checkDocs("constant", c.Name(), c.Documentation()+c.Comment(), c.Position(), !c.AstDecl.Lparen.IsValid())
+		}
+	}
+	for _, v := range pkg.PackageAnalyzeResult.AllVariables {
+		if v.Exported() && v.Parent() == scope {
+			checkDocs("variable", v.Name(), v.Documentation()+v.Comment(), v.Position(), !v.AstDecl.Lparen.IsValid())
+		}
+	}
+	for _, f := range pkg.PackageAnalyzeResult.AllFunctions {
+		if f.Func == nil || f.AstDecl == nil || !f.Exported() {
+			continue
+		}
+		if !f.IsMethod() {
+			checkDocs("function", f.Name(), f.Documentation(), f.Position(), true)
+			continue
+		}
+		if _, tn, _ := f.ReceiverTypeName(); tn != nil && tn.Exported() {
+			checkDocs("method", tn.Name()+"."+f.Name(), f.Documentation(), f.Position(), true)
+		}
+	}
+	for _, tn := range pkg.PackageAnalyzeResult.AllTypeNames {
+		if !tn.Exported() || tn.Parent() != scope || tn.AstSpec == nil {
+			continue
+		}
+		doc := tn.Documentation()
+		if doc == "" {
+			doc = tn.Comment()
+		}
+		checkDocs("type", tn.Name(), doc, tn.Position(), !tn.AstDecl.Lparen.IsValid())
+
+		if tn.Named == nil {
+			continue
+		}
+		var selectors []*code.Selector
+		switch tn.Named.TT.Underlying().(type) {
+		case *types.Interface:
+			selectors = tn.Named.DirectSelectors
+		case *types.Struct:
+			if tn.Named.Underlying != nil {
+				selectors = tn.Named.Underlying.DirectSelectors
+			}
+		}
+		for _, sel := range selectors {
+			if !token.IsExported(sel.Name()) || sel.Package() == nil {
+				continue
+			}
+			switch {
+			case sel.Field != nil && sel.Field.Mode == code.EmbedMode_None:
+				checkDocs("field", tn.Name()+"."+sel.Name(), sel.Field.Documentation()+sel.Field.Comment(), sel.Position(), false)
+			case sel.Method != nil && sel.Method.AstField != nil:
+				checkDocs("method", tn.Name()+"."+sel.Name(), sel.Method.Documentation()+sel.Method.Comment(), sel.Position(), false)
+			}
+		}
+	}
+
+	sortDocsIssues(report.Issues)
+	return report
+}
+
+func sortDocsIssues(issues []DocsIssue) {
+	sort.SliceStable(issues, func(a, b int) bool {
+		pa, pb := issues[a].Pos, issues[b].Pos
+		if pa.Filename != pb.Filename {
+			return pa.Filename < pb.Filename
+		}
+		return pa.Offset < pb.Offset
+	})
+}
+
+// docStartsWithName returns whether or not the first word of doc is name.
+// The name may be preceded by an article.
+func docStartsWithName(doc, name string) bool {
+	words := strings.Fields(doc)
+	if len(words) > 1 {
+		switch words[0] {
+		case "A", "An", "The":
+			words = words[1:]
+		}
+	}
+	if len(words) == 0 || !strings.HasPrefix(words[0], name) {
+		return false
+	}
+	r, _ := utf8.DecodeRuneInString(words[0][len(name):])
+	return r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+func (ds *docServer) buildDocsCheckPage(w http.ResponseWriter, reports []*PackageDocsReport) []byte {
+	page := NewHtmlPage(goldsVersion, ds.currentTranslation.Text_DocsCheck(), ds.currentTheme, ds.currentTranslation, createPagePathInfo(ResTypeNone, "docs-check"))
+
+	fmt.Fprintf(page, `<pre><code><span style="font-size:x-large;">%s</span>
+`,
+		page.Translation().Text_DocsCheck(),
+	)
+
+	if numIssues, _, _ := docsCheckSummary(reports); numIssues == 0 {
+		fmt.Fprintf(page, "\n\t%s\n", page.Translation().Text_NoDocsIssues())
+	}
+
+	for _, r := range reports {
+		pkgPath := r.Package.Path()
+		page.WriteString("\n")
+		fmt.Fprintf(page, `<div class="anchor" id="pkg-%s">`, pkgPath)
+		fmt.Fprintf(page, `<span class="title"><a href="%s">%s</a>`,
+			buildPageHref(page.PathInfo, createPagePathInfo1(ResTypePackage, pkgPath), nil, ""),
+			pkgPath,
+		)
+		fmt.Fprintf(page, `<span class="title-stat"><i>%s</i></span></span>`,
+			page.Translation().Text_EnclosedInOarentheses(
+				page.Translation().Text_DocsCoverage(r.NumDocumenteds, r.NumExporteds)+
+					page.Translation().Text_Comma()+
+					page.Translation().Text_NumProblems(len(r.Issues)),
+			),
+		)
+		for _, issue := range r.Issues {
+			fmt.Fprintf(page, "\n\t<i>%s</i> ", issue.Kind)
+			writeProblemPosition(page, r.Package, issue.Pos)
+			page.WriteString(issue.Msg)
+		}
+		page.WriteString("\n</div>")
+	}
+
+	page.WriteString("</code></pre>")
+	return page.Done(w)
+}
+
+// docsCheckSummary returns the total numbers of the issues,
+// the exported identifiers and selectors, and the documented ones.
+func docsCheckSummary(reports []*PackageDocsReport) (numIssues, numExporteds, numDocumenteds int) {
+	for _, r := range reports {
+		numIssues += len(r.Issues)
+		numExporteds += r.NumExporteds
+		numDocumenteds += r.NumDocumenteds
+	}
+	return
+}
+
+// CheckDocs checks the docs of the packages specified by the arguments
+// (but not their dependencies) and prints the issues and coverage.
+// It returns false if any issue is found.
+func CheckDocs(options PageOutputOptions, args []string, silentMode bool, printUsage func(io.Writer)) bool {
+	toolchain, err := findToolchainInfo()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ds := &docServer{}
+	if err := ds.analyze(args, options, toolchain, false, printUsage); err != nil {
+		log.Fatal(err)
+	}
+	defer os.Chdir(ds.initialWorkingDirectory)
+
+	reports := ds.buildDocsCheckData(ds.analyzer.ArgumentPackages())
+	for _, r := range reports {
+		for _, issue := range r.Issues {
+			pos := issue.Pos
+			if rel, err := filepath.Rel(ds.initialWorkingDirectory, pos.Filename); err == nil {
+				pos.Filename = rel
+			}
+			if pos.Filename == "" {
+				fmt.Fprintf(os.Stderr, "%s: %s: %s\n", r.Package.Path(), issue.Kind, issue.Msg)
+			} else {
+				fmt.Fprintf(os.Stderr, "%s: %s: %s\n", pos, issue.Kind, issue.Msg)
+			}
+		}
+	}
+
+	if !silentMode {
+		for _, r := range reports {
+			log.Printf("%s: docs coverage %.1f%% (%d/%d), %d issues", r.Package.Path(), r.Coverage(), r.NumDocumenteds, r.NumExporteds, len(r.Issues))
+		}
+	}
+
+	numIssues, numExporteds, numDocumenteds := docsCheckSummary(reports)
+	total := &PackageDocsReport{NumExporteds: numExporteds, NumDocumenteds: numDocumenteds}
+	log.Printf("Docs of %d packages are checked: coverage %.1f%% (%d/%d), %d issues.", len(reports), total.Coverage(), numDocumenteds, numExporteds, numIssues)
+	return numIssues == 0
+}
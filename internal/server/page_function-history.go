@@ -0,0 +1,164 @@
+package server
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"log"
+	"net/http"
+	"strings"
+
+	"go101.org/golds/code"
+	"go101.org/golds/internal/util"
+)
+
+// funcName might be the name of a package-level function,
+// or a method name prefixed with its receiver type name and a dot.
+func (ds *docServer) functionHistoryPage(w http.ResponseWriter, r *http.Request, pkgPath, funcName string) {
+	w.Header().Set("Content-Type", "text/html")
+
+	tokens := strings.Split(funcName, ".")
+	if genDocsMode {
+		pkgPath = deHashScope(pkgPath)
+		for i, t := range tokens {
+			tokens[i] = deHashIdentifier(t)
+		}
+	}
+
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+
+	if ds.phase < Phase_Analyzed {
+		w.WriteHeader(http.StatusTooEarly)
+		ds.loadingPage(w, r)
+		return
+	}
+
+	var recvTypeName string
+	switch len(tokens) {
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprintf(w, "Invalid function name: %s", funcName)
+		return
+	case 1:
+		funcName = tokens[0]
+	case 2:
+		recvTypeName, funcName = tokens[0], tokens[1]
+	}
+
+	pageKey := pageCacheKey{
+		resType: ResTypeHistory,
+		res:     [...]string{pkgPath, recvTypeName, funcName},
+	}
+	data, ok := ds.cachedPage(pageKey)
+	if !ok {
+		history, err := ds.buildFunctionHistoryData(pkgPath, recvTypeName, funcName)
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, err)
+			return
+		}
+
+		data = ds.buildFunctionHistoryPage(w, history)
+		ds.cachePage(pageKey, data)
+	}
+	w.Write(data)
+}
+
+type FunctionHistory struct {
+	Package      *code.Package
+	RecvTypeName string // blank for package-level functions
+	FuncName     string
+	Position     token.Position
+
+	Commits []*GitCommit // the latest ones are put first
+}
+
+func (h *FunctionHistory) FullName() string {
+	if h.RecvTypeName == "" {
+		return h.FuncName
+	}
+	return h.RecvTypeName + "." + h.FuncName
+}
+
+func (ds *docServer) buildFunctionHistoryData(pkgPath, recvTypeName, funcName string) (*FunctionHistory, error) {
+	pkg := ds.analyzer.PackageByPath(pkgPath)
+	if pkg == nil {
+		return nil, fmt.Errorf("package (%s) not found", pkgPath)
+	}
+	if !gitAnnotations || !ds.isWorkingDirectoryPackage(pkg) {
+		return nil, fmt.Errorf("git history is not available for package %s", pkgPath)
+	}
+
+	history := &FunctionHistory{
+		Package:      pkg,
+		RecvTypeName: recvTypeName,
+		FuncName:     funcName,
+	}
+	fset := pkg.PPkg.Fset
+	for i := range pkg.SourceFiles {
+		info := &pkg.SourceFiles[i]
+		if info.AstFile == nil || info.OriginalFile == "" || info.GeneratedFile != "" && info.GeneratedFile != info.OriginalFile {
+			continue
+		}
+		for _, decl := range info.AstFile.Decls {
+			fd, ok := decl.(*ast.FuncDecl)
+			if !ok || fd.Name.Name != funcName || funcDeclRecvTypeName(fd) != recvTypeName {
+				continue
+			}
+
+			history.Position = fset.PositionFor(fd.Name.Pos(), false)
+			startLine := fset.PositionFor(fd.Pos(), false).Line
+			endLine := fset.PositionFor(fd.End(), false).Line
+			commits, err := gitLineRangeHistory(info.OriginalFile, startLine, endLine)
+			if err != nil && verboseLogs {
+				log.Printf("git log -L %d,%d:%s error: %s", startLine, endLine, info.OriginalFile, err)
+			}
+			history.Commits = commits
+			return history, nil
+		}
+	}
+
+	return nil, fmt.Errorf("function %s is not found in package %s", history.FullName(), pkgPath)
+}
+
+func (ds *docServer) buildFunctionHistoryPage(w http.ResponseWriter, history *FunctionHistory) []byte {
+	var pathInfo pagePathInfo
+	if history.RecvTypeName == "" {
+		pathInfo = createPagePathInfo2(ResTypeHistory, history.Package.Path(), "..", history.FuncName)
+	} else {
+		pathInfo = createPagePathInfo3(ResTypeHistory, history.Package.Path(), "..", history.RecvTypeName, history.FuncName)
+	}
+	title := ds.currentTranslation.Text_FunctionHistory() + ds.currentTranslation.Text_Colon(false) + history.Package.Path() + "." + history.FullName()
+	page := NewHtmlPage(goldsVersion, title, ds.currentTheme, ds.currentTranslation, pathInfo)
+
+	fmt.Fprintf(page, `<pre><code><span style="font-size:x-large;">%s</span>
+`,
+		page.Translation().Text_FunctionHistory(),
+	)
+	page.WriteString("\t")
+	buildPageHref(page.PathInfo, createPagePathInfo1(ResTypePackage, history.Package.Path()), page, history.Package.Path())
+	page.WriteString(".")
+	writeSrouceCodeLineLink(page, history.Package, history.Position, history.FullName(), "")
+	page.WriteString("\n")
+
+	fmt.Fprintf(page, `
+<span class="title">%s<span class="title-stat"><i>%s</i></span></span>
+`,
+		page.Translation().Text_LocalCommits(),
+		page.Translation().Text_EnclosedInOarentheses(fmt.Sprint(len(history.Commits))),
+	)
+	if len(history.Commits) == 0 {
+		fmt.Fprintf(page, "\t%s\n", page.Translation().Text_NoGitCommits())
+	}
+	for _, c := range history.Commits {
+		page.WriteString("\t")
+		writeGitCommitBrief(page, c)
+		page.WriteString(page.Translation().Text_Colon(false))
+		util.WriteHtmlEscapedString(page, c.Summary)
+		page.WriteString("\n")
+	}
+
+	page.WriteString("</code></pre>")
+	return page.Done(w)
+}
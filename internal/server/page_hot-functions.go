@@ -0,0 +1,97 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+)
+
+func (ds *docServer) hotFunctionsPage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html")
+
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+
+	if ds.phase < Phase_Analyzed {
+		w.WriteHeader(http.StatusTooEarly)
+		ds.loadingPage(w, r)
+		return
+	}
+
+	pageKey := pageCacheKey{
+		resType: ResTypeNone,
+		res:     "hot-functions",
+	}
+	data, ok := ds.cachedPage(pageKey)
+	if !ok {
+		data = ds.buildHotFunctionsPage(w, ds.profileOverlay(), 100, 5)
+		ds.cachePage(pageKey, data)
+	}
+	w.Write(data)
+}
+
+// At most maxFunctions functions are listed,
+// each with at most maxCallers callers.
+func (ds *docServer) buildHotFunctionsPage(w http.ResponseWriter, overlay *ProfileOverlay, maxFunctions, maxCallers int) []byte {
+	page := NewHtmlPage(goldsVersion, ds.currentTranslation.Text_HotFunctions(), ds.currentTheme, ds.currentTranslation, createPagePathInfo(ResTypeNone, "hot-functions"))
+
+	fmt.Fprintf(page, `<pre id="hot-functions"><code><span style="font-size:x-large;">%s</span>
+`,
+		page.Translation().Text_HotFunctions(),
+	)
+
+	if overlay == nil || len(overlay.Functions) == 0 {
+		fmt.Fprintf(page, "\n\t%s\n", page.Translation().Text_NoProfileSamples())
+		page.WriteString("</code></pre>")
+		return page.Done(w)
+	}
+
+	profile := overlay.Profile
+	fmt.Fprintf(page, "\t%s\n", page.Translation().Text_ProfileSummary(profile.SampleType, profile.FormatValue(profile.Total), len(profile.Samples)))
+
+	functions := overlay.Functions
+	if len(functions) > maxFunctions {
+		functions = functions[:maxFunctions]
+	}
+	fmt.Fprintf(page, `
+<span class="title">%s<span class="title-stat"><i>%s</i></span></span>
+`,
+		page.Translation().Text_HotFunctions(),
+		page.Translation().Text_EnclosedInOarentheses(fmt.Sprint(len(functions))),
+	)
+	for _, fp := range functions {
+		page.WriteString("\t")
+		writeFunctionSourceLink(page, fp.Function)
+		writeProfileCost(page, profile, fp.ProfileCost)
+		page.WriteString("\n")
+
+		callers := fp.Callers
+		if len(callers) > maxCallers {
+			callers = callers[:maxCallers]
+		}
+		for _, c := range callers {
+			fmt.Fprintf(page, "\t\t<i>%s</i> ", page.Translation().Text_CalledBy())
+			writeFunctionSourceLink(page, c.Function)
+			fmt.Fprintf(page, ` <span class="profile-cost">%s</span>`, profile.FormatValueWithPercent(c.Cost))
+			page.WriteString("\n")
+		}
+	}
+
+	if len(overlay.HotLines) > 0 {
+		fmt.Fprintf(page, `
+<span class="title">%s<span class="title-stat"><i>%s</i></span></span>
+`,
+			page.Translation().Text_HottestLines(),
+			page.Translation().Text_EnclosedInOarentheses(fmt.Sprint(len(overlay.HotLines))),
+		)
+		for _, hl := range overlay.HotLines {
+			pkgPath := hl.Package.Path()
+			fmt.Fprintf(page, "\t%s/", pkgPath)
+			buildPageHref(page.PathInfo, createPagePathInfo2b(ResTypeSource, pkgPath, "/", hl.BareFilename), page, fmt.Sprintf("%s:%d", hl.BareFilename, hl.Line), "line-", fmt.Sprint(hl.Line))
+			writeProfileCost(page, profile, hl.ProfileCost)
+			page.WriteString("\n")
+		}
+	}
+
+	page.WriteString("</code></pre>")
+	return page.Done(w)
+}
@@ -0,0 +1,182 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"go/token"
+	"go/types"
+	"html"
+	"net/http"
+	"strings"
+
+	"go101.org/golds/code"
+)
+
+// The diagnosis page is only available in server mode.
+// It is requested through the form on imp: pages and package details pages.
+func (ds *docServer) implementationDiagnosisPage(w http.ResponseWriter, r *http.Request, pkgPath, typeName, with string) {
+	w.Header().Set("Content-Type", "text/html")
+
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+
+	if ds.phase < Phase_Analyzed {
+		w.WriteHeader(http.StatusTooEarly)
+		ds.loadingPage(w, r)
+		return
+	}
+
+	pageKey := pageCacheKey{
+		resType: ResTypeImplementation,
+		res:     [...]string{pkgPath, typeName, with},
+	}
+	data, ok := ds.cachedPage(pageKey)
+	if !ok {
+		result, err := buildImplementationDiagnosisData(ds.analyzer, pkgPath, typeName, with)
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, "Diagnose implementation for (", typeName, ") in ", pkgPath, " with ", html.EscapeString(with), " error: ", err)
+			return
+		}
+
+		data = ds.buildImplementationDiagnosisPage(w, result)
+		ds.cachePage(pageKey, data)
+	}
+	w.Write(data)
+}
+
+type ImplementationDiagnosisResult struct {
+	TypeName      *code.TypeName
+	InterfaceName *code.TypeName
+
+	*code.ImplementationDiagnosis
+}
+
+func lookForTypeName(analyzer *code.CodeAnalyzer, pkgPath, typeName string) (*code.TypeName, error) {
+	if !collectUnexporteds && pkgPath != "builtin" && !token.IsExported(typeName) {
+		return nil, errors.New("typename not found")
+	}
+
+	pkg := analyzer.PackageByPath(pkgPath)
+	if pkg == nil {
+		return nil, errors.New("package not found")
+	}
+	for _, tn := range pkg.PackageAnalyzeResult.AllTypeNames {
+		if tn.Name() == typeName {
+			return tn, nil
+		}
+	}
+	return nil, errors.New("typename not found")
+}
+
+// with is the full name of the other type, in the form "import/path.TypeName".
+// One of the two types must be an interface type.
+func buildImplementationDiagnosisData(analyzer *code.CodeAnalyzer, pkgPath, typeName, with string) (*ImplementationDiagnosisResult, error) {
+	index := strings.LastIndex(with, ".")
+	if index < 0 {
+		return nil, errors.New("the package of the other type is not specified")
+	}
+
+	tn, err := lookForTypeName(analyzer, pkgPath, typeName)
+	if err != nil {
+		return nil, err
+	}
+	itn, err := lookForTypeName(analyzer, with[:index], with[index+1:])
+	if err != nil {
+		return nil, err
+	}
+
+	isInterface := func(tn *code.TypeName) bool {
+		_, ok := tn.Denoting().TT.Underlying().(*types.Interface)
+		return ok
+	}
+	if !isInterface(itn) {
+		if !isInterface(tn) {
+			return nil, errors.New("neither of the two types is an interface type")
+		}
+		tn, itn = itn, tn
+	}
+
+	return &ImplementationDiagnosisResult{
+		TypeName:                tn,
+		InterfaceName:           itn,
+		ImplementationDiagnosis: analyzer.DiagnoseImplementation(tn.Denoting(), itn.Denoting()),
+	}, nil
+}
+
+func (ds *docServer) buildImplementationDiagnosisPage(w http.ResponseWriter, result *ImplementationDiagnosisResult) []byte {
+	typeName, interfaceName := result.TypeName.Name(), result.InterfaceName.Name()
+	title := ds.currentTranslation.Text_ImplementationDiagnosis() + ds.currentTranslation.Text_Colon(false) + typeName + " - " + interfaceName
+	page := NewHtmlPage(goldsVersion, title, ds.currentTheme, ds.currentTranslation, createPagePathInfo2(ResTypeImplementation, result.TypeName.Package().Path(), ".", typeName))
+
+	fmt.Fprintf(page, `<pre><code><span style="font-size:x-large;">%s</span>
+`,
+		page.Translation().Text_ImplementationDiagnosis(),
+	)
+
+	var writeTypeName = func(tn *code.TypeName) {
+		fmt.Fprintf(page, `
+	type <a href="%s">%s</a>.`,
+			buildPageHref(page.PathInfo, createPagePathInfo1(ResTypePackage, tn.Package().Path()), nil, ""),
+			tn.Package().Path(),
+		)
+		page.WriteString("<b>")
+		ds.writeResourceIndexHTML(page, tn.Package(), tn, false, false, false)
+		page.WriteString("</b>")
+		writeKindText(page, tn.Denoting().TT)
+	}
+	writeTypeName(result.TypeName)
+	writeTypeName(result.InterfaceName)
+
+	fmt.Fprintf(page, "\n\n\t<b>%s</b>\n",
+		page.Translation().Text_ImplementationVerdict(typeName, interfaceName, result.Implemented(), result.PointerImplemented()),
+	)
+
+	var writeMismatches = func(title string, mismatches []code.MethodMismatch, writeMismatch func(code.MethodMismatch)) {
+		if len(mismatches) == 0 {
+			return
+		}
+		fmt.Fprintf(page, `
+<code><span class="title">%s<span class="title-stat"><i>%s</i></span></span>`,
+			title,
+			page.Translation().Text_EnclosedInOarentheses(fmt.Sprint(len(mismatches))),
+		)
+		for _, m := range mismatches {
+			page.WriteString("\n")
+			writeMismatch(m)
+		}
+		page.WriteString("\n</code>")
+	}
+
+	writeMismatches(page.Translation().Text_MissingMethods(), result.Missings, func(m code.MethodMismatch) {
+		page.WriteString("\t")
+		ds.writeMethodForListing(page, result.InterfaceName.Package(), m.Required, result.InterfaceName, true, false)
+	})
+	writeMismatches(page.Translation().Text_MethodsWithDifferentSignatures(), result.WrongSignatures, func(m code.MethodMismatch) {
+		page.WriteString("\t")
+		ds.writeMethodForListing(page, result.InterfaceName.Package(), m.Required, result.InterfaceName, true, false)
+		page.WriteString("\n\t")
+		ds.writeMethodForListing(page, result.TypeName.Package(), m.Provided, result.TypeName, true, false)
+		page.WriteString("\n")
+	})
+	writeMismatches(page.Translation().Text_PointerReceiverOnlyMethods(typeName), result.PointerOnlys, func(m code.MethodMismatch) {
+		page.WriteString("\t")
+		ds.writeMethodForListing(page, result.TypeName.Package(), m.Provided, result.TypeName, true, false)
+	})
+
+	page.WriteString("</code></pre>")
+	return page.Done(w)
+}
+
+// writeImplementationDiagnosisForm writes a form to query why a type doesn't
+// implement an interface type. Forms don't work for generated docs.
+func writeImplementationDiagnosisForm(page *htmlPage, tn *code.TypeName, isInterface bool) {
+	if genDocsMode {
+		return
+	}
+
+	fmt.Fprintf(page, `<form class="diagnose" action="%s" method="get">%s</form>`,
+		buildPageHref(page.PathInfo, createPagePathInfo2(ResTypeImplementation, tn.Package().Path(), ".", tn.Name()), nil, ""),
+		page.Translation().Text_WhyNotImplement(isInterface, `<input name="with" size="32" placeholder="import/path.TypeName">`),
+	)
+}
@@ -33,6 +33,19 @@ function todo() {
 	var segments = urlParams.get('segments');
 }
 
+function switchDocsVersion(select) {
+	var root = new URL(select.dataset.root, window.location.href).href;
+	var path = decodeURI(window.location.href.split("#")[0].slice(root.length));
+	var version = select.value;
+	var pages = typeof docsVersionPages == "undefined" ? null : docsVersionPages[version];
+	var hash = window.location.hash;
+	if (pages == null || pages.indexOf(path) < 0) {
+		path = "index.html";
+		hash = "";
+	}
+	window.location.href = new URL("../" + encodeURIComponent(version) + "/" + path + hash, root).href;
+}
+
 function onPageLoad() {
 	var lastClicked;
 	var lastClickedBorder;
@@ -70,9 +83,40 @@ function onPageLoad() {
 
 	if (document.getElementById("package-details") != null) {
 		initPackageDetailsPage();
+	} else if (document.getElementById("notes-filters") != null) {
+		initNotesPage();
 	}
 }
 
+function initNotesPage() {
+	var filters = document.getElementById("notes-filters");
+	filters.style.display = "inline";
+
+	var pkgDivs = document.querySelectorAll(".notes-pkg");
+	var filterNotes = function() {
+		var marker = document.getElementById("notes-filter-marker").value;
+		var author = document.getElementById("notes-filter-author").value;
+		var pkg = document.getElementById("notes-filter-package").value;
+		pkgDivs.forEach(function (div) {
+			var numShowns = 0;
+			if (pkg == "" || div.dataset.package == pkg) {
+				div.querySelectorAll(".note").forEach(function (note) {
+					var show = (marker == "" || note.dataset.marker == marker) && (author == "" || note.dataset.author == author);
+					note.style.display = show ? "block" : "none";
+					if (show) {
+						numShowns++;
+					}
+				});
+			}
+			div.style.display = numShowns > 0 ? "block" : "none";
+		});
+	};
+	filters.querySelectorAll("select").forEach(function (select) {
+		select.addEventListener("change", filterNotes);
+	});
+	filterNotes();
+}
+
 function initOverviewPage() {
 	document.addEventListener("keydown", function(e){
 		if (e.ctrlKey || e.altKey || e.shiftKey) {
@@ -363,11 +407,13 @@ function initPackageDetailsPage() {
 	var nodesTypeRes = container.querySelectorAll(".type-res");
 	var typesByAlphabet = new Array(nodesTypeRes.length);
 	var typesByPopularity = new Array(nodesTypeRes.length);
+	var typesByUsage = new Array(nodesTypeRes.length);
 	for (var i = 0; i < nodesTypeRes.length; i++) {
 		var n = nodesTypeRes[i];
-		var t = {node: n, popularity: parseInt(n.dataset.popularity)};
+		var t = {node: n, popularity: parseInt(n.dataset.popularity), usage: parseInt(n.dataset.usage)};
 		typesByAlphabet[i] = t;
 		typesByPopularity[i] = t;
+		typesByUsage[i] = t;
 	}
 	typesByPopularity.sort(function(a, b) {
 		if (a.popularity == b.popularity) {
@@ -378,6 +424,15 @@ function initPackageDetailsPage() {
 		}
 		return b.popularity - a.popularity;
 	});
+	typesByUsage.sort(function(a, b) {
+		if (a.usage == b.usage) {
+			if (a.node.id < b.node.id) {
+				return -1;
+			}
+			return 1;
+		}
+		return b.usage - a.usage;
+	});
 
 	//var printArray = function(a, title) {
 	//	console.log("==================== ", title);
@@ -390,7 +445,8 @@ function initPackageDetailsPage() {
 
 	var showSortingButtons = false;
 	for (var i = 0; i < nodesTypeRes.length; i++) {
-		if (typesByAlphabet[i].node.id != typesByPopularity[i].node.id) {
+		if (typesByAlphabet[i].node.id != typesByPopularity[i].node.id ||
+			typesByAlphabet[i].node.id != typesByUsage[i].node.id) {
 			showSortingButtons = true;
 			break;
 		}
@@ -405,6 +461,7 @@ function initPackageDetailsPage() {
 	var currentSortBy = "alphabet";
 	var sortByAlphabet = buttons.querySelector("#sort-types-by-alphabet");
 	var sortByPopularity = buttons.querySelector("#sort-types-by-popularity");
+	var sortByUsage = buttons.querySelector("#sort-types-by-usage");
 	sortByAlphabet.classList.add("chosen");
 	sortByAlphabet.addEventListener('click', function(event) {
 		if (currentSortBy == "alphabet") {
@@ -418,6 +475,7 @@ function initPackageDetailsPage() {
 		currentSortBy = "alphabet";
 		sortByAlphabet.classList.add("chosen");
 		sortByPopularity.classList.remove("chosen");
+		sortByUsage.classList.remove("chosen");
 	});
 	sortByPopularity.addEventListener('click', function(event) {
 		if (currentSortBy == "popularity") {
@@ -431,6 +489,21 @@ function initPackageDetailsPage() {
 		currentSortBy = "popularity";
 		sortByPopularity.classList.add("chosen");
 		sortByAlphabet.classList.remove("chosen");
+		sortByUsage.classList.remove("chosen");
+	});
+	sortByUsage.addEventListener('click', function(event) {
+		if (currentSortBy == "usage") {
+			return;
+		}
+
+		typesByUsage.forEach(function (x) {
+			container.appendChild(x.node);
+		});
+
+		currentSortBy = "usage";
+		sortByUsage.classList.add("chosen");
+		sortByAlphabet.classList.remove("chosen");
+		sortByPopularity.classList.remove("chosen");
 	});
 }
 
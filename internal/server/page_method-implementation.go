@@ -82,6 +82,11 @@ func (ds *docServer) buildImplementationPage(w http.ResponseWriter, result *Meth
 	page.WriteString(`</b></span><span style="font-size:large;">`)
 	writeKindText(page, result.TypeName.Denoting().TT)
 	page.WriteString("</span>\n")
+	if !genDocsMode {
+		page.WriteString("\n")
+		writeImplementationDiagnosisForm(page, result.TypeName, result.IsInterface)
+		page.WriteString("\n")
+	}
 
 	nonImplementingMethodCountText := ""
 	if !result.IsInterface {
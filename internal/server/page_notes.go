@@ -0,0 +1,248 @@
+package server
+
+import (
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"go/token"
+	"html"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"go101.org/golds/code"
+)
+
+func (ds *docServer) notesPage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html")
+
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+
+	if ds.phase < Phase_Analyzed {
+		w.WriteHeader(http.StatusTooEarly)
+		ds.loadingPage(w, r)
+		return
+	}
+
+	pageKey := pageCacheKey{
+		resType: ResTypeNone,
+		res:     "notes",
+	}
+	data, ok := ds.cachedPage(pageKey)
+	if !ok {
+		data = ds.buildNotesPage(w, buildNotesData(ds.analyzer.ArgumentPackages()))
+		ds.cachePage(pageKey, data)
+	}
+	w.Write(data)
+}
+
+// A PackageNote is a marked comment, such as "BUG(who): ..." and "TODO ...".
+type PackageNote struct {
+	Marker string // BUG, TODO, FIXME, XXX, HACK, NOTE, ...
+	Author string // the uid in "MARKER(uid): ...", might be blank
+	Body   string // whitespaces are collapsed
+	Pos    token.Position
+}
+
+type PackageNotes struct {
+	Package *code.Package
+	Notes   []*PackageNote
+}
+
+// buildNotesData collects the notes in the specified packages. The notes
+// page only lists the notes in the argument packages, for the notes in
+// the dependency packages (including standard ones) are rarely concerned.
+// The notes of every package are still shown in its package details page.
+func buildNotesData(pkgs []*code.Package) []PackageNotes {
+	var list []PackageNotes
+	for _, pkg := range pkgs {
+		if notes := collectPackageNotes(pkg); len(notes) > 0 {
+			list = append(list, PackageNotes{Package: pkg, Notes: notes})
+		}
+	}
+	sort.Slice(list, func(a, b int) bool {
+		return list[a].Package.Path() < list[b].Package.Path()
+	})
+	return list
+}
+
+// Free-form notes which are not recognized by go/doc,
+// such as "TODO: ..." and "FIXME ...". Only the ones
+// at the starts of comment lines are collected.
+var freeFormNoteRegexp = regexp.MustCompile(`^\s*(TODO|FIXME|XXX|HACK)\b(?:\(([^)]*)\))?:?(.*)$`)
+
+// collectPackageNotes collects the "MARKER(uid): ..." notes recognized by
+// go/doc and the free-form TODO/FIXME/XXX/HACK comments in the Go source
+// files of pkg. The returned notes are sorted by positions.
+func collectPackageNotes(pkg *code.Package) []*PackageNote {
+	var files []*ast.File
+	for i := range pkg.SourceFiles {
+		if f := pkg.SourceFiles[i].AstFile; f != nil {
+			files = append(files, f)
+		}
+	}
+	if len(files) == 0 {
+		return nil
+	}
+
+	fset := pkg.PPkg.Fset
+	var notes []*PackageNote
+	var noteLines = make(map[string]map[int]bool) // filename -> lines
+	var registerNote = func(note *PackageNote) {
+		lines := noteLines[note.Pos.Filename]
+		if lines == nil {
+			lines = make(map[int]bool)
+			noteLines[note.Pos.Filename] = lines
+		}
+		lines[note.Pos.Line] = true
+		notes = append(notes, note)
+	}
+
+	// AllDecls and PreserveAST are used to avoid the AST being modified.
+	if p, err := doc.NewFromFiles(fset, files, pkg.Path(), doc.AllDecls|doc.PreserveAST); err == nil {
+		for marker, list := range p.Notes {
+			for _, n := range list {
+				registerNote(&PackageNote{
+					Marker: marker,
+					Author: n.UID,
+					Body:   strings.Join(strings.Fields(n.Body), " "),
+					Pos:    fset.PositionFor(n.Pos, false),
+				})
+			}
+		}
+	}
+
+	for _, f := range files {
+		for _, group := range f.Comments {
+			for _, c := range group.List {
+				pos := fset.PositionFor(c.Slash, false)
+				text := c.Text[2:]
+				if c.Text[1] == '*' {
+					text = strings.TrimSuffix(text, "*/")
+				}
+				for i, line := range strings.Split(text, "\n") {
+					if noteLines[pos.Filename][pos.Line+i] {
+						continue
+					}
+					m := freeFormNoteRegexp.FindStringSubmatch(line)
+					if m == nil {
+						continue
+					}
+					notePos := pos
+					notePos.Line += i
+					if i > 0 {
+						notePos.Column = 1
+					}
+					registerNote(&PackageNote{
+						Marker: m[1],
+						Author: m[2],
+						Body:   strings.Join(strings.Fields(m[3]), " "),
+						Pos:    notePos,
+					})
+				}
+			}
+		}
+	}
+
+	sort.Slice(notes, func(a, b int) bool {
+		pa, pb := notes[a].Pos, notes[b].Pos
+		if pa.Filename != pb.Filename {
+			return pa.Filename < pb.Filename
+		}
+		return pa.Line < pb.Line
+	})
+	return notes
+}
+
+// writePackageNote writes a note as one line. The source position is linked.
+func writePackageNote(page *htmlPage, pkg *code.Package, note *PackageNote) {
+	fmt.Fprintf(page, "\t<i>%s</i>", note.Marker)
+	if note.Author != "" {
+		fmt.Fprintf(page, "(%s)", html.EscapeString(note.Author))
+	}
+	page.WriteString(" ")
+	writeProblemPosition(page, pkg, note.Pos)
+	page.WriteString(html.EscapeString(note.Body))
+}
+
+func (ds *docServer) buildNotesPage(w http.ResponseWriter, list []PackageNotes) []byte {
+	page := NewHtmlPage(goldsVersion, ds.currentTranslation.Text_AllNotes(), ds.currentTheme, ds.currentTranslation, createPagePathInfo(ResTypeNone, "notes"))
+
+	fmt.Fprintf(page, `<pre id="notes"><code><span style="font-size:x-large;">%s</span>
+`,
+		page.Translation().Text_AllNotes(),
+	)
+
+	if len(list) == 0 {
+		fmt.Fprintf(page, "\n\t%s\n", page.Translation().Text_NoNotes())
+		page.WriteString("</code></pre>")
+		return page.Done(w)
+	}
+
+	var markers, authors = make(map[string]bool), make(map[string]bool)
+	for _, pn := range list {
+		for _, note := range pn.Notes {
+			markers[note.Marker] = true
+			if note.Author != "" {
+				authors[note.Author] = true
+			}
+		}
+	}
+	writeFilter := func(kind string, values []string) {
+		sort.Strings(values)
+		fmt.Fprintf(page, `%s%s<select id="notes-filter-%s">`,
+			page.Translation().Text_NotesFilter(kind),
+			page.Translation().Text_Colon(false),
+			kind,
+		)
+		fmt.Fprintf(page, `<option value="">%s</option>`, page.Translation().Text_NotesFilterAll())
+		for _, v := range values {
+			v = html.EscapeString(v)
+			fmt.Fprintf(page, `<option value="%s">%s</option>`, v, v)
+		}
+		page.WriteString(`</select>`)
+	}
+	var markerList, authorList, pkgList = make([]string, 0, len(markers)), make([]string, 0, len(authors)), make([]string, 0, len(list))
+	for m := range markers {
+		markerList = append(markerList, m)
+	}
+	for a := range authors {
+		authorList = append(authorList, a)
+	}
+	for _, pn := range list {
+		pkgList = append(pkgList, pn.Package.Path())
+	}
+	page.WriteString("\n")
+	page.WriteString(`<span id="notes-filters" class="js-on">`)
+	writeFilter("marker", markerList)
+	page.WriteString("   ")
+	writeFilter("author", authorList)
+	page.WriteString("   ")
+	writeFilter("package", pkgList)
+	page.WriteString("</span>\n")
+
+	for _, pn := range list {
+		pkgPath := pn.Package.Path()
+		// The leading blank line is in the div, so that it is hidden with the div.
+		fmt.Fprintf(page, `<div class="anchor notes-pkg" id="pkg-%[1]s" data-package="%[1]s">`, pkgPath)
+		page.WriteString("\n")
+		fmt.Fprintf(page, `<span class="title"><a href="%s">%s</a>`,
+			buildPageHref(page.PathInfo, createPagePathInfo1(ResTypePackage, pkgPath), nil, "", "notes"),
+			pkgPath,
+		)
+		fmt.Fprintf(page, `<span class="title-stat"><i>%s</i></span></span>`,
+			page.Translation().Text_EnclosedInOarentheses(fmt.Sprint(len(pn.Notes))),
+		)
+		for _, note := range pn.Notes {
+			fmt.Fprintf(page, `<div class="note" data-marker="%s" data-author="%s">`, note.Marker, html.EscapeString(note.Author))
+			writePackageNote(page, pn.Package, note)
+			page.WriteString("</div>")
+		}
+		page.WriteString("</div>")
+	}
+
+	page.WriteString("</code></pre>")
+	return page.Done(w)
+}
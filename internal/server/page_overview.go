@@ -73,6 +73,59 @@ func (ds *docServer) buildOverviewPage(w http.ResponseWriter, overview *Overview
 		ds.writeSimpleStatsBlock(page, &overview.Stats)
 	}
 
+	if problems := ds.analyzer.Problems(); len(problems) > 0 {
+		fmt.Fprintf(page, `
+<pre><code><span class="title">%s</span></code>
+	<a href="%s">%s</a>
+</pre>`,
+			page.Translation().Text_AnalysisProblems(),
+			buildPageHref(page.PathInfo, createPagePathInfo(ResTypeNone, "problems"), nil, ""),
+			page.Translation().Text_NumProblems(len(problems)),
+		)
+	}
+
+	fmt.Fprintf(page, `
+<pre><code><span class="title">%s</span></code>
+	<a href="%s">%s</a>
+</pre>`,
+		page.Translation().Text_AllNotes(),
+		buildPageHref(page.PathInfo, createPagePathInfo(ResTypeNone, "notes"), nil, ""),
+		page.Translation().Text_ViewAllNotes(),
+	)
+
+	if reports := ds.buildDocsCheckData(ds.workingDirectoryPackages()); len(reports) > 0 {
+		numIssues, numExporteds, numDocumenteds := docsCheckSummary(reports)
+		fmt.Fprintf(page, `
+<pre><code><span class="title">%s</span></code>
+	<a href="%s">%s%s%s</a>
+</pre>`,
+			page.Translation().Text_DocsCheck(),
+			buildPageHref(page.PathInfo, createPagePathInfo(ResTypeNone, "docs-check"), nil, ""),
+			page.Translation().Text_DocsCoverage(numDocumenteds, numExporteds),
+			page.Translation().Text_Comma(),
+			page.Translation().Text_NumProblems(numIssues),
+		)
+	}
+
+	if cpuProfile != nil {
+		fmt.Fprintf(page, `
+<pre><code><span class="title">%s</span></code>
+	<a href="%s">%s</a>
+</pre>`,
+			page.Translation().Text_HotFunctions(),
+			buildPageHref(page.PathInfo, createPagePathInfo(ResTypeNone, "hot-functions"), nil, ""),
+			page.Translation().Text_ViewHotFunctions(),
+		)
+	}
+
+	if wt := ds.gitWorkingTree(); wt != nil && len(wt.DirtyPackages) > 0 {
+		writeGitUncommittedChanges(page, wt)
+	}
+
+	if changes := ds.gitRecentChanges(10); len(changes) > 0 {
+		writeGitRecentChanges(page, changes)
+	}
+
 	page.WriteString("<pre><code>")
 
 	page.WriteString(`<span class="title">`)
@@ -196,6 +249,12 @@ func (ds *docServer) writePackagesForListing(page *htmlPage, packages []*Package
 			}
 		}
 
+		ds.writeProblemsBadge(page, pkg.Path)
+		ds.writeGitDirtyPackageBadge(page, pkg.Package)
+		if stats, ok := coverProfile.PackageCoverage(pkg.Path); ok {
+			writeCoverageBadge(page, stats)
+		}
+
 		if writeDataAttrs {
 			if pkg.Path != "builtin" {
 				func() {
@@ -246,8 +305,13 @@ func (ds *docServer) writePackagesForListing(page *htmlPage, packages []*Package
 
 		page.WriteString(`<div id="wd-packages" class="alphabet">`)
 		i := 0
+		var lastModule *code.Module
 		for _, pkg := range packages {
 			if pkg.InWorkingDirectory {
+				if pkg.WorkspaceModule != nil && pkg.WorkspaceModule != lastModule {
+					lastModule = pkg.WorkspaceModule
+					fmt.Fprintf(page, `<div class="wd-module"><code>%s<i>module</i> %s</code></div>`, SPACES[:MinPrefixSpacesCount], lastModule.Path)
+				}
 				listPackage(i, pkg, false, showOthers)
 				i++
 			}
@@ -334,12 +398,14 @@ type PackageForListing struct {
 
 	//IsStandard         bool
 	InWorkingDirectory bool
+	WorkspaceModule    *code.Module // non-nil only in workspace mode
 }
 
 func (ds *docServer) buildOverviewData() *Overview {
 	numPkgs := ds.analyzer.NumPackages()
 	var pkgs = make([]PackageForListing, numPkgs)
 	var result = make([]*PackageForListing, numPkgs)
+	var inWorkspace = len(ds.analyzer.WorkingDirectoryModules()) > 1
 	for i := range result {
 		pkg := &pkgs[i]
 		result[i] = pkg
@@ -365,6 +431,11 @@ func (ds *docServer) buildOverviewData() *Overview {
 		}
 
 		pkg.InWorkingDirectory = strings.HasPrefix(p.Directory, ds.initialWorkingDirectory)
+		if inWorkspace && p.Module != nil && ds.analyzer.IsWorkingDirectoryModule(p.Module) {
+			// Packages in all workspace modules are viewed as working directory ones.
+			pkg.InWorkingDirectory = true
+			pkg.WorkspaceModule = p.Module
+		}
 	}
 
 	// ToDo: might be problematic sometimes. Should sort token by token.
@@ -374,6 +445,13 @@ func (ds *docServer) buildOverviewData() *Overview {
 			if result[a].InWorkingDirectory != result[b].InWorkingDirectory {
 				return result[a].InWorkingDirectory
 			}
+			// Group working directory packages by workspace modules.
+			if ma, mb := result[a].WorkspaceModule, result[b].WorkspaceModule; ma != mb {
+				if ma == nil || mb == nil {
+					return mb == nil
+				}
+				return ComparePackagePaths(ma.Path, mb.Path, '/')
+			}
 		}
 		// ...
 
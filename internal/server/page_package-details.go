@@ -8,6 +8,7 @@ import (
 	"go/ast"
 	"go/doc"
 	"go/format"
+	"go/scanner"
 	"go/token"
 	"go/types"
 	"io"
@@ -85,6 +86,11 @@ func (ds *docServer) buildPackageDetailsPage(w http.ResponseWriter, pkg *Package
 		pkg.ImportPath,
 		page.Translation().Text_PackageDocsLinksOnOtherWebsites(godevLink, pkg.IsStandard),
 	)
+	ds.writeProblemsBadge(page, pkg.ImportPath)
+	ds.writeGitDirtyPackageBadge(page, pkg.Package)
+	if stats, ok := coverProfile.PackageCoverage(pkg.ImportPath); ok {
+		writeCoverageBadge(page, stats)
+	}
 
 	isBuiltin := pkg.ImportPath == "builtin"
 	if !isBuiltin {
@@ -120,6 +126,10 @@ func (ds *docServer) buildPackageDetailsPage(w http.ResponseWriter, pkg *Package
 				page.WriteString("   ")
 			}
 			writeSrouceCodeFileLink(page, pkg.Package, info.Filename)
+			writePlatformsBadge(page, ds.analyzer.FilePlatforms(pkg.Package.Path(), info.Filename))
+			if wt := ds.gitWorkingTree(); wt != nil {
+				writeGitStatusBadge(page, wt.FileStatus(pkg.Package, info.Filename))
+			}
 		}
 
 		func() {
@@ -158,7 +168,9 @@ func (ds *docServer) buildPackageDetailsPage(w http.ResponseWriter, pkg *Package
 					},
 					func() {
 						page.WriteString("\n")
-						writePageTextEx(page, "\t\t", info.DocText, true, true)
+						if !ds.writeDocComment(page, pkg.Package, "\t\t", info.DocText, "", true) {
+							writePageTextEx(page, "\t\t", info.DocText, true, true)
+						}
 						if i < len(pkg.Files)-1 {
 							page.WriteString("\n")
 						}
@@ -217,6 +229,51 @@ func (ds *docServer) buildPackageDetailsPage(w http.ResponseWriter, pkg *Package
 		}()
 	}
 
+	if files := pkg.Package.ExcludedFiles(); len(files) > 0 {
+		func() {
+			page.WriteString("\n")
+			page.WriteString(`<div id="excluded-files">`)
+			defer page.WriteString("</div>")
+			fmt.Fprint(page, `<span class="title">`, page.Translation().Text_ExcludedFiles(len(files)), `</span>`)
+
+			page.WriteString("\n")
+
+			for _, file := range files {
+				fmt.Fprintf(page, "\n\t%s", file)
+				writePlatformsBadge(page, ds.analyzer.FilePlatforms(pkg.Package.Path(), file))
+			}
+			page.WriteString("\n")
+		}()
+	}
+
+	if decls := ds.analyzer.PlatformOnlyDeclarations(pkg.Package.Path()); len(decls) > 0 {
+		if !collectUnexporteds {
+			exporteds := decls[:0]
+			for _, decl := range decls {
+				if token.IsExported(decl.Name) && (decl.Recv == "" || token.IsExported(decl.Recv)) {
+					exporteds = append(exporteds, decl)
+				}
+			}
+			decls = exporteds
+		}
+		if len(decls) > 0 {
+			func() {
+				page.WriteString("\n")
+				page.WriteString(`<div id="platform-only-decls">`)
+				defer page.WriteString("</div>")
+				fmt.Fprint(page, `<span class="title">`, page.Translation().Text_OtherPlatformDeclarations(len(decls)), `</span>`)
+
+				page.WriteString("\n")
+
+				for i := range decls {
+					page.WriteString("\n\t")
+					ds.writePlatformOnlyDeclaration(page, pkg.Package, &decls[i])
+				}
+				page.WriteString("\n")
+			}()
+		}
+	}
+
 	if len(pkg.Examples) > 0 {
 		func() {
 			page.WriteString("\n")
@@ -259,6 +316,27 @@ func (ds *docServer) buildPackageDetailsPage(w http.ResponseWriter, pkg *Package
 		}()
 	}
 
+	if len(pkg.Notes) > 0 {
+		func() {
+			page.WriteString("\n")
+			page.WriteString(`<div id="notes">`)
+			defer page.WriteString("</div>")
+			fmt.Fprintf(page, `<span class="title"><a href="%s#pkg-%s">%s</a></span>`,
+				buildPageHref(page.PathInfo, createPagePathInfo(ResTypeNone, "notes"), nil, ""),
+				pkg.Package.Path(),
+				page.Translation().Text_Notes(len(pkg.Notes)),
+			)
+
+			page.WriteString("\n")
+
+			for _, note := range pkg.Notes {
+				page.WriteString("\n")
+				writePackageNote(page, pkg.Package, note)
+			}
+			page.WriteString("\n")
+		}()
+	}
+
 	//var writePackageLevelValues = func(title, name string, values []code.ValueResource, numExporteds int) {
 	var writePackageLevelValues = func(title, name string, values []ResourceWithPosition, numExporteds int) {
 
@@ -309,15 +387,29 @@ func (ds *docServer) buildPackageDetailsPage(w http.ResponseWriter, pkg *Package
 				}
 				//<<
 
+				var writeValueIndex = func() {
+					ds.writeResourceIndexHTML(page, pkg.Package, v, true, true, false)
+					ds.writeResourceRefCounts(page, v)
+					writePlatformsBadge(page, ds.analyzer.ResourcePlatforms(v))
+					if fv, ok := v.(*code.Function); ok {
+						if stats, ok := coverProfile.FuncDeclCoverage(fv.Pkg, fv.AstDecl); ok {
+							writeCoverageBadge(page, stats)
+						}
+						ds.writeFunctionProfileCost(page, fv.AstDecl)
+					}
+					if comment := v.Comment(); comment != "" {
+						page.WriteString(" // ")
+						writePageText(page, "", comment, true)
+					}
+				}
+
 				if doc := v.Documentation(); doc == "" && writeFuncTypeParameters == nil {
 					page.WriteString(`<span class="nodocs">`)
-					ds.writeResourceIndexHTML(page, pkg.Package, v, true, true, true)
+					writeValueIndex()
 					page.WriteString(`</span>`)
 				} else {
 					writeFoldingBlock(page, v.Name(), "content", "docs", false,
-						func() {
-							ds.writeResourceIndexHTML(page, pkg.Package, v, true, true, true)
-						},
+						writeValueIndex,
 						func() {
 							if writeFuncTypeParameters != nil {
 								writeFuncTypeParameters()
@@ -326,8 +418,10 @@ func (ds *docServer) buildPackageDetailsPage(w http.ResponseWriter, pkg *Package
 
 							if doc != "" {
 								page.WriteString("\n")
-								writePageText(page, "\t\t", doc, true)
-								page.WriteString("\n")
+								if !ds.writeDocComment(page, pkg.Package, "\t\t", doc, v.Name()+"-", false) {
+									writePageText(page, "\t\t", doc, true)
+									page.WriteString("\n")
+								}
 							}
 
 							page.WriteString("\n")
@@ -376,6 +470,41 @@ func (ds *docServer) buildPackageDetailsPage(w http.ResponseWriter, pkg *Package
 		page.WriteString(stat)
 	}
 
+	var writeTypeNameList = func(td *TypeDetails, typeIsExported bool, listName, title string, list []*TypeForListing, numExporteds int) {
+		page.WriteString("\n\t\t")
+		writeFoldingBlock(page, td.TypeName.Name(), listName, "items", false,
+			func() {
+				writeItemHeader(
+					title,
+					page.Translation().Text_PackageLevelResourceSimpleStat(false, len(list), numExporteds, collectUnexporteds),
+				)
+			},
+			func() {
+				exported := true
+			ListTypeNames:
+				for _, t := range list {
+					if t.TypeName.Exported() != exported {
+						continue
+					}
+					func() {
+						defer writeItemWrapper(exported)()
+
+						ds.writeTypeForListing(page, t, pkg.Package, "", DotMStyle_NotShow)
+					}()
+				}
+
+				if exported {
+					if numUnexporteds := len(list) - numExporteds; numUnexporteds > 0 {
+						page.WriteString("\n\t\t\t")
+						writeHiddenItemsHeader(page, td.TypeName.Name(), listName, typeIsExported, numUnexporteds, false)
+						exported = false
+						goto ListTypeNames
+					}
+				}
+			},
+		)
+	}
+
 	if len(pkg.TypeNames) == 0 {
 		goto WriteFunctions
 	}
@@ -412,6 +541,10 @@ func (ds *docServer) buildPackageDetailsPage(w http.ResponseWriter, pkg *Package
 	page.WriteString(`<label id="sort-types-by-popularity" class="button">`)
 	page.WriteString(page.Translation().Text_SortByItem("popularity"))
 	page.WriteString(`</label>`)
+	page.WriteString(" | ")
+	page.WriteString(`<label id="sort-types-by-usage" class="button">`)
+	page.WriteString(page.Translation().Text_SortByItem("usage"))
+	page.WriteString(`</label>`)
 	page.WriteString(" */</div>")
 
 	for i, tdwp := range pkg.TypeNames {
@@ -427,7 +560,7 @@ func (ds *docServer) buildPackageDetailsPage(w http.ResponseWriter, pkg *Package
 		if !typeIsExported {
 			extraClass = " " + classHiddenItem
 		}
-		fmt.Fprintf(page, `<div class="anchor type-res%s" id="name-%s" data-popularity="%d">`, extraClass, td.TypeName.Name(), td.Popularity)
+		fmt.Fprintf(page, `<div class="anchor type-res%s" id="name-%s" data-popularity="%d" data-usage="%d">`, extraClass, td.TypeName.Name(), td.Popularity, td.RefCounts.Total())
 		page.WriteString("\t")
 
 		//>> 1.18
@@ -437,11 +570,15 @@ func (ds *docServer) buildPackageDetailsPage(w http.ResponseWriter, pkg *Package
 		if doc := td.TypeName.Documentation(); doc == "" && writeTypeTypeParameters == nil && td.AllListsAreBlank {
 			page.WriteString(`<span class="nodocs">`)
 			ds.writeResourceIndexHTML(page, pkg.Package, td.TypeName, true, true, false)
+			writeRefCounts(page, td.RefCounts)
+			writePlatformsBadge(page, ds.analyzer.ResourcePlatforms(td.TypeName))
 			page.WriteString(`</span>`)
 		} else {
 			writeFoldingBlock(page, td.TypeName.Name(), "content", "docs", false,
 				func() {
 					ds.writeResourceIndexHTML(page, pkg.Package, td.TypeName, true, true, false)
+					writeRefCounts(page, td.RefCounts)
+					writePlatformsBadge(page, ds.analyzer.ResourcePlatforms(td.TypeName))
 				},
 				func() {
 					if writeTypeTypeParameters != nil {
@@ -453,7 +590,9 @@ func (ds *docServer) buildPackageDetailsPage(w http.ResponseWriter, pkg *Package
 
 					if doc != "" {
 						page.WriteString("\n")
-						writePageText(page, "\t\t", doc, true)
+						if !ds.writeDocComment(page, pkg.Package, "\t\t", doc, td.TypeName.Name()+"-", false) {
+							writePageText(page, "\t\t", doc, true)
+						}
 					}
 
 					// ToDo: for alias, if its denoting type is an exported named type, then stop here.
@@ -484,22 +623,40 @@ func (ds *docServer) buildPackageDetailsPage(w http.ResponseWriter, pkg *Package
 										if fldDoc, fldComment := fld.Field.Documentation(), fld.Field.Comment(); fldDoc == "" && fldComment == "" {
 											page.WriteString(`<span class="nodocs">`)
 											ds.writeFieldForListing(page, pkg.Package, fld, td.TypeName)
+											writeRefCounts(page, ds.analyzer.ObjectReferenceCounts(fld.Object()))
 											page.WriteString(`</span>`)
 										} else {
 											writeFoldingBlock(page, td.TypeName.Name(), "field-"+fld.Name(), "docs", false,
 												func() {
 													ds.writeFieldForListing(page, pkg.Package, fld, td.TypeName)
+													writeRefCounts(page, ds.analyzer.ObjectReferenceCounts(fld.Object()))
 												},
 												func() {
+													// A rendered doc comment is a block, so no line breaks are needed after it.
+													docRendered := false
 													if fldDoc != "" {
 														page.WriteString("\n")
-														writePageText(page, "\t\t\t\t", fldDoc, true)
+														// Promoted fields might be declared in other packages,
+														// against which the doc links should be resolved.
+														docPkg := pkg.Package
+														if fld.Field.Pkg != nil {
+															docPkg = fld.Field.Pkg
+														}
+														docRendered = ds.writeDocComment(page, docPkg, "\t\t\t\t", fldDoc, td.TypeName.Name()+"-"+fld.Name()+"-", false)
+														if !docRendered {
+															writePageText(page, "\t\t\t\t", fldDoc, true)
+														}
 													}
 													if fldComment != "" {
-														page.WriteString("\n")
+														if !docRendered {
+															page.WriteString("\n")
+														}
 														writePageText(page, "\t\t\t\t// ", fldComment, true)
+														docRendered = false
+													}
+													if !docRendered {
+														page.WriteString("\n")
 													}
-													page.WriteString("\n")
 												})
 										}
 									}()
@@ -516,6 +673,24 @@ func (ds *docServer) buildPackageDetailsPage(w http.ResponseWriter, pkg *Package
 							},
 						)
 					}
+					if td.Layout != nil {
+						hasLists = true
+						page.WriteString("\n\t\t")
+						writeFoldingBlock(page, td.TypeName.Name(), "layout", "items", false,
+							func() {
+								writeItemHeader(
+									page.Translation().Text_StructLayout(),
+									page.Translation().Text_StructLayoutStat(td.Layout.Size, td.Layout.Align, td.Layout.Padding),
+								)
+							},
+							func() {
+								page.WriteString("\n")
+								writeStructLayout(page, pkg.Package, td.Layout, "\t\t\t")
+								page.WriteString("\t\t\t")
+								buildPageHref(page.PathInfo, createPagePathInfo1(ResTypeLayout, pkg.Package.Path()), page, page.Translation().Text_ViewStructLayouts(), "name-", td.TypeName.Name())
+							},
+						)
+					}
 					if count, numExporteds := len(td.Methods), int(td.NumExportedMethods); count > 0 {
 						hasLists = true
 						page.WriteString("\n\t\t")
@@ -539,22 +714,47 @@ func (ds *docServer) buildPackageDetailsPage(w http.ResponseWriter, pkg *Package
 										if mthdDoc, mthdComment := mthd.Method.Documentation(), mthd.Method.Comment(); mthdDoc == "" && mthdComment == "" {
 											page.WriteString(`<span class="nodocs">`)
 											ds.writeMethodForListing(page, pkg.Package, mthd, td.TypeName, true, false)
+											writeRefCounts(page, ds.analyzer.ObjectReferenceCounts(mthd.Object()))
+											writePlatformsBadge(page, ds.analyzer.MethodPlatforms(mthd.Method))
+											if stats, ok := coverProfile.FuncDeclCoverage(mthd.Method.Pkg, mthd.Method.AstFunc); ok {
+												writeCoverageBadge(page, stats)
+											}
+											ds.writeFunctionProfileCost(page, mthd.Method.AstFunc)
 											page.WriteString(`</span>`)
 										} else {
 											writeFoldingBlock(page, td.TypeName.Name(), "method-"+mthd.Name(), "docs", false,
 												func() {
 													ds.writeMethodForListing(page, pkg.Package, mthd, td.TypeName, true, false)
+													writeRefCounts(page, ds.analyzer.ObjectReferenceCounts(mthd.Object()))
+													writePlatformsBadge(page, ds.analyzer.MethodPlatforms(mthd.Method))
+													if stats, ok := coverProfile.FuncDeclCoverage(mthd.Method.Pkg, mthd.Method.AstFunc); ok {
+														writeCoverageBadge(page, stats)
+													}
+													ds.writeFunctionProfileCost(page, mthd.Method.AstFunc)
 												},
 												func() {
+													docRendered := false
 													if mthdDoc != "" {
 														page.WriteString("\n")
-														writePageText(page, "\t\t\t\t", mthdDoc, true)
+														docPkg := pkg.Package
+														if mthd.Method.Pkg != nil {
+															docPkg = mthd.Method.Pkg
+														}
+														docRendered = ds.writeDocComment(page, docPkg, "\t\t\t\t", mthdDoc, td.TypeName.Name()+"-"+mthd.Name()+"-", false)
+														if !docRendered {
+															writePageText(page, "\t\t\t\t", mthdDoc, true)
+														}
 													}
 													if mthdComment != "" {
-														page.WriteString("\n")
+														if !docRendered {
+															page.WriteString("\n")
+														}
 														writePageText(page, "\t\t\t\t// ", mthdComment, true)
+														docRendered = false
+													}
+													if !docRendered {
+														page.WriteString("\n")
 													}
-													page.WriteString("\n")
 												},
 											)
 										}
@@ -572,6 +772,14 @@ func (ds *docServer) buildPackageDetailsPage(w http.ResponseWriter, pkg *Package
 							},
 						)
 					}
+					// Also shown for the types without methods, to explain
+					// why they don't implement a non-blank interface.
+					if !genDocsMode {
+						hasLists = true
+						_, isInterface := td.TypeName.Denoting().TT.Underlying().(*types.Interface)
+						page.WriteString("\n\t\t")
+						writeImplementationDiagnosisForm(page, td.TypeName, isInterface)
+					}
 					if count, numExporteds := len(td.ImplementedBys), int(td.NumExportedImpedBys); count > 0 {
 						hasLists = true
 						page.WriteString("\n\t\t")
@@ -645,6 +853,18 @@ func (ds *docServer) buildPackageDetailsPage(w http.ResponseWriter, pkg *Package
 							},
 						)
 					}
+					if count := len(td.Aliases); count > 0 {
+						hasLists = true
+						writeTypeNameList(td, typeIsExported, "aliases", page.Translation().Text_Aliases(), td.Aliases, int(td.NumExportedAliases))
+					}
+					if count := len(td.SameUnderlyings); count > 0 {
+						hasLists = true
+						writeTypeNameList(td, typeIsExported, "sameunderlyings", page.Translation().Text_SameUnderlyingTypes(), td.SameUnderlyings, int(td.NumExportedSameUnderlyings))
+					}
+					if count := len(td.Convertibles); count > 0 {
+						hasLists = true
+						writeTypeNameList(td, typeIsExported, "convertibles", page.Translation().Text_ConvertibleTypes(), td.Convertibles, int(td.NumExportedConvertibles))
+					}
 					if count, numExporteds := len(td.AsOutputsOf), int(td.NumExportedAsOutputsOfs); count > 0 {
 						hasLists = true
 						page.WriteString("\n\t\t")
@@ -750,6 +970,41 @@ func (ds *docServer) buildPackageDetailsPage(w http.ResponseWriter, pkg *Package
 							},
 						)
 					}
+					if count, numExporteds := len(td.Assignables), int(td.NumExportedAssignables); count > 0 {
+						hasLists = true
+						page.WriteString("\n\t\t")
+						writeFoldingBlock(page, td.TypeName.Name(), "assignables", "items", false,
+							func() {
+								writeItemHeader(
+									page.Translation().Text_Assignables(),
+									page.Translation().Text_PackageLevelResourceSimpleStat(true, count, numExporteds, collectUnexporteds),
+								)
+							},
+							func() {
+								exported := true
+							ListAssignables:
+								for _, v := range td.Assignables {
+									if v.Exported() != exported {
+										continue
+									}
+									func() {
+										defer writeItemWrapper(exported)()
+
+										ds.writeValueForListing(page, v, pkg.Package, td.TypeName)
+									}()
+								}
+
+								if exported {
+									if numUnexporteds := len(td.Assignables) - numExporteds; numUnexporteds > 0 {
+										page.WriteString("\n\t\t\t")
+										writeHiddenItemsHeader(page, td.TypeName.Name(), "assignables", typeIsExported, numUnexporteds, true)
+										exported = false
+										goto ListAssignables
+									}
+								}
+							},
+						)
+					}
 					page.WriteByte('\n')
 					if hasLists {
 						page.WriteByte('\n')
@@ -869,14 +1124,28 @@ type PackageDetails struct {
 	//IntroductionCode template.HTML
 	Examples       []*doc.Example
 	ExampleFileSet *token.FileSet
+
+	Notes []*PackageNote // BUG, TODO, FIXME, ... notes
 }
 
 type TypeDetails struct {
 	TypeName         *code.TypeName
 	AllListsAreBlank bool
 	Popularity       int
+	RefCounts        code.RefCounts
 
-	Aliases []*TypeForListing // excluding self if self is an alias.
+	Aliases            []*TypeForListing // excluding self if self is an alias.
+	NumExportedAliases int32
+
+	// Defined types sharing the same underlying type (excluding self),
+	// and defined types which underlying types are only different
+	// in struct field tags (values of them are convertible to each other).
+	SameUnderlyings            []*TypeForListing
+	Convertibles               []*TypeForListing
+	NumExportedSameUnderlyings int32
+	NumExportedConvertibles    int32
+
+	Layout *code.StructLayout // for struct types only
 
 	Fields             []*SelectorForListing // []*code.Selector
 	Methods            []*code.Selector
@@ -899,13 +1168,13 @@ type TypeDetails struct {
 	NumExportedAsInputsOfs  int32
 	NumExportedAsOutputsOfs int32
 
-	// ToDo: also list functions for function types.
-	//       But only for function types with at least
-	//       one type declared in the current package,
-	//       to avoid listing too many.
-
 	Values            []*ValueForListing
 	NumExportedValues int32
+
+	// Functions and methods for function types, and
+	// variables of the underlying types for slice/map types.
+	Assignables            []*ValueForListing
+	NumExportedAssignables int32
 }
 
 type ValueForListing struct {
@@ -939,7 +1208,10 @@ func (td *TypeDetails) calculatePopularity() {
 		len(td.Implements)*50 +
 		len(td.ImplementedBys)*150 +
 		len(td.AsInputsOf)*35 +
-		len(td.AsOutputsOf)*75
+		len(td.AsOutputsOf)*75 +
+		int(td.RefCounts.InPackage)*2 +
+		int(td.RefCounts.InModule)*5 +
+		int(td.RefCounts.External)*10
 }
 
 // ds should be locked before calling this method.
@@ -1134,6 +1406,7 @@ func buildPackageDetailsData(analyzer *code.CodeAnalyzer, pkgPath string, alsoCo
 		//typeResources = append(typeResources, td)
 		rwp := regResForFile(tn)
 		td := rwp.Type
+		td.RefCounts = analyzer.ObjectReferenceCounts(tn.TypeName)
 		typeResources = append(typeResources, rwp)
 
 		// Generally, we don't collect info for a type alias, execpt it denotes an unnamed or unexported type.
@@ -1148,11 +1421,18 @@ func buildPackageDetailsData(analyzer *code.CodeAnalyzer, pkgPath string, alsoCo
 		td.ImplementedBys, td.NumExportedImpedBys = buildTypeImplementedByList(analyzer, pkg, denoting, alsoCollectNonExporteds, tn)
 		//td.Implements = make([]code.Implementation, 0, len(denoting.Implements))
 		td.Implements, td.NumExportedImpls = buildTypeImplementsList(analyzer, pkg, denoting, alsoCollectNonExporteds)
+		td.Aliases, td.NumExportedAliases = buildTypeNameList(denoting.Aliases, pkg, alsoCollectNonExporteds, tn)
+		td.SameUnderlyings, td.NumExportedSameUnderlyings = buildTypeNameList(sameUnderlyingTypeNames(denoting), pkg, alsoCollectNonExporteds, tn)
+		td.Convertibles, td.NumExportedConvertibles = buildTypeNameList(convertibleTypeNames(denoting), pkg, alsoCollectNonExporteds, tn)
 
 		if isBuiltin {
 			continue
 		}
 
+		if st, ok := denoting.TT.Underlying().(*types.Struct); ok && st.NumFields() > 0 {
+			td.Layout = analyzer.StructLayoutOf(tn)
+		}
+
 		//td.Values = buildValueList(denoting.AsTypesOf, alsoCollectNonExporteds)
 		td.AsInputsOf, td.NumExportedAsInputsOfs = buildValueList(denoting.AsInputsOf, pkg, alsoCollectNonExporteds)
 		td.AsOutputsOf, td.NumExportedAsOutputsOfs = buildValueList(denoting.AsOutputsOf, pkg, alsoCollectNonExporteds)
@@ -1165,6 +1445,7 @@ func buildPackageDetailsData(analyzer *code.CodeAnalyzer, pkgPath string, alsoCo
 			values = append(values, t.AsTypesOf...)
 		}
 		td.Values, td.NumExportedValues = buildValueList(values, pkg, alsoCollectNonExporteds)
+		td.Assignables, td.NumExportedAssignables = buildValueList(denoting.AssignableValues, pkg, alsoCollectNonExporteds)
 	}
 
 	for _, tdwp := range typeResources {
@@ -1172,13 +1453,18 @@ func buildPackageDetailsData(analyzer *code.CodeAnalyzer, pkgPath string, alsoCo
 		td.calculatePopularity()
 
 		td.AllListsAreBlank =
-			len(td.Fields) == 0 &&
+			td.Layout == nil &&
+				len(td.Fields) == 0 &&
 				len(td.Methods) == 0 &&
 				len(td.ImplementedBys) == 0 &&
 				len(td.Implements) == 0 &&
 				len(td.Values) == 0 &&
+				len(td.Assignables) == 0 &&
 				len(td.AsInputsOf) == 0 &&
-				len(td.AsOutputsOf) == 0
+				len(td.AsOutputsOf) == 0 &&
+				len(td.Aliases) == 0 &&
+				len(td.SameUnderlyings) == 0 &&
+				len(td.Convertibles) == 0
 	}
 
 	// default sort-by
@@ -1220,6 +1506,7 @@ func buildPackageDetailsData(analyzer *code.CodeAnalyzer, pkgPath string, alsoCo
 
 	pkgDetails.Examples = pkg.Examples
 	pkgDetails.ExampleFileSet = analyzer.ExampleFileSet()
+	pkgDetails.Notes = collectPackageNotes(pkg)
 
 	return pkgDetails
 }
@@ -1395,6 +1682,48 @@ func buildTypeImplementsList(analyzer *code.CodeAnalyzer, pkg *code.Package, den
 	return sortTypeList(implements, pkg), numExporteds
 }
 
+func buildTypeNameList(typeNames []*code.TypeName, pkg *code.Package, alsoCollectNonExporteds bool, exceptTypeName *code.TypeName) ([]*TypeForListing, int32) {
+	numExporteds, list := int32(0), make([]TypeForListing, 0, len(typeNames))
+	for _, tn := range typeNames {
+		if tn == exceptTypeName {
+			continue
+		}
+		if e := tn.Exported(); alsoCollectNonExporteds || e {
+			list = append(list, TypeForListing{TypeName: tn})
+			if e {
+				numExporteds++
+			}
+		}
+	}
+	return sortTypeList(list, pkg), numExporteds
+}
+
+// The result includes the builtin basic type if the underlying type is a basic type.
+func sameUnderlyingTypeNames(denoting *code.TypeInfo) []*code.TypeName {
+	ut := denoting.Underlying
+	if ut == nil {
+		return nil
+	}
+	if ut.TypeName == nil || ut == denoting {
+		return ut.Underlieds
+	}
+	typeNames := make([]*code.TypeName, 0, len(ut.Underlieds)+1)
+	typeNames = append(typeNames, ut.TypeName)
+	return append(typeNames, ut.Underlieds...)
+}
+
+func convertibleTypeNames(denoting *code.TypeInfo) []*code.TypeName {
+	ut := denoting.Underlying
+	if ut == nil {
+		return nil
+	}
+	var typeNames []*code.TypeName
+	for _, t := range ut.IdenticalsIgnoringTags {
+		typeNames = append(typeNames, t.Underlieds...)
+	}
+	return typeNames
+}
+
 // Assume all types are named or pointer to named.
 func sortTypeList(typeList []TypeForListing, pkg *code.Package) []*TypeForListing {
 	result := make([]*TypeForListing, len(typeList))
@@ -1859,6 +2188,163 @@ func (ds *docServer) writeMethodType(page *htmlPage, docPkg *code.Package, metho
 	}
 }
 
+// writePlatformsBadge writes the platforms on which a declaration (or a file)
+// exists. Nothing is written if it exists on all the target platforms.
+// writePlatformOnlyDeclaration writes a declaration which only exists on some
+// non-primary platforms. It is not type checked, so only the identifiers which
+// are package-level type names in the primary platform view are linked.
+func (ds *docServer) writePlatformOnlyDeclaration(page *htmlPage, pkg *code.Package, decl *code.PlatformOnlyDeclaration) {
+	fullName := decl.Name
+	if decl.Recv != "" {
+		fullName = decl.Recv + "." + decl.Name
+	}
+	writeHeader := func() {
+		page.WriteString(fullName)
+		writePlatformsBadge(page, decl.Platforms)
+		if decl.Line > 0 {
+			fmt.Fprintf(page, ` <i class="refcounts">(%s:%d)</i>`, decl.File, decl.Line)
+		}
+	}
+	if decl.Declaration == "" && decl.Doc == "" {
+		writeHeader()
+		return
+	}
+
+	writeFoldingBlock(page, "platform-only-"+fullName, "content", "docs", false,
+		writeHeader,
+		func() {
+			if decl.Declaration != "" {
+				page.WriteString("\n\t\t")
+				ds.writePlatformOnlyDeclarationText(page, pkg, decl)
+				page.WriteString("\n")
+			}
+			if decl.Doc != "" {
+				page.WriteString("\n")
+				if !ds.writeDocComment(page, pkg, "\t\t", decl.Doc, "platform-only-"+fullName+"-", false) {
+					writePageText(page, "\t\t", decl.Doc, true)
+					page.WriteString("\n")
+				}
+			}
+			page.WriteString("\n")
+		},
+	)
+}
+
+func (ds *docServer) writePlatformOnlyDeclarationText(page *htmlPage, pkg *code.Package, decl *code.PlatformOnlyDeclaration) {
+	src := []byte(decl.Declaration)
+	fset := token.NewFileSet()
+	var s scanner.Scanner
+	s.Init(fset.AddFile("", -1, len(src)), src, nil, 0)
+
+	writeTypeLink := func(pkgPath, name string) bool {
+		if !collectUnexporteds && !token.IsExported(name) && pkgPath != "builtin" {
+			return false
+		}
+		p := ds.analyzer.PackageByPath(pkgPath)
+		if p == nil {
+			return false
+		}
+		var obj types.Object
+		if pkgPath == "builtin" {
+			obj = types.Universe.Lookup(name)
+		} else {
+			obj = p.PPkg.Types.Scope().Lookup(name)
+		}
+		if _, ok := obj.(*types.TypeName); !ok {
+			return false
+		}
+		buildPageHref(page.PathInfo, createPagePathInfo1(ResTypePackage, pkgPath), page, name, "name-", name)
+		return true
+	}
+
+	var last int          // the end offset of the last written token
+	var importPath string // non-blank if the last tokens are an import name and a dot
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		if tok == token.SEMICOLON && lit == "\n" {
+			continue // an automatically inserted semicolon
+		}
+		offset := fset.Position(pos).Offset
+		util.WriteHtmlEscapedString(page, decl.Declaration[last:offset])
+		text := tok.String()
+		if lit != "" {
+			text = lit
+		}
+		last = offset + len(text)
+
+		if tok != token.IDENT {
+			if tok != token.PERIOD {
+				importPath = ""
+			}
+			util.WriteHtmlEscapedString(page, text)
+			continue
+		}
+
+		switch {
+		case importPath != "":
+			if !writeTypeLink(importPath, lit) {
+				page.WriteString(lit)
+			}
+			importPath = ""
+		case lit == decl.Name:
+			fmt.Fprintf(page, "<b>%s</b>", lit)
+		case decl.Imports[lit] != "":
+			importPath = decl.Imports[lit]
+			page.WriteString(lit)
+		case !writeTypeLink(pkg.Path(), lit) && !writeTypeLink("builtin", lit):
+			page.WriteString(lit)
+		}
+	}
+	util.WriteHtmlEscapedString(page, decl.Declaration[last:])
+}
+
+func writePlatformsBadge(page *htmlPage, platforms []code.Platform) {
+	if len(platforms) == 0 {
+		return
+	}
+	names := make([]string, len(platforms))
+	for i, p := range platforms {
+		names[i] = p.String()
+	}
+	fmt.Fprintf(page, ` <span class="platforms" title="%s">%s</span>`,
+		page.Translation().Text_PlatformsBadgeTitle(),
+		strings.Join(names, ", "),
+	)
+}
+
+func writeRefCounts(page *htmlPage, counts code.RefCounts) {
+	if counts.Total() == 0 {
+		return
+	}
+	fmt.Fprintf(page, ` <i class="refcounts" title="%s">(%s)</i>`,
+		page.Translation().Text_ReferenceCounts(int(counts.InPackage), int(counts.InModule), int(counts.External)),
+		page.Translation().Text_ObjectUses(int(counts.Total())),
+	)
+}
+
+func (ds *docServer) writeResourceRefCounts(page *htmlPage, res code.Resource) {
+	var obj types.Object
+	switch res := res.(type) {
+	case *code.TypeName:
+		obj = res.TypeName
+	case *code.Function:
+		if res.Func == nil {
+			return // builtin
+		}
+		obj = res.Func
+	case *code.Variable:
+		obj = res.Var
+	case *code.Constant:
+		obj = res.Const
+	default:
+		return
+	}
+	writeRefCounts(page, ds.analyzer.ObjectReferenceCounts(obj))
+}
+
 func writeKindText(page *htmlPage, tt types.Type) {
 	var kind string
 	var bold = false
@@ -10,6 +10,8 @@ import (
 	"go/types"
 	"log"
 	"net/http"
+	"path/filepath"
+	"sort"
 	"strconv"
 
 	"go101.org/golds/code"
@@ -119,6 +121,34 @@ func (ds *docServer) buildSourceCodePage(w http.ResponseWriter, result *SourceFi
 		}
 	}
 
+	writePlatformsBadge(page, ds.analyzer.FilePlatforms(result.PkgPath, result.BareFilename))
+	if result.Git != nil {
+		writeGitStatusBadge(page, result.Git.Status)
+	}
+
+	if result.Coverage != nil {
+		fmt.Fprintf(page, `
+
+<span class="title">%s</span>
+	%s (%s)`,
+			page.Translation().Text_Coverage(),
+			page.Translation().Text_CoveragePercent(result.Coverage.Percent()),
+			page.Translation().Text_CoverageStats(result.Coverage.NumCovered, result.Coverage.NumStmts),
+		)
+	}
+
+	if decls := ds.platformSpecificDeclarations(result.PkgPath, result.BareFilename); len(decls) > 0 {
+		fmt.Fprintf(page, `
+
+<span class="title">%s</span>`,
+			page.Translation().Text_PlatformSpecificDeclarations(),
+		)
+		for _, decl := range decls {
+			fmt.Fprintf(page, "\n\t<a href=\"#line-%d\">%s</a>", decl.Line, decl.Name)
+			writePlatformsBadge(page, decl.Platforms)
+		}
+	}
+
 	fmt.Fprintf(page, `
 
 <span class="title">%s</span>
@@ -161,22 +191,98 @@ func (ds *docServer) buildSourceCodePage(w http.ResponseWriter, result *SourceFi
 		}
 	}
 
+	if len(result.CommentBlocks) > 0 {
+		writeCommentShowingModeSwitcher(page)
+	}
+
 	page.WriteString(`
 <pre class="line-numbers">`)
 
+	// The lines containing only comments might be hidden, so each of them is
+	// enclosed with its leading line break in a "comment-line" span. As hidden
+	// lines are not counted, the line counter is reset for the line following
+	// such a line.
+	var blocks = result.CommentBlocks
+	var lastIsCommentLine = false
 	var outputNewLine = true
 	for i, line := range result.Lines {
 		//		fmt.Fprintf(page, `
 		//<span class="anchor" id="line-%d"><code>%s</code></span>`,
 		//			i+1, line)
 		lineNumber := i + 1
+		if lineNumber == result.DocStartLine {
+			if outputNewLine {
+				page.WriteByte('\n')
+			}
+			page.WriteString(`<div class="anchor" id="doc">`)
+			outputNewLine = false
+		}
+
+		for len(blocks) > 0 && blocks[0].EndLine < lineNumber {
+			blocks = blocks[1:]
+		}
+		var block *SourceCommentBlock
+		if len(blocks) > 0 && blocks[0].StartLine <= lineNumber {
+			block = &blocks[0]
+		}
+		if block != nil {
+			if lineNumber == block.StartLine {
+				fmt.Fprintf(page, `<input type='checkbox' class="comment-fold" id="comment-fold-%d">`, lineNumber)
+			}
+			page.WriteString(`<span class="comment-line`)
+			if block.IsDoc {
+				page.WriteString(` doc`)
+			}
+			if lineNumber == block.StartLine && block.EndLine > block.StartLine {
+				page.WriteString(` comment-block-start`)
+			}
+			page.WriteString(`">`)
+		}
 		if outputNewLine {
 			page.WriteByte('\n')
 		}
-		if lineNumber == result.DocStartLine {
-			page.WriteString(`<div class="anchor" id="doc">`)
+		page.WriteString(`<span class="codeline`)
+		if result.Git != nil && result.Git.ChangedLines[lineNumber] != "" {
+			page.WriteString(` git-`)
+			page.WriteString(result.Git.ChangedLines[lineNumber])
+		}
+		if result.Profile != nil && result.Profile.HotLines[lineNumber] {
+			page.WriteString(` hot`)
+		}
+		page.WriteString(`"`)
+		if lastIsCommentLine {
+			fmt.Fprintf(page, ` style="counter-reset: line %d;"`, lineNumber-1)
+		}
+		if result.Git != nil && i < len(result.Git.LineCommits) && result.Git.LineCommits[i] != nil {
+			writeGitLineTitle(page, result.Git.LineCommits[i])
+		}
+		fmt.Fprintf(page, ` id="line-%d">`, lineNumber)
+		if block != nil && lineNumber == block.StartLine {
+			fmt.Fprintf(page, `<label for="comment-fold-%d"><code>%s</code></label>`, lineNumber, line)
+		} else {
+			fmt.Fprintf(page, `<code>%s</code>`, line)
+		}
+		if result.Profile != nil {
+			if cost := result.Profile.Lines[lineNumber]; cost != nil {
+				writeProfileCost(page, cpuProfile, *cost)
+			}
+		}
+		if result.Git != nil {
+			if change := result.Git.Declarations[lineNumber]; change != nil {
+				writeGitDeclarationChange(page, result.PkgPath, change)
+			}
+		}
+		page.WriteString(`</span>`)
+		if block != nil {
+			page.WriteString(`</span>`)
+			if lineNumber == block.StartLine && block.EndLine > block.StartLine {
+				page.WriteString(`<span class="comment-block-rest">`)
+			} else if lineNumber == block.EndLine && block.EndLine > block.StartLine {
+				page.WriteString(`</span>`)
+			}
 		}
-		fmt.Fprintf(page, `<span class="codeline" id="line-%d"><code>%s</code></span>`, lineNumber, line)
+		lastIsCommentLine = block != nil
+
 		if lineNumber == result.DocEndLine {
 			page.WriteString(`</div>`)
 			outputNewLine = false
@@ -191,6 +297,88 @@ func (ds *docServer) buildSourceCodePage(w http.ResponseWriter, result *SourceFi
 	return page.Done(w)
 }
 
+var commentShowingModes = []string{"all", "docs", "collapsed", "none"}
+
+// writeCommentShowingModeSwitcher writes the radio inputs and their labels
+// to switch the comment showing modes in a source code page. The inputs must
+// be the preceding siblings of the code lines container.
+func writeCommentShowingModeSwitcher(page *htmlPage) {
+	page.WriteString("\n")
+	for i, mode := range commentShowingModes {
+		checked := ""
+		if i == 0 {
+			checked = " checked"
+		}
+		fmt.Fprintf(page, `<input type="radio" name="comment-mode" class="comment-mode" id="comment-mode-%s"%s>`, mode, checked)
+	}
+	fmt.Fprintf(page, `
+<pre id="comment-modes"><code><span class="title">%s</span>
+	`,
+		page.Translation().Text_CommentShowingModes(),
+	)
+	for i, mode := range commentShowingModes {
+		if i > 0 {
+			page.WriteString(" | ")
+		}
+		fmt.Fprintf(page, `<label for="comment-mode-%s" class="button">%s</label>`, mode, page.Translation().Text_CommentShowingMode(mode))
+	}
+	page.WriteString("</code></pre>")
+}
+
+type PlatformSpecificDeclaration struct {
+	Name      string // "Recv.Name" for methods
+	Line      int
+	Platforms []code.Platform
+}
+
+// platformSpecificDeclarations returns the declarations in a source file
+// which don't exist on all the target platforms, in their line order.
+func (ds *docServer) platformSpecificDeclarations(pkgPath, bareFilename string) []PlatformSpecificDeclaration {
+	if !ds.analyzer.IsMultiPlatform() {
+		return nil
+	}
+	pkg := ds.analyzer.PackageByPath(pkgPath)
+	if pkg == nil || pkg.PackageAnalyzeResult == nil {
+		return nil
+	}
+
+	var decls []PlatformSpecificDeclaration
+	var check = func(res code.Resource) {
+		if !collectUnexporteds && !res.Exported() {
+			return
+		}
+		pos := res.Position()
+		if filepath.Base(pos.Filename) != bareFilename {
+			return
+		}
+		if platforms := ds.analyzer.ResourcePlatforms(res); platforms != nil {
+			name := res.Name()
+			if f, ok := res.(*code.Function); ok && f.IsMethod() {
+				_, tn, _ := f.ReceiverTypeName()
+				name = tn.Name() + "." + name
+			}
+			decls = append(decls, PlatformSpecificDeclaration{Name: name, Line: pos.Line, Platforms: platforms})
+		}
+	}
+	for _, tn := range pkg.PackageAnalyzeResult.AllTypeNames {
+		check(tn)
+	}
+	for _, f := range pkg.PackageAnalyzeResult.AllFunctions {
+		check(f)
+	}
+	for _, v := range pkg.PackageAnalyzeResult.AllVariables {
+		check(v)
+	}
+	for _, c := range pkg.PackageAnalyzeResult.AllConstants {
+		check(c)
+	}
+
+	sort.Slice(decls, func(a, b int) bool {
+		return decls[a].Line < decls[b].Line
+	})
+	return decls
+}
+
 type SourceFileAnalyzeResult struct {
 	PkgPath         string
 	BareFilename    string
@@ -201,6 +389,24 @@ type SourceFileAnalyzeResult struct {
 	NumImportRatios int32
 	DocStartLine    int
 	DocEndLine      int
+
+	// The line ranges of the comment groups (only the lines
+	// which contain nothing but comments are included).
+	CommentBlocks []SourceCommentBlock
+
+	// Nil if git annotations are not enabled or not available.
+	Git *GitFileAnnotations
+
+	// Nil if the file is not in the coverage profile.
+	Coverage *CoverStats
+
+	// Nil if the file is not in the samples of the CPU profile.
+	Profile *FileProfile
+}
+
+type SourceCommentBlock struct {
+	StartLine, EndLine int
+	IsDoc              bool // a doc or line comment of a declaration, spec or field
 }
 
 /*
@@ -476,6 +682,9 @@ type astVisitor struct {
 
 	sameFileObjects map[types.Object]int32
 
+	// The doc and line comments of declarations, specs and fields.
+	docCommentGroups map[*ast.CommentGroup]bool
+
 	astNodeDepth int32
 
 	topLevelFuncNodeDepth int32
@@ -562,7 +771,7 @@ func (v *astVisitor) tryToHandleSomeSpecialNodes(beforeNode ast.Node) {
 		default:
 			panic("should not")
 		case *ast.CommentGroup:
-			v.handleNode(node, "comment", "")
+			v.handleCommentGroup(node)
 		case *KeywordToken:
 			v.handleKeywordToken(node.pos, node.keyword)
 		case *ChanCommOprator:
@@ -949,30 +1158,7 @@ func (v *astVisitor) Visit(n ast.Node) (w ast.Visitor) {
 		case *ast.FuncDecl:
 			v.topLevelFuncNodeDepth = v.astNodeDepth
 
-			var recvTypeName string
-			if f.Recv != nil {
-				recvTypeName = func(typeExpr ast.Expr) string {
-					for {
-						switch e := typeExpr.(type) {
-						case *ast.Ident:
-							// ToDo: what if this ident is an alias to a pointer type?
-							return e.Name
-						case *ast.ParenExpr:
-							typeExpr = e.X
-						case *ast.StarExpr:
-							typeExpr = e.X
-						//>> 1.18
-						case *astIndexExpr:
-							typeExpr = e.X
-						case *astIndexListExpr:
-							typeExpr = e.X
-						//<<
-						default:
-							panic(fmt.Sprintf("impossible type: %T", e))
-						}
-					}
-				}(f.Recv.List[0].Type)
-			}
+			recvTypeName := funcDeclRecvTypeName(f)
 
 			v.topLevelFuncInfo = &astFunctionInfo{
 				Node:         n,
@@ -1239,6 +1425,34 @@ func (v *astVisitor) Visit(n ast.Node) (w ast.Visitor) {
 	return
 }
 
+// funcDeclRecvTypeName returns the receiver base type name of a method
+// declaration. It returns a blank string for a function declaration.
+func funcDeclRecvTypeName(f *ast.FuncDecl) string {
+	if f.Recv == nil {
+		return ""
+	}
+	typeExpr := f.Recv.List[0].Type
+	for {
+		switch e := typeExpr.(type) {
+		case *ast.Ident:
+			// ToDo: what if this ident is an alias to a pointer type?
+			return e.Name
+		case *ast.ParenExpr:
+			typeExpr = e.X
+		case *ast.StarExpr:
+			typeExpr = e.X
+		//>> 1.18
+		case *astIndexExpr:
+			typeExpr = e.X
+		case *astIndexListExpr:
+			typeExpr = e.X
+		//<<
+		default:
+			panic(fmt.Sprintf("impossible type: %T", e))
+		}
+	}
+}
+
 func (v *astVisitor) handleNode(node ast.Node, class, labelForId string) {
 	start := v.fset.PositionFor(node.Pos(), false)
 	end := v.fset.PositionFor(node.End(), false)
@@ -1250,6 +1464,68 @@ func (v *astVisitor) handleNode(node ast.Node, class, labelForId string) {
 	v.buildText(start, end, class, "", labelForId)
 }
 
+// handleCommentGroup writes a comment group and
+// records the lines which contain only comments.
+func (v *astVisitor) handleCommentGroup(cg *ast.CommentGroup) {
+	isDoc := v.docCommentGroups[cg]
+	class := "comment"
+	if isDoc {
+		class += " doc"
+	}
+	v.handleNode(cg, class, "")
+
+	start := v.fset.PositionFor(cg.Pos(), false)
+	end := v.fset.PositionFor(cg.End(), false)
+	block := SourceCommentBlock{StartLine: start.Line, EndLine: end.Line, IsDoc: isDoc}
+	lineStart := v.file.Offset(v.file.LineStart(start.Line))
+	if len(bytes.TrimSpace(v.content[lineStart:start.Offset])) > 0 {
+		block.StartLine++
+	}
+	lineEnd := end.Offset + bytes.IndexByte(v.content[end.Offset:], '\n')
+	if lineEnd < end.Offset {
+		lineEnd = len(v.content)
+	}
+	if len(bytes.TrimSpace(v.content[end.Offset:lineEnd])) > 0 {
+		block.EndLine--
+	}
+	if block.StartLine <= block.EndLine {
+		v.result.CommentBlocks = append(v.result.CommentBlocks, block)
+	}
+}
+
+// collectDocCommentGroups returns the doc and line
+// comments of the declarations, specs and fields in f.
+func collectDocCommentGroups(f *ast.File) map[*ast.CommentGroup]bool {
+	var groups = make(map[*ast.CommentGroup]bool)
+	var add = func(cgs ...*ast.CommentGroup) {
+		for _, cg := range cgs {
+			if cg != nil {
+				groups[cg] = true
+			}
+		}
+	}
+	ast.Inspect(f, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.File:
+			add(n.Doc)
+		case *ast.GenDecl:
+			add(n.Doc)
+		case *ast.FuncDecl:
+			add(n.Doc)
+		case *ast.ImportSpec:
+			add(n.Doc, n.Comment)
+		case *ast.ValueSpec:
+			add(n.Doc, n.Comment)
+		case *ast.TypeSpec:
+			add(n.Doc, n.Comment)
+		case *ast.Field:
+			add(n.Doc, n.Comment)
+		}
+		return true
+	})
+	return groups
+}
+
 func (v *astVisitor) handleBasicLit(basicLit *ast.BasicLit, extraClass, labelForId string) {
 	class := "lit-number"
 	if basicLit.Kind == token.STRING {
@@ -1666,6 +1942,17 @@ func writeSrouceCodeLineLink(page *htmlPage, pkg *code.Package, p token.Position
 	fmt.Fprintf(page, `"%s>%s</a>`, class, text)
 }
 
+// writeFunctionSourceLink writes the full name (prefixed with the package path
+// and the receiver type name if it is a method) of a function, linked to its source.
+func writeFunctionSourceLink(page *htmlPage, f *code.Function) {
+	name := f.Name()
+	if recv := funcDeclRecvTypeName(f.AstDecl); recv != "" {
+		name = recv + "." + name
+	}
+	fmt.Fprintf(page, "%s.", f.Pkg.Path())
+	writeSrouceCodeLineLink(page, f.Pkg, f.Position(), name, "")
+}
+
 func writeSrouceCodeFileLink(page *htmlPage, pkg *code.Package, sourceFilename string) {
 	buildPageHref(page.PathInfo, createPagePathInfo2b(ResTypeSource, pkg.Path(), "/", sourceFilename), page, sourceFilename)
 }
@@ -1878,6 +2165,8 @@ func (ds *docServer) analyzeSoureCode(pkgPath, bareFilename string) (*SourceFile
 			//pendingTokenPoses: make([]TokenPos, 0, 10),
 
 			sameFileObjects: make(map[types.Object]int32, 256),
+
+			docCommentGroups: collectDocCommentGroups(fileInfo.AstFile),
 		}
 		av.lineBuilder.Grow(1024)
 		av.pkgPath2RatioID = make(map[string]int32, len(fileInfo.AstFile.Imports))
@@ -1896,5 +2185,13 @@ func (ds *docServer) analyzeSoureCode(pkgPath, bareFilename string) (*SourceFile
 		result = av.result
 	}
 
+	result.Git = ds.gitFileAnnotations(pkg, fileInfo)
+	if stats, ok := applyCoverageToSourceLines(result); ok {
+		result.Coverage = &stats
+	}
+	if overlay := ds.profileOverlay(); overlay != nil && result.GeneratedPath == "" {
+		result.Profile = overlay.FileProfile(result.PkgPath, result.BareFilename)
+	}
+
 	return result, nil
 }
@@ -227,6 +227,11 @@ func (ds *docServer) buildStatisticsPage(w http.ResponseWriter) []byte {
 		writeTypes(items)
 	})
 
+	fmt.Fprintf(page, "\n\t<a href=\"%s\">%s</a>\n\n",
+		buildPageHref(page.PathInfo, createPagePathInfo(ResTypeNone, "unnamed-types"), nil, ""),
+		page.Translation().Text_ViewUnnamedTypes(),
+	)
+
 	fmt.Fprintf(page, `<pre><code><span class="title">%s</span></code>`, page.Translation().Text_StatisticsTitle("values"))
 	textSegments = page.Translation().Text_ValueStatistics(map[string]interface{}{
 		"exportedVariables": stats.ExportedVariables,
@@ -316,5 +321,86 @@ func (ds *docServer) buildStatisticsPage(w http.ResponseWriter) []byte {
 		}
 	})
 
+	if len(stats.MostReferencedExportedTypeNames.Items)+
+		len(stats.MostReferencedExportedFunctions.Items)+
+		len(stats.MostReferencedExportedValues.Items) > 0 {
+
+		fmt.Fprintf(page, `<pre><code><span class="title">%s</span></code>`, page.Translation().Text_StatisticsTitle("references"))
+		textSegments = page.Translation().Text_ReferenceStatistics(map[string]interface{}{
+			"typeNameCount": len(stats.MostReferencedExportedTypeNames.Items),
+			"functionCount": len(stats.MostReferencedExportedFunctions.Items),
+			"valueCount":    len(stats.MostReferencedExportedValues.Items),
+		})
+
+		// All are linked to source code.
+		writeRankedResources := func(items []code.RankedItem) {
+			defer page.WriteString("\n")
+			for _, item := range items {
+				res, ok := item.Item.(code.Resource)
+				if !ok {
+					continue
+				}
+				func() {
+					page.WriteString("\t\t")
+					defer page.WriteString("\n")
+					page.WriteString(res.Package().Path())
+					page.WriteByte('.')
+					ds.writeResourceIndexHTML(page, res.Package(), res, false, false, false)
+					fmt.Fprintf(page, ` <i class="refcounts">(%s)</i>`, page.Translation().Text_ObjectUses(item.Score))
+				}()
+			}
+		}
+
+		for i, rl := range []*code.RankedList{
+			&stats.MostReferencedExportedTypeNames,
+			&stats.MostReferencedExportedFunctions,
+			&stats.MostReferencedExportedValues,
+		} {
+			if len(rl.Items) == 0 {
+				continue
+			}
+			page.WriteString(textSegments[i])
+			writeRankedResources(rl.Items)
+		}
+	}
+
+	if items := stats.ExportedStructsWastingMostBytes.Items; len(items) > 0 {
+		fmt.Fprintf(page, `<pre><code><span class="title">%s</span></code>`, page.Translation().Text_StatisticsTitle("layouts"))
+		textSegments = page.Translation().Text_LayoutStatistics(map[string]interface{}{
+			"structCount": len(items),
+		})
+
+		page.WriteString(textSegments[0])
+		for _, item := range items {
+			tn, ok := item.Item.(*code.TypeName)
+			if !ok {
+				continue
+			}
+			fmt.Fprintf(page, "\t\t%s.", tn.Package().Path())
+			buildPageHref(page.PathInfo, createPagePathInfo1(ResTypeLayout, tn.Package().Path()), page, tn.Name(), "name-", tn.Name())
+			fmt.Fprintf(page, " <i>(%s)</i>\n", page.Translation().Text_WastedBytes(item.Score))
+		}
+		page.WriteString("\n")
+	}
+
+	if list, total := ds.leastCoveredFunctions(20); len(list) > 0 {
+		fmt.Fprintf(page, `<pre><code><span class="title">%s</span></code>`, page.Translation().Text_StatisticsTitle("coverage"))
+		textSegments = page.Translation().Text_CoverageStatistics(map[string]interface{}{
+			"statementCount":  total.NumStmts,
+			"coveragePercent": total.Percent(),
+			"functionCount":   len(list),
+		})
+
+		// All are linked to source code.
+		page.WriteString(textSegments[0])
+		for _, fc := range list {
+			page.WriteString("\t\t")
+			writeFunctionSourceLink(page, fc.Function)
+			writeCoverageBadge(page, fc.CoverStats)
+			page.WriteString("\n")
+		}
+		page.WriteString("\n")
+	}
+
 	return page.Done(w)
 }
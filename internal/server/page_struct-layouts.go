@@ -0,0 +1,145 @@
+package server
+
+import (
+	"fmt"
+	"go/types"
+	"html"
+	"net/http"
+	"sort"
+	"strings"
+
+	"go101.org/golds/code"
+)
+
+func (ds *docServer) structLayoutsPage(w http.ResponseWriter, r *http.Request, pkgPath string) {
+	w.Header().Set("Content-Type", "text/html")
+
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+
+	if ds.phase < Phase_Analyzed {
+		w.WriteHeader(http.StatusTooEarly)
+		ds.loadingPage(w, r)
+		return
+	}
+
+	if genDocsMode {
+		pkgPath = deHashScope(pkgPath)
+	}
+
+	pageKey := pageCacheKey{
+		resType: ResTypeLayout,
+		res:     pkgPath,
+	}
+	data, ok := ds.cachedPage(pageKey)
+	if !ok {
+		pkg := ds.analyzer.PackageByPath(pkgPath)
+		if pkg == nil {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprintf(w, "Package (%s) not found", pkgPath)
+			return
+		}
+
+		data = ds.buildStructLayoutsPage(w, pkg, buildStructLayoutsData(ds.analyzer, pkg))
+		ds.cachePage(pageKey, data)
+	}
+	w.Write(data)
+}
+
+type StructLayoutDetails struct {
+	TypeName *code.TypeName
+	Layout   *code.StructLayout
+}
+
+func buildStructLayoutsData(analyzer *code.CodeAnalyzer, pkg *code.Package) []StructLayoutDetails {
+	var list []StructLayoutDetails
+	for _, tn := range pkg.PackageAnalyzeResult.AllTypeNames {
+		if !collectUnexporteds && !tn.Exported() {
+			continue
+		}
+		if t := tn.Denoting(); tn.Alias != nil && t.TypeName != nil {
+			continue // to avoid duplicated listing
+		}
+		if layout := analyzer.StructLayoutOf(tn); layout != nil {
+			list = append(list, StructLayoutDetails{TypeName: tn, Layout: layout})
+		}
+	}
+
+	sort.Slice(list, func(a, b int) bool {
+		if wa, wb := list[a].Layout.Wasted(), list[b].Layout.Wasted(); wa != wb {
+			return wa > wb
+		}
+		return strings.ToLower(list[a].TypeName.Name()) < strings.ToLower(list[b].TypeName.Name())
+	})
+	return list
+}
+
+func (ds *docServer) buildStructLayoutsPage(w http.ResponseWriter, pkg *code.Package, list []StructLayoutDetails) []byte {
+	title := ds.currentTranslation.Text_StructLayouts() + ds.currentTranslation.Text_Colon(false) + pkg.Path()
+	page := NewHtmlPage(goldsVersion, title, ds.currentTheme, ds.currentTranslation, createPagePathInfo1(ResTypeLayout, pkg.Path()))
+
+	fmt.Fprintf(page, `<pre><code><span style="font-size:x-large;">%s%s<a href="%s">%s</a></span>
+`,
+		page.Translation().Text_StructLayouts(),
+		page.Translation().Text_Colon(false),
+		buildPageHref(page.PathInfo, createPagePathInfo1(ResTypePackage, pkg.Path()), nil, ""),
+		pkg.Path(),
+	)
+
+	for _, sld := range list {
+		fmt.Fprintf(page, `
+<div class="anchor" id="name-%s">	type `, sld.TypeName.Name())
+		page.WriteString("<b>")
+		ds.writeResourceIndexHTML(page, pkg, sld.TypeName, false, false, false)
+		page.WriteString("</b> ")
+		page.WriteString(page.Translation().Text_StructLayoutStat(sld.Layout.Size, sld.Layout.Align, sld.Layout.Padding))
+		page.WriteString("\n")
+		writeStructLayout(page, pkg, sld.Layout, "\t\t")
+		page.WriteString("</div>")
+	}
+
+	page.WriteString("</code></pre>")
+	return page.Done(w)
+}
+
+// writeStructLayout writes the field offsets, sizes and alignments of a struct
+// type, with the padding holes, and the suggested field order if it exists.
+func writeStructLayout(page *htmlPage, pkg *code.Package, layout *code.StructLayout, indent string) {
+	qualifier := func(p *types.Package) string {
+		if p == pkg.PPkg.Types {
+			return ""
+		}
+		return p.Name()
+	}
+
+	fmt.Fprintf(page, "%s<i>%s</i>\n", indent, page.Translation().Text_StructLayoutHeader())
+	for _, f := range layout.Fields {
+		name := f.Name
+		if f.Embedded {
+			name = "<i>" + name + "</i>"
+		}
+		fmt.Fprintf(page, "%s%6d %6d %6d  %s%s %s\n",
+			indent, f.Offset, f.Size, f.Align,
+			strings.Repeat("  ", f.Depth), name,
+			html.EscapeString(types.TypeString(f.Type, qualifier)),
+		)
+		if f.Padding > 0 {
+			fmt.Fprintf(page, `%s%6s <span class="padding">%6d</span>  %s<i>%s</i>`+"\n",
+				indent, "", f.Padding,
+				strings.Repeat("  ", f.Depth),
+				page.Translation().Text_PaddingBytes(int(f.Padding)),
+			)
+		}
+	}
+
+	if layout.Wasted() > 0 {
+		fmt.Fprintf(page, "\n%s%s\n%s\t", indent, page.Translation().Text_SuggestedFieldOrder(layout.OptimalSize), indent)
+		for i, k := range layout.OptimalOrder {
+			if i > 0 {
+				page.WriteString(", ")
+			}
+			page.WriteString(layout.Fields[k].Name)
+		}
+		page.WriteString("\n")
+	}
+}
@@ -0,0 +1,136 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+
+	"go101.org/golds/code"
+)
+
+func (ds *docServer) unnamedTypesPage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html")
+
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+
+	if ds.phase < Phase_Analyzed {
+		w.WriteHeader(http.StatusTooEarly)
+		ds.loadingPage(w, r)
+		return
+	}
+
+	pageKey := pageCacheKey{
+		resType: ResTypeNone,
+		res:     "unnamed-types",
+	}
+	data, ok := ds.cachedPage(pageKey)
+	if !ok {
+		data = ds.buildUnnamedTypesPage(w, buildUnnamedTypesData(ds.analyzer))
+		ds.cachePage(pageKey, data)
+	}
+	w.Write(data)
+}
+
+type UnnamedTypeDetails struct {
+	Type *code.TypeInfo
+
+	DefinedTypes []*TypeForListing
+	Aliases      []*TypeForListing
+	Convertibles []*TypeForListing
+}
+
+func buildUnnamedTypesData(analyzer *code.CodeAnalyzer) []*UnnamedTypeDetails {
+	// Only used to sort type lists.
+	builtinPkg := analyzer.BuiltinPackge()
+
+	var list []*UnnamedTypeDetails
+	analyzer.IterateTypes(func(t *code.TypeInfo) {
+		if t.TypeName != nil {
+			return // named or builtin basic types
+		}
+		if len(t.Underlieds) < 2 && len(t.Aliases) == 0 && len(t.IdenticalsIgnoringTags) == 0 {
+			return
+		}
+
+		utd := &UnnamedTypeDetails{Type: t}
+		utd.DefinedTypes, _ = buildTypeNameList(t.Underlieds, builtinPkg, collectUnexporteds, nil)
+		utd.Aliases, _ = buildTypeNameList(t.Aliases, builtinPkg, collectUnexporteds, nil)
+		var convertibles []*code.TypeName
+		for _, o := range t.IdenticalsIgnoringTags {
+			convertibles = append(convertibles, o.Underlieds...)
+		}
+		utd.Convertibles, _ = buildTypeNameList(convertibles, builtinPkg, collectUnexporteds, nil)
+
+		if len(utd.DefinedTypes)+len(utd.Aliases) < 2 && len(utd.Convertibles) == 0 {
+			return
+		}
+		list = append(list, utd)
+	})
+
+	sort.Slice(list, func(a, b int) bool {
+		na := len(list[a].DefinedTypes) + len(list[a].Aliases)
+		nb := len(list[b].DefinedTypes) + len(list[b].Aliases)
+		if na != nb {
+			return na > nb
+		}
+		return list[a].Type.ID() < list[b].Type.ID()
+	})
+
+	return list
+}
+
+func (ds *docServer) buildUnnamedTypesPage(w http.ResponseWriter, list []*UnnamedTypeDetails) []byte {
+	page := NewHtmlPage(goldsVersion, ds.currentTranslation.Text_UnnamedTypes(), ds.currentTheme, ds.currentTranslation, createPagePathInfo(ResTypeNone, "unnamed-types"))
+	fmt.Fprintf(page, `
+<pre><code><span style="font-size:xx-large;">%s</span></code></pre>
+`,
+		page.Translation().Text_UnnamedTypes(),
+	)
+
+	page.WriteString("<pre><code>\n\t")
+	page.WriteString(page.Translation().Text_UnnamedTypesIntroduction())
+	page.WriteString("\n")
+
+	var writeTypeList = func(utd *UnnamedTypeDetails, listName, title string, types []*TypeForListing) {
+		if len(types) == 0 {
+			return
+		}
+		page.WriteString("\n\t\t")
+		writeFoldingBlock(page, fmt.Sprintf("type-%d", utd.Type.ID()), listName, "items", false,
+			func() {
+				page.WriteString(title)
+				page.WriteString(page.Translation().Text_Parenthesis(false))
+				fmt.Fprintf(page, "<i>%d</i>", len(types))
+				page.WriteString(page.Translation().Text_Parenthesis(true))
+			},
+			func() {
+				for _, t := range types {
+					page.WriteString("\n\t\t\t")
+					ds.writeTypeForListing(page, t, nil, "", DotMStyle_NotShow)
+				}
+			},
+		)
+	}
+
+	for _, utd := range list {
+		fmt.Fprintf(page, `<div class="anchor" id="type-%d">`, utd.Type.ID())
+		page.WriteString("\n\t")
+		// Use the declaration of a type name to show the unnamed type.
+		var tn *code.TypeName
+		if len(utd.Type.Underlieds) > 0 {
+			tn = utd.Type.Underlieds[0]
+		} else {
+			tn = utd.Type.Aliases[0]
+		}
+		ds.WriteAstType(page, tn.AstSpec.Type, tn.Pkg, tn.Pkg, true, nil, nil)
+		writeKindText(page, utd.Type.TT)
+		writeTypeList(utd, "definedtypes", page.Translation().Text_DefinedTypes(), utd.DefinedTypes)
+		writeTypeList(utd, "aliases", page.Translation().Text_Aliases(), utd.Aliases)
+		writeTypeList(utd, "convertibles", page.Translation().Text_ConvertibleTypes(), utd.Convertibles)
+		page.WriteString("\n</div>")
+	}
+
+	page.WriteString("</code></pre>")
+	return page.Done(w)
+}
@@ -94,6 +94,81 @@ type Translation interface {
 	Text_AsOutputsOf() string
 	Text_AsInputsOf() string
 	Text_AsTypesOf() string
+	Text_Aliases() string
+	Text_SameUnderlyingTypes() string
+	Text_ConvertibleTypes() string
+	Text_Assignables() string
+	Text_StructLayout() string
+	Text_StructLayoutStat(size, align, padding int64) string // also used in struct layouts page
+	Text_StructLayoutHeader() string                         // also used in struct layouts page
+	Text_PaddingBytes(n int) string                          // also used in struct layouts page
+	Text_SuggestedFieldOrder(size int64) string              // also used in struct layouts page
+	Text_ViewStructLayouts() string
+	Text_PlatformsBadgeTitle() string // also used in source code page
+	Text_OtherPlatformDeclarations(num int) string
+	Text_ExcludedFiles(num int) string
+
+	// struct layouts page
+	Text_StructLayouts() string
+
+	// versioned docs
+	Text_DocsVersions() string // the version index page title
+	Text_DocsVersion() string  // the version switcher label
+
+	// analysis problems page
+	Text_AnalysisProblems() string // also used in overview and package details pages
+	Text_NoAnalysisProblems() string
+	Text_NumProblems(num int) string // also used in overview and package details pages
+
+	// docs check page
+	Text_DocsCheck() string // also used in overview page
+	Text_NoDocsIssues() string
+	Text_DocsCoverage(numDocumenteds, numExporteds int) string // also used in overview page
+
+	// notes page
+	Text_AllNotes() string     // also used in overview page
+	Text_ViewAllNotes() string // used in overview page
+	Text_NoNotes() string
+	Text_Notes(num int) string           // used in package details page
+	Text_NotesFilter(kind string) string // kind: marker | author | package
+	Text_NotesFilterAll() string
+
+	// function history page
+	Text_FunctionHistory() string
+	Text_LocalCommits() string
+	Text_NoGitCommits() string
+	Text_GitHistory() string      // used in source code pages
+	Text_GitUncommitted() string  // also used in source code pages
+	Text_RecentlyChanged() string // used in overview page
+
+	// uncommitted changes and API changes page
+	Text_UncommittedChanges() string         // used in overview, package details and source code pages
+	Text_GitFileStatus(status string) string // status: modified | added | untracked | deleted
+	Text_NumChangedFiles(num int) string     // used in overview and package details pages
+	Text_ViewAPIChanges() string             // used in overview page
+	Text_APIChanges() string
+	Text_NoAPIChanges() string
+	Text_APIChangeKind(kind string) string // kind: added | removed | changed
+
+	// test coverage
+	Text_Coverage() string                              // used in source code pages
+	Text_CoveragePercent(percent float64) string        // used in overview, package details and source code pages
+	Text_CoverageStats(numCovered, numStmts int) string // used in overview, package details and source code pages
+
+	// hot functions page
+	Text_HotFunctions() string     // also used in overview page
+	Text_ViewHotFunctions() string // used in overview page
+	Text_NoProfileSamples() string
+	Text_ProfileSummary(sampleType, total string, numSamples int) string
+	Text_ProfileCost(flat, cum string) string // also used in package details and source code pages
+	Text_CalledBy() string
+	Text_HottestLines() string
+
+	// unnamed types page
+	Text_UnnamedTypes() string
+	Text_UnnamedTypesIntroduction() string
+	Text_DefinedTypes() string
+	Text_ViewUnnamedTypes() string // used in statistics page
 
 	// package dependencies page
 	Text_DependencyRelations(pkgPath string) string // also used in package details page with a blank argument.
@@ -104,17 +179,29 @@ type Translation interface {
 	Text_MethodImplementations() string
 	Text_NumMethodsImplementingNothing(count int) string
 	Text_ViewMethodImplementations() string
+	Text_WhyNotImplement(forInterface bool, inputHTML string) string // also used in package details page
+
+	// implementation diagnosis page
+	Text_ImplementationDiagnosis() string
+	Text_ImplementationVerdict(typeName, interfaceName string, implemented, pointerImplemented bool) string
+	Text_MissingMethods() string
+	Text_MethodsWithDifferentSignatures() string
+	Text_PointerReceiverOnlyMethods(typeName string) string
 
 	// object references(uses) page
 	Text_ReferenceList() string
 	Text_CurrentPackage() string
 	Text_ObjectKind(kind string) string
 	Text_ObjectUses(num int) string // also used in other pages
+	Text_ReferenceCounts(inPackage, inModule, external int) string
 
 	// source code page
 	Text_SourceCode(pkgPath, bareFilename string) string
 	Text_SourceFilePath() string
 	Text_GeneratedFrom() string
+	Text_PlatformSpecificDeclarations() string
+	Text_CommentShowingModes() string
+	Text_CommentShowingMode(mode string) string // mode: all | docs | collapsed | none
 
 	// statistics
 	Text_Statistics() string
@@ -124,10 +211,22 @@ type Translation interface {
 	Text_TypeStatistics(values map[string]interface{}) []string
 	Text_ValueStatistics(values map[string]interface{}) []string
 	Text_Othertatistics(values map[string]interface{}) []string
+	Text_ReferenceStatistics(values map[string]interface{}) []string
+	Text_LayoutStatistics(values map[string]interface{}) []string
+	Text_WastedBytes(n int) string
+	Text_CoverageStatistics(values map[string]interface{}) []string
+
+	// doc comments
+	Text_DocContents() string
+	Text_BrokenDocLink() string
+
+	// one-page docs
+	Text_ModuleDocs(modulePath string) string
+	Text_ModulePackages(num int) string
 
 	// Footer
-	Text_GeneratedPageFooter(goldsVersion, qrCodeLink, goOS, goArch string) string
-	Text_GeneratedPageFooterSimple(goldsVersion, goOS, goArch string) string
+	Text_GeneratedPageFooter(goldsVersion, qrCodeLink, goOS, goArch, buildTags string) string
+	Text_GeneratedPageFooterSimple(goldsVersion, goOS, goArch, buildTags string) string
 }
 
 func (ds *docServer) currentSettings() (Theme, Translation) {
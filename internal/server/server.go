@@ -2,6 +2,7 @@ package server
 
 import (
 	//"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -81,24 +82,40 @@ type docServer struct {
 	generalLogger *log.Logger
 	visited       int32
 
-	// ToDo: show which packages are dirty in overview page.
-	wdRepositoryWarnings []string // not committed, not pushed, etc. (useful for docs generation mode)
-}
+	wdRepositoryWarnings      []string   // not committed, not pushed, etc. (useful for docs generation mode)
+	wdRepositoryWarningsMutex sync.Mutex // workspace modules are completed concurrently
 
-func Run(options PageOutputOptions, args []string, recommendedPort string, silentMode bool, printUsage func(io.Writer), appPkgPath string, roughBuildTime func() time.Time) {
-	ds := &docServer{
-		appPkgPath: appPkgPath,
+	// The dirty packages are shown in overview page.
+	theGitWorkingTree  *GitWorkingTree
+	gitWorkingTreeOnce sync.Once
 
-		analyzingLogger: log.New(os.Stdout, "[Analyzing] ", 0),
-		analyzingLogs:   make([]LoadingLogMessage, 0, 64),
+	// The CPU profile samples mapped to functions and lines.
+	theProfileOverlay  *ProfileOverlay
+	profileOverlayOnce sync.Once
+}
 
-		updateLogger:   log.New(os.Stdout, "[Update] ", 0),
-		roughBuildTime: roughBuildTime,
+func Run(options PageOutputOptions, args []string, recommendedPort string, silentMode bool, printUsage func(io.Writer), appPkgPath string, roughBuildTime func() time.Time) {
+	// Several versions of a module are analyzed in separated
+	// projects and served by separated servers side by side.
+	argGroups, _ := code.SplitModuleVersionArguments(args)
+	servers := make([]*docServer, len(argGroups))
+	for i := range servers {
+		ds := &docServer{
+			appPkgPath: appPkgPath,
+
+			analyzingLogger: log.New(os.Stdout, "[Analyzing] ", 0),
+			analyzingLogs:   make([]LoadingLogMessage, 0, 64),
+
+			updateLogger:   log.New(os.Stdout, "[Update] ", 0),
+			roughBuildTime: roughBuildTime,
+		}
+		if options.PreferredLang != "" {
+			ds.visited = 1 // to avoid auto adjusted
+		}
+		servers[i] = ds
 	}
 
-	if options.PreferredLang != "" {
-		ds.visited = 1 // to avoid auto adjusted
-	} else {
+	if options.PreferredLang == "" {
 		options.PreferredLang = os.Getenv("LANG")
 	}
 
@@ -116,30 +133,53 @@ func Run(options PageOutputOptions, args []string, recommendedPort string, silen
 		delta = 1
 	}
 
-NextTry:
-	l, err := net.ListenTCP("tcp", addr)
-	if err != nil {
-		if strings.Index(err.Error(), "bind: address already in use") >= 0 {
+	listeners := make([]*net.TCPListener, len(servers))
+	ports := make([]int, len(servers))
+	for i := range listeners {
+		if i > 0 {
 			addr.Port += delta
-			goto NextTry
 		}
-		log.Fatal(err)
+	NextTry:
+		l, err := net.ListenTCP("tcp", addr)
+		if err != nil {
+			if strings.Index(err.Error(), "bind: address already in use") >= 0 {
+				addr.Port += delta
+				goto NextTry
+			}
+			log.Fatal(err)
+		}
+		listeners[i], ports[i] = l, addr.Port
 	}
 
 	go func() {
-		ds.analyze(args, options, toolchain, false, printUsage)
-		ds.analyzingLogger.SetPrefix("")
-		serverStarted := ds.currentTranslationSafely().Text_Server_Started()
-		ds.analyzingLogger.Printf("%s http://localhost:%v\n", serverStarted, addr.Port)
+		// The analyses are run one by one, for the
+		// working directory might be changed in them.
+		for i, ds := range servers {
+			if err := ds.analyze(argGroups[i], options, toolchain, false, printUsage); err != nil {
+				log.Fatal(err)
+			}
+			ds.analyzingLogger.SetPrefix("")
+			serverStarted := ds.currentTranslationSafely().Text_Server_Started()
+			ds.analyzingLogger.Printf("%s http://localhost:%v\n", serverStarted, ports[i])
+		}
 	}()
 
 	if !silentMode {
-		err = util.OpenBrowser(fmt.Sprintf("http://localhost:%v", addr.Port))
-		if err != nil {
-			log.Println(err)
+		for _, port := range ports {
+			err = util.OpenBrowser(fmt.Sprintf("http://localhost:%v", port))
+			if err != nil {
+				log.Println(err)
+			}
 		}
 	}
 
+	for i := range servers[1:] {
+		go serve(servers[i], listeners[i])
+	}
+	serve(servers[len(servers)-1], listeners[len(listeners)-1])
+}
+
+func serve(ds *docServer, l net.Listener) {
 	(&http.Server{
 		Handler:      ds,
 		WriteTimeout: 5 * time.Second,
@@ -180,6 +220,18 @@ func (ds *docServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			http.Redirect(w, r, "/", http.StatusTemporaryRedirect)
 		case "statistics":
 			ds.statisticsPage(w, r)
+		case "unnamed-types":
+			ds.unnamedTypesPage(w, r)
+		case "problems":
+			ds.analysisProblemsPage(w, r)
+		case "docs-check":
+			ds.docsCheckPage(w, r)
+		case "notes":
+			ds.notesPage(w, r)
+		case "api-changes":
+			ds.apiChangesPage(w, r)
+		case "hot-functions":
+			ds.hotFunctionsPage(w, r)
 		}
 		return
 	}
@@ -212,6 +264,8 @@ func (ds *docServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		ds.packageDetailsPage(w, r, resPath)
 	case ResTypeDependency: // "dep"
 		ds.packageDependenciesPage(w, r, resPath)
+	case ResTypeLayout: // "lay"
+		ds.structLayoutsPage(w, r, resPath)
 	case ResTypeSource: // "src"
 		const sep = "/"
 		index := strings.LastIndex(resPath, sep)
@@ -227,6 +281,8 @@ func (ds *docServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		if index < 0 {
 			//ds.sourceCodePage(w, r, "", resPath)
 			fmt.Fprint(w, "Interface type containing package is not specified")
+		} else if with := r.FormValue("with"); with != "" {
+			ds.implementationDiagnosisPage(w, r, resPath[:index], resPath[index+len(sep):], with)
 		} else {
 			ds.methodImplementationPage(w, r, resPath[:index], resPath[index+len(sep):])
 		}
@@ -243,10 +299,21 @@ func (ds *docServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		} else {
 			ds.identifierReferencePage(w, r, resPath[:index], resPath[index+len(sep):])
 		}
+	case ResTypeHistory: // "his"
+		// Two forms: pkg..func or pkg..type.method.
+		const sep = ".."
+		index := strings.LastIndex(resPath, sep)
+		if index < 0 {
+			fmt.Fprint(w, "Function containing package is not specified")
+		} else {
+			ds.functionHistoryPage(w, r, resPath[:index], resPath[index+len(sep):])
+		}
 	}
 }
 
-func (ds *docServer) analyze(args []string, options PageOutputOptions, toolchain code.ToolchainInfo, forTesting bool, printUsage func(io.Writer)) {
+// analyze parses and analyzes the packages specified by the arguments.
+// The load errors are printed before an error is returned.
+func (ds *docServer) analyze(args []string, options PageOutputOptions, toolchain code.ToolchainInfo, forTesting bool, printUsage func(io.Writer)) error {
 	setPageOutputOptions(options, forTesting)
 	ds.initSettings(options.PreferredLang)
 
@@ -292,6 +359,9 @@ func (ds *docServer) analyze(args []string, options PageOutputOptions, toolchain
 	})
 
 	// ...
+	toolchain.Platforms = options.Platforms
+	toolchain.BuildTags = options.BuildTags
+	toolchain.Tolerant = options.Tolerant
 	if err := ds.analyzer.ParsePackages(ds.onAnalyzingSubTaskDone, ds.tryToCompleteModuleInfo, toolchain, args...); err != nil {
 		if loadErr, ok := err.(*code.LoadError); ok {
 			for _, e := range loadErr.Errs {
@@ -299,7 +369,7 @@ func (ds *docServer) analyze(args []string, options PageOutputOptions, toolchain
 			}
 
 			log.Println()
-			log.Fatal(`Exit for the above errors.
+			return errors.New(`Exit for the above errors.
 
 If you are sure that the code should compile okay, and
 you just upgraded your Go toolchain to a new Go version,
@@ -307,17 +377,26 @@ then please rebuild Golds with the following command.
 
 	go install go101.org/golds@latest
 
+Or run Golds with the -tolerant option to go on
+analyzing the packages in spite of the errors.
+
 `)
 
 		} else {
-			log.Println(err)
-
 			//if printUsage != nil {
 			//printUsage(os.Stdout)
 			//}
 
-			os.Exit(1)
+			return err
+		}
+	}
+
+	if problems := ds.analyzer.Problems(); len(problems) > 0 {
+		for _, p := range problems {
+			fmt.Fprintln(os.Stderr, p)
 		}
+		log.Printf("Analysis goes on with the above %d problems (tolerant mode).", len(problems))
+		log.Println()
 	}
 
 	// ...
@@ -359,4 +438,5 @@ then please rebuild Golds with the following command.
 	}()
 
 	succeeded = true
+	return nil
 }
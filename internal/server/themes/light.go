@@ -19,6 +19,8 @@ h3 {background: #ddd;}
 .title:after {content: "{{ .Colon }}";}
 .title-stat {font-size: medium; font-wieght: normal;}
 
+form.diagnose {display: inline; font-size: smaller;}
+
 .type-res, .value-res {padding-top: 2px; padding-bottom: 2px;}
 
 .js-on {display: none;}
@@ -37,6 +39,7 @@ h3 {background: #ddd;}
 /* overview page */
 
 div.pkg {margin-top: 1px; padding-top: 1px; padding-bottom: 1px;}
+div.wd-module {margin-top: 5px; color: #777;}
 
 a.path-duplicate {color: #9cd;}
 
@@ -78,6 +81,11 @@ i.codelines, i.importedbys, i.depdepth, i.depheight {font-size: smaller;}
 
 div:target {display: block;}
 span.nodocs {padding-left: 1px; padding-right: 1px;}
+i.refcounts {font-size: smaller; color: #999;}
+span.padding {color: #c33;}
+#docs-versions {float: right; margin: 0;}
+a.problems {font-size: smaller; color: #c33;}
+span.platforms {font-size: smaller; color: #777; border: 1px solid #ccc; border-radius: 3px; padding: 0 2px;}
 span.nodocs:before {content: ". ";}
 label {cursor: pointer; padding-left: 1px; padding-right: 1px;}
 input.fold {display: none;}
@@ -91,6 +99,16 @@ input.fold:checked + label + .fold-docs {display: inline;}
 input.fold + label.stats:before {content: "";}
 input.fold:checked + label.stats:before {content: "";}
 
+div.doc-comment {white-space: normal; max-width: 100ch;}
+div.doc-comment p {margin: 0 0 0.6em 0;}
+div.doc-comment h4 {margin: 0.9em 0 0.4em 0;}
+div.doc-comment pre {white-space: pre; margin: 0 0 0.6em 4ch;}
+div.doc-comment ul, div.doc-comment ol {margin: 0 0 0.6em 0; padding-left: 4ch;}
+div.doc-comment > :last-child {margin-bottom: 0;}
+div.doc-toc {margin-bottom: 0.9em;}
+div.doc-toc ul {margin: 0.3em 0; padding-left: 4ch;}
+span.broken-link {color: #c33; text-decoration: underline dashed;}
+
 .hidden {display: none;}
 .show-inline {display: inline;}
 .hide-inline {display: none;}
@@ -150,5 +168,38 @@ code .lit-string {color: #a66;}
 code .keyword {color: brown;}
 code .comment {color: green; font-style: italic;}
 
+input.comment-mode, input.comment-fold {display: none;}
+input#comment-mode-all:checked ~ #comment-modes label[for=comment-mode-all],
+input#comment-mode-docs:checked ~ #comment-modes label[for=comment-mode-docs],
+input#comment-mode-collapsed:checked ~ #comment-modes label[for=comment-mode-collapsed],
+input#comment-mode-none:checked ~ #comment-modes label[for=comment-mode-none]
+{font-weight: bold; text-decoration: underline;}
+input#comment-mode-docs:checked ~ pre .comment-line:not(.doc),
+input#comment-mode-docs:checked ~ pre span.comment:not(.doc),
+input#comment-mode-none:checked ~ pre .comment-line,
+input#comment-mode-none:checked ~ pre span.comment,
+input#comment-mode-collapsed:checked ~ pre input.comment-fold:not(:checked) + .comment-line + .comment-block-rest
+{display: none;}
+input#comment-mode-collapsed:checked ~ pre .comment-block-start label {cursor: pointer;}
+input#comment-mode-collapsed:checked ~ pre input.comment-fold:not(:checked) + .comment-block-start code:after {content: " ⋯"; color: #999;}
+
+span.git-decl {margin-left: 3ch; color: #999; font-size: smaller; font-style: italic; user-select: none;}
+span.git-decl a {color: #777;}
+span.git-status {font-size: smaller; color: #a60; border: 1px solid #eb8; border-radius: 3px; padding: 0 2px;}
+span.git-status.added, span.git-status.untracked {color: #280; border-color: #9c8;}
+span.git-status.deleted {color: #c33; border-color: #e99;}
+pre.line-numbers span.codeline.git-changed:before {background-color: #d8f0d0;}
+pre.line-numbers span.codeline.git-deleted-below:before {border-bottom: 2px solid #d66;}
+span.api-added {color: #280;}
+span.api-removed {color: #c33;}
+
+span.covered {background-color: #e0f4dc;}
+span.uncovered {background-color: #fbe0e0;}
+span.coverage {font-size: smaller; color: #396; border: 1px solid #ac9; border-radius: 3px; padding: 0 2px;}
+
+span.profile-cost {margin-left: 1ch; color: #b60; font-size: smaller; font-style: italic; user-select: none;}
+span.codeline.hot code {background-color: #fde8d0;}
+pre.line-numbers span.codeline.hot:before {color: #c30; font-weight: bold;}
+
 `
 }
@@ -4,6 +4,7 @@ import (
 	"container/list"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"go/token"
 	"io"
@@ -15,9 +16,13 @@ import (
 	"path/filepath"
 	"runtime"
 	"runtime/debug"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+
+	"go101.org/golds/code"
+	"go101.org/golds/internal/util"
 )
 
 var _ = runtime.GC
@@ -138,6 +143,32 @@ func deHashIdentifier(t string) string {
 	return t
 }
 
+// preregisterHashedIdentifiers registers all the declared exported identifiers
+// in sorted order, so that which one of the identifiers only different in
+// letter cases keeps unhashed doesn't depend on the page generation order.
+func preregisterHashedIdentifiers(analyzer *code.CodeAnalyzer) {
+	var names = make(map[string]struct{}, 1024*64)
+	for i := 0; i < analyzer.NumPackages(); i++ {
+		pkg := analyzer.PackageAt(i)
+		if pkg.PPkg.TypesInfo == nil {
+			continue
+		}
+		for id := range pkg.PPkg.TypesInfo.Defs {
+			if id.IsExported() {
+				names[id.Name] = struct{}{}
+			}
+		}
+	}
+	var sorted = make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+	for _, name := range sorted {
+		hashedIdentifier(name)
+	}
+}
+
 func hashHexHead(data []byte) []byte {
 	sum := sha256.Sum256([]byte(data)) // [32]byte
 	hexes := sum[10:30]
@@ -439,6 +470,14 @@ Generate:
 	var currentHref = makeHref(currentPageInfo)
 	var generatedHref = makeHref(linkedPageInfo)
 	var relativeHref = RelativePath(currentHref, generatedHref)
+	if currentDocsVersion != "" && isSharedAsset(linkedPageInfo.resType) {
+		// For versioned docs, the assets are shared by all
+		// versions and put in the parent directory.
+		if !isSharedAsset(currentPageInfo.resType) {
+			relativeHref = "../" + relativeHref
+		}
+		generatedHref = "../" + generatedHref
+	}
 
 	writeLink := func(w writer) {
 		writePageLink(func() {
@@ -489,21 +528,78 @@ Generate:
 }
 
 func GenDocs(options PageOutputOptions, args []string, outputDir string, silentMode bool, printUsage func(io.Writer), increaseGCFrequency bool, viewDocsCommand func(string) string) {
-	toolchain, err := findToolchainInfo()
+	if argGroups, labels := code.SplitModuleVersionArguments(args); len(argGroups) > 1 {
+		genModuleVersionsDocs(options, argGroups, labels, outputDir, silentMode, printUsage, increaseGCFrequency, viewDocsCommand)
+		return
+	}
+
+	ds, _, err := genDocs(options, args, outputDir, silentMode, printUsage, increaseGCFrequency)
 	if err != nil {
 		log.Fatal(err)
 	}
+	if ds == nil {
+		return
+	}
+
+	switch {
+	case docsArchiveFormat(outputDir) != "":
+		log.Printf("Docs are generated in the archive %s.", outputDir)
+		ds.printRepositoryWarnings()
+	case options.EmbedPackage != "":
+		log.Printf("Docs are generated as the Go package %s in %s.", options.EmbedPackage, outputDir)
+		ds.printRepositoryWarnings()
+		log.Println("Import the package and serve the docs, for example, by:")
+		log.Printf("\thttp.Handle(\"/docs/\", %s.Handler(\"/docs/\"))", options.EmbedPackage)
+	default:
+		log.Printf("Docs are generated in %s.", outputDir) // genOutputDir)
+		ds.printRepositoryWarnings()
+		log.Println("Run the following command to view the docs:")
+		log.Printf("\t%s", viewDocsCommand(outputDir)) // genOutputDir))
+	}
+}
+
+func (ds *docServer) printRepositoryWarnings() {
+	if sourceReadingStyle == SourceReadingStyle_external {
+		for _, w := range ds.wdRepositoryWarnings {
+			log.Println("!!! Warning:", w)
+		}
+		if len(ds.wdRepositoryWarnings) > 0 {
+			log.Println()
+		}
+	}
+}
+
+// genDocs generates the docs pages into outputDir. The returned docServer is
+// nil for testing (outputDir is blank). The returned paths are the ones of the
+// generated HTML pages, relative to outputDir. The errors are returned instead
+// of exiting, so that the callers could release the used resources.
+func genDocs(options PageOutputOptions, args []string, outputDir string, silentMode bool, printUsage func(io.Writer), increaseGCFrequency bool) (*docServer, []string, error) {
+	toolchain, err := findToolchainInfo()
+	if err != nil {
+		return nil, nil, err
+	}
 
 	enabledHtmlGenerationMod()
 
 	forTesting := outputDir == ""
 	silent := silentMode || forTesting
+	if options.EmbedPackage != "" {
+		if !isValidPackageName(options.EmbedPackage) {
+			return nil, nil, fmt.Errorf("invalid package name: %s", options.EmbedPackage)
+		}
+		if docsArchiveFormat(outputDir) != "" {
+			return nil, nil, errors.New("docs can't be generated as an embeddable package in an archive")
+		}
+	}
 	if increaseGCFrequency {
 		debug.SetGCPercent(75)
 	}
 	// ...
 	ds := &docServer{}
-	ds.analyze(args, options, toolchain, forTesting, printUsage)
+	if err := ds.analyze(args, options, toolchain, forTesting, printUsage); err != nil {
+		return nil, nil, err
+	}
+	preregisterHashedIdentifiers(ds.analyzer)
 
 	// ...
 	genOutputDir := outputDir
@@ -562,6 +658,7 @@ func GenDocs(options PageOutputOptions, args []string, outputDir string, silentM
 	buildPageHref(createPagePathInfo(ResTypeNone, ""), createPagePathInfo(ResTypeNone, ""), nil, "") // the overview page
 
 	// page loader
+	var loadErr error // only read after pages is closed
 	go func() {
 		for {
 			info := nextPageToLoad()
@@ -571,7 +668,8 @@ func GenDocs(options PageOutputOptions, args []string, outputDir string, silentM
 
 			content, err := buildPageContent(info.HrefPath)
 			if err != nil {
-				log.Fatalln("Read page data error:", err)
+				loadErr = fmt.Errorf("read page data error: %w", err)
+				break
 			}
 
 			//log.Println(count, count&2048, info.FilePath)
@@ -585,31 +683,82 @@ func GenDocs(options PageOutputOptions, args []string, outputDir string, silentM
 
 	// page saver
 	numPages, numBytes := 0, 0
+	var pagePaths []string
+
+	// The generated files are put in a directory (the output directory itself
+	// or a subfolder of an embeddable package), or streamed into an archive.
+	var filesDir = genOutputDir
+	var archive util.ArchiveWriter
+	var closeArchive func() error
+	var manifest, oldManifest *genManifest
+	if !forTesting {
+		if docsArchiveFormat(genOutputDir) != "" {
+			if options.Incremental {
+				log.Println("Note: the -incremental option is ignored for archive outputs.")
+			}
+			archive, closeArchive, err = createDocsArchive(genOutputDir)
+			if err != nil {
+				return nil, nil, fmt.Errorf("create archive error: %w", err)
+			}
+		} else {
+			var baseDir string
+			if options.EmbedPackage != "" {
+				baseDir = embeddedDocsFolder
+				filesDir = filepath.Join(genOutputDir, embeddedDocsFolder)
+			}
+			manifest = newGenManifest(baseDir, options.Incremental)
+			// The old manifest is also needed in non-incremental mode,
+			// to remove the orphaned files generated last time.
+			oldManifest = readGenManifest(genOutputDir)
+		}
+	}
+
+	// The pages are still received after an error occurs,
+	// so that the page loader is not blocked.
+	var saveErr error
 	for pg := range pages {
 		func(pg Page) {
 			defer contentPool.collect(pg.Content)
 
-			if forTesting {
+			if forTesting || saveErr != nil {
 				return
 			}
 
-			path := filepath.Join(genOutputDir, pg.FilePath)
-			path = strings.Replace(path, "/", string(filepath.Separator), -1)
-			path = strings.Replace(path, "\\", string(filepath.Separator), -1)
-
-			if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
-				log.Fatalln("Mkdir error:", err)
+			if strings.HasSuffix(pg.FilePath, ".html") {
+				pagePaths = append(pagePaths, pg.FilePath)
 			}
 
-			//if err := ioutil.WriteFile(path, pg.Content, 0644); err != nil {
-			//	log.Fatalln("Write file error:", err)
-			//}
-			if n, err := writeFile(path, pg.Content); err != nil {
-				log.Fatalln("Write file error:", err)
+			var n int
+			if archive != nil {
+				if err := archive.WriteFile(pg.FilePath, pg.Content...); err != nil {
+					saveErr = fmt.Errorf("write archive error: %w", err)
+					return
+				}
+				n = pg.Content.DataLength()
 			} else {
-				numPages++
-				numBytes += n
+				path := filepath.Join(filesDir, pg.FilePath)
+				path = strings.Replace(path, "/", string(filepath.Separator), -1)
+				path = strings.Replace(path, "\\", string(filepath.Separator), -1)
+
+				if !manifest.needWrite(pg.FilePath, path, pg.Content) {
+					return
+				}
+
+				if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+					saveErr = fmt.Errorf("mkdir error: %w", err)
+					return
+				}
+
+				//if err := ioutil.WriteFile(path, pg.Content, 0644); err != nil {
+				//	log.Fatalln("Write file error:", err)
+				//}
+				if n, err = writeFile(path, pg.Content); err != nil {
+					saveErr = fmt.Errorf("write file error: %w", err)
+					return
+				}
 			}
+			numPages++
+			numBytes += n
 
 			//if verboseLogs || !silent {
 			if !silent {
@@ -618,24 +767,48 @@ func GenDocs(options PageOutputOptions, args []string, outputDir string, silentM
 		}(pg)
 	}
 
+	if loadErr != nil {
+		return nil, nil, loadErr
+	}
 	if forTesting {
-		return
+		return nil, nil, nil
+	}
+
+	if archive != nil {
+		err := closeArchive()
+		if saveErr != nil {
+			return nil, nil, saveErr
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("write archive error: %w", err)
+		}
+	} else {
+		if saveErr != nil {
+			return nil, nil, saveErr
+		}
+		if err := manifest.removeOrphans(genOutputDir, oldManifest); err != nil {
+			return nil, nil, fmt.Errorf("remove file error: %w", err)
+		}
+		if err := manifest.write(genOutputDir); err != nil {
+			return nil, nil, fmt.Errorf("write file error: %w", err)
+		}
+		if options.EmbedPackage != "" {
+			if err := writeEmbeddedDocsPackage(genOutputDir, options.EmbedPackage); err != nil {
+				return nil, nil, fmt.Errorf("write file error: %w", err)
+			}
+		}
 	}
 
 	//if verboseLogs || !silent {
 	if !silent {
 		log.Printf("Done (%d pages are generated and %d bytes are written).", numPages, numBytes)
 	}
-
-	log.Printf("Docs are generated in %s.", outputDir) // genOutputDir)
-	if sourceReadingStyle == SourceReadingStyle_external {
-		for _, w := range ds.wdRepositoryWarnings {
-			log.Println("!!! Warning:", w)
-		}
-		if len(ds.wdRepositoryWarnings) > 0 {
-			log.Println()
-		}
+	if manifest != nil && manifest.Incremental {
+		log.Printf("%d files are added, %d are changed, %d are removed and %d are unchanged (see %s).",
+			len(manifest.Added), len(manifest.Changed), len(manifest.Removed), manifest.Unchanged, genManifestFile)
+	} else if manifest != nil && len(manifest.Removed) > 0 {
+		log.Printf("%d orphaned files generated last time are removed (see %s).", len(manifest.Removed), genManifestFile)
 	}
-	log.Println("Run the following command to view the docs:")
-	log.Printf("\t%s", viewDocsCommand(outputDir)) // genOutputDir))
+
+	return ds, pagePaths, nil
 }
@@ -0,0 +1,148 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"go101.org/golds/internal/util"
+)
+
+// The manifest file is written into the docs output directory.
+// It is used to find the unchanged and orphaned files in the next
+// incremental generation, and to tell which files are changed.
+const genManifestFile = "golds-manifest.json"
+
+type genManifest struct {
+	GoldsVersion string `json:"goldsVersion"`
+
+	// The directory (relative to the output directory, in slash form)
+	// which the file paths are relative to. It is blank for the output
+	// directory itself, or the files folder of an embeddable package.
+	BaseDir string `json:"baseDir,omitempty"`
+
+	// File paths (relative to the base directory) to content SHA-256 hashes.
+	Files map[string]string `json:"files"`
+
+	// Except Removed, the following ones are only recorded in incremental mode.
+	Incremental bool     `json:"incremental"`
+	Added       []string `json:"added,omitempty"`
+	Changed     []string `json:"changed,omitempty"`
+	Removed     []string `json:"removed,omitempty"`
+	Unchanged   int      `json:"unchanged,omitempty"`
+}
+
+func newGenManifest(baseDir string, incremental bool) *genManifest {
+	return &genManifest{
+		GoldsVersion: goldsVersion,
+		BaseDir:      baseDir,
+		Files:        make(map[string]string, 1024),
+		Incremental:  incremental,
+	}
+}
+
+// readGenManifest returns nil if the manifest file doesn't exist or is invalid.
+func readGenManifest(outputDir string) *genManifest {
+	data, err := os.ReadFile(filepath.Join(outputDir, genManifestFile))
+	if err != nil {
+		return nil
+	}
+	var m genManifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil
+	}
+	return &m
+}
+
+func (m *genManifest) write(outputDir string) error {
+	sort.Strings(m.Added)
+	sort.Strings(m.Changed)
+	sort.Strings(m.Removed)
+	data, err := json.MarshalIndent(m, "", "\t")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(outputDir, genManifestFile), data, 0644)
+}
+
+func contentHash(c Content) string {
+	h := sha256.New()
+	for _, bs := range c {
+		h.Write(bs)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// needWrite records a generated file and returns whether or not the file
+// needs to be written. In incremental mode, a file is not written if its
+// content is the same as the existing one on disk.
+func (m *genManifest) needWrite(filePath, path string, c Content) bool {
+	hash := contentHash(c)
+	m.Files[filePath] = hash
+	if !m.Incremental {
+		return true
+	}
+
+	old, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		m.Added = append(m.Added, filePath)
+		return true
+	case err != nil:
+		m.Changed = append(m.Changed, filePath)
+		return true
+	}
+	if sum := sha256.Sum256(old); hex.EncodeToString(sum[:]) == hash {
+		m.Unchanged++
+		return false
+	}
+	m.Changed = append(m.Changed, filePath)
+	return true
+}
+
+// removeOrphans removes the files recorded in the old manifest but not
+// generated this time. The old manifest may be written for another base
+// directory (in the embed mode or not), so the paths are compared after
+// being resolved against their own base directories. Only the files in
+// the output directory are removed, so the shared assets of versioned
+// docs (out of the output directory) and invalid paths are ignored.
+func (m *genManifest) removeOrphans(outputDir string, old *genManifest) error {
+	if old == nil {
+		return nil
+	}
+	outputDir, err := filepath.Abs(outputDir)
+	if err != nil {
+		return err
+	}
+	baseDir := filepath.Join(outputDir, filepath.FromSlash(m.BaseDir))
+	generated := make(map[string]bool, len(m.Files))
+	for filePath := range m.Files {
+		generated[filepath.Join(baseDir, filepath.FromSlash(filePath))] = true
+	}
+
+	oldBaseDir := filepath.Join(outputDir, filepath.FromSlash(old.BaseDir))
+	for filePath := range old.Files {
+		path := filepath.Join(oldBaseDir, filepath.FromSlash(filePath))
+		if generated[path] || path == outputDir || !util.IsInDirectory(path, outputDir) {
+			continue
+		}
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		if rel, err := filepath.Rel(baseDir, path); err == nil {
+			m.Removed = append(m.Removed, filepath.ToSlash(rel))
+		}
+
+		// Remove the directories which become empty.
+		for dir := filepath.Dir(path); len(dir) > len(outputDir); dir = filepath.Dir(dir) {
+			if os.Remove(dir) != nil {
+				break
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,364 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/token"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"go101.org/golds/code"
+)
+
+// GenMarkdownDocs generates one Markdown file for each of the packages
+// matching the arguments. The file for a package is put at the import path
+// of the package (plus the ".md" extension) under outputDir.
+func GenMarkdownDocs(options PageOutputOptions, args []string, outputDir string, silentMode bool, printUsage func(io.Writer)) {
+	toolchain, err := findToolchainInfo()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	forTesting := outputDir == ""
+	silent := silentMode || forTesting
+	// Source locations are linked to the external code hosts.
+	options.SourceReadingStyle = SourceReadingStyle_external
+
+	ds := &docServer{}
+	if err := ds.analyze(args, options, toolchain, forTesting, printUsage); err != nil {
+		log.Fatal(err)
+	}
+
+	genOutputDir := outputDir
+	if genOutputDir == "." {
+		genOutputDir = ds.initialWorkingDirectory
+	}
+	defer os.Chdir(ds.initialWorkingDirectory)
+
+	var generated = make(map[string]bool)
+	var pkgs []*code.Package
+	for _, pkg := range ds.analyzer.ArgumentPackages() {
+		if pkg.Path() != "builtin" {
+			generated[pkg.Path()] = true
+			pkgs = append(pkgs, pkg)
+		}
+	}
+	if len(pkgs) == 0 {
+		log.Println("No packages are matched by the arguments.")
+		return
+	}
+
+	for _, pkg := range pkgs {
+		details := buildPackageDetailsData(ds.analyzer, pkg.Path(), false)
+		md := &markdownWriter{
+			ds:        ds,
+			pkg:       pkg,
+			filePath:  markdownFilePath(pkg.Path()),
+			generated: generated,
+		}
+		md.writePackage(details)
+
+		if forTesting {
+			continue
+		}
+
+		path := filepath.Join(genOutputDir, filepath.FromSlash(md.filePath))
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			log.Fatalln("Mkdir error:", err)
+		}
+		if err := os.WriteFile(path, md.Bytes(), 0644); err != nil {
+			log.Fatalln("Write file error:", err)
+		}
+		if !silent {
+			log.Printf("Generated %s (size: %d).", md.filePath, md.Len())
+		}
+	}
+
+	if !forTesting {
+		log.Printf("Markdown docs of %d packages are generated in %s.", len(pkgs), outputDir)
+		ds.printRepositoryWarnings()
+	}
+}
+
+func markdownFilePath(pkgPath string) string {
+	return pkgPath + ".md"
+}
+
+type markdownWriter struct {
+	bytes.Buffer
+
+	ds        *docServer
+	pkg       *code.Package
+	filePath  string          // relative to the output directory
+	generated map[string]bool // the paths of the packages with generated Markdown files
+}
+
+func (md *markdownWriter) writePackage(details *PackageDetails) {
+	tr := md.ds.currentTranslation
+
+	fmt.Fprintf(md, "# package %s\n\n", details.Name)
+	fmt.Fprintf(md, "```go\nimport %s\n```\n\n", strconv.Quote(details.ImportPath))
+	for _, f := range details.Files {
+		if f.DocText != "" {
+			md.writeDocs(f.DocText)
+		}
+	}
+
+	writeValues := func(title string, values []ResourceWithPosition) {
+		if len(values) == 0 {
+			return
+		}
+		fmt.Fprintf(md, "## %s\n\n", title)
+		for _, v := range values {
+			md.writeValue(v.Value)
+		}
+	}
+	writeValues(tr.Text_PackageLevelConstants(), details.Constants)
+	writeValues(tr.Text_PackageLevelVariables(), details.Variables)
+	writeValues(tr.Text_PackageLevelFunctions(), details.Functions)
+
+	if len(details.TypeNames) > 0 {
+		fmt.Fprintf(md, "## %s\n\n", tr.Text_PackageLevelTypeNames())
+		for _, t := range details.TypeNames {
+			md.writeType(t.Type)
+		}
+	}
+}
+
+func (md *markdownWriter) writeValue(v code.ValueResource) {
+	var decl ast.Node
+	var kind string
+	switch res := v.(type) {
+	case *code.Constant:
+		kind = "const"
+		decl = &ast.GenDecl{Tok: token.CONST, Specs: []ast.Spec{md.constSpec(res)}}
+	case *code.Variable:
+		kind = "var"
+		decl = &ast.GenDecl{Tok: token.VAR, Specs: []ast.Spec{md.valueSpec(res.AstSpec, res.Name())}}
+	case *code.Function:
+		if res.AstDecl == nil {
+			return
+		}
+		kind = "func"
+		fd := *res.AstDecl
+		fd.Doc, fd.Body = nil, nil
+		decl = &fd
+	default:
+		return
+	}
+
+	md.writeHeading(3, v.Name(), kind+" "+v.Name(), v.Package(), v.Position())
+	md.writeCode(decl)
+	md.writeDocs(v.Documentation())
+}
+
+// constSpec returns a spec which only declares the constant c. The value
+// of a constant with an implicitly repeated expression (such as iota) is
+// shown as the evaluated one, with the type of the repeated spec.
+func (md *markdownWriter) constSpec(c *code.Constant) *ast.ValueSpec {
+	if len(c.AstSpec.Values) > 0 {
+		return md.valueSpec(c.AstSpec, c.Name())
+	}
+
+	vs := &ast.ValueSpec{
+		Names:  []*ast.Ident{ast.NewIdent(c.Name())},
+		Values: []ast.Expr{&ast.BasicLit{Value: c.Val().ExactString()}},
+	}
+	for _, spec := range c.AstDecl.Specs {
+		spec := spec.(*ast.ValueSpec)
+		if spec == c.AstSpec {
+			break
+		}
+		if len(spec.Values) > 0 {
+			vs.Type = spec.Type
+		}
+	}
+	return vs
+}
+
+// valueSpec returns a copy of spec which only declares the specified name.
+func (md *markdownWriter) valueSpec(spec *ast.ValueSpec, name string) *ast.ValueSpec {
+	vs := &ast.ValueSpec{Type: spec.Type}
+	for i, n := range spec.Names {
+		if n.Name == name {
+			vs.Names = []*ast.Ident{n}
+			if len(spec.Values) == len(spec.Names) {
+				vs.Values = []ast.Expr{spec.Values[i]}
+			}
+			break
+		}
+	}
+	return vs
+}
+
+func (md *markdownWriter) writeType(td *TypeDetails) {
+	tr := md.ds.currentTranslation
+	tn := td.TypeName
+
+	md.writeHeading(3, tn.Name(), "type "+tn.Name(), tn.Pkg, tn.Position())
+	if tn.AstSpec != nil {
+		ts := *tn.AstSpec
+		ts.Doc, ts.Comment = nil, nil
+		md.writeCode(&ast.GenDecl{Tok: token.TYPE, Specs: []ast.Spec{&ts}})
+	}
+	md.writeDocs(tn.Documentation())
+
+	if len(td.Fields) > 0 {
+		fmt.Fprintf(md, "**%s**\n\n", tr.Text_Fields())
+		for _, fld := range td.Fields {
+			md.WriteString("- `")
+			for _, middle := range fld.Middles {
+				md.WriteString(middle.Name)
+				md.WriteByte('.')
+			}
+			md.WriteString(fld.Name())
+			if fld.Field.Mode == code.EmbedMode_None {
+				md.WriteByte(' ')
+				md.WriteString(md.formatNode(fld.Field.Pkg, fld.Field.AstField.Type))
+			}
+			md.WriteString("`")
+			if fld.Field.Pkg != nil {
+				md.writeSourceLink(fld.Field.Pkg, fld.Field.Position())
+			}
+			if doc := firstDocLine(fld.Field.Documentation()); doc != "" {
+				md.WriteString(" - ")
+				md.WriteString(doc)
+			}
+			md.WriteByte('\n')
+		}
+		md.WriteByte('\n')
+	}
+
+	if len(td.Methods) > 0 {
+		fmt.Fprintf(md, "**%s**\n\n", tr.Text_Methods())
+		for _, sel := range td.Methods {
+			mthd := sel.Method
+			md.WriteString("- `")
+			switch {
+			case mthd.AstFunc != nil:
+				fd := *mthd.AstFunc
+				fd.Doc, fd.Body = nil, nil
+				md.WriteString(md.formatNode(mthd.Pkg, &fd))
+			case mthd.AstField != nil:
+				md.WriteString(sel.Name())
+				md.WriteString(strings.TrimPrefix(md.formatNode(mthd.Pkg, mthd.AstField.Type), "func"))
+			default:
+				md.WriteString(sel.Name())
+			}
+			md.WriteString("`")
+			if mthd.Pkg != nil {
+				md.writeSourceLink(mthd.Pkg, mthd.Position())
+			}
+			md.WriteByte('\n')
+		}
+		md.WriteByte('\n')
+	}
+
+	writeTypeList := func(title string, list []*TypeForListing) {
+		if len(list) == 0 {
+			return
+		}
+		fmt.Fprintf(md, "**%s**\n\n", title)
+		for _, t := range list {
+			md.WriteString("- ")
+			md.writeTypeLink(t)
+			md.WriteByte('\n')
+		}
+		md.WriteByte('\n')
+	}
+	writeTypeList(tr.Text_Implements(), td.Implements)
+	writeTypeList(tr.Text_ImplementedBy(), td.ImplementedBys)
+}
+
+// writeHeading writes a heading with an explicit anchor, followed
+// by a link to the source location on the external code host.
+func (md *markdownWriter) writeHeading(level int, anchor, title string, pkg *code.Package, pos token.Position) {
+	fmt.Fprintf(md, "<a name=\"%s\"></a>\n%s %s", anchor, strings.Repeat("#", level), title)
+	md.writeSourceLink(pkg, pos)
+	md.WriteString("\n\n")
+}
+
+// writeSourceLink writes a link to the source location on the external
+// code host. Nothing is written if the code host is unknown.
+func (md *markdownWriter) writeSourceLink(pkg *code.Package, pos token.Position) {
+	if pos.Filename == "" {
+		return
+	}
+	filename := filepath.Base(pos.Filename)
+	line := strconv.Itoa(pos.Line)
+	var link strings.Builder
+	handled, err := md.ds.buildExternelSourceLink(&link, pkg.Path()+"/"+filename, line, "")
+	if err == nil && handled {
+		fmt.Fprintf(md, " <sub>[%s#L%s](%s)</sub>", filename, line, link.String())
+	}
+}
+
+// writeTypeLink writes a type name, which is linked to its docs if the docs
+// are generated in Markdown too, or the type is a standard one.
+func (md *markdownWriter) writeTypeLink(t *TypeForListing) {
+	if t.IsPointer {
+		md.WriteByte('*')
+	}
+	tnPkg := t.Package()
+	text := t.Name()
+	if !t.InCurrentPkg {
+		text = tnPkg.PPkg.Name + "." + text
+	}
+
+	var href string
+	switch {
+	case t.InCurrentPkg:
+		href = "#" + t.Name()
+	case md.generated[tnPkg.Path()]:
+		href = RelativePath(md.filePath, markdownFilePath(tnPkg.Path())) + "#" + t.Name()
+	case md.ds.analyzer.IsStandardPackage(tnPkg):
+		href = "https://pkg.go.dev/" + tnPkg.Path() + "#" + t.Name()
+	}
+	if href == "" {
+		fmt.Fprintf(md, "`%s`", text)
+	} else {
+		fmt.Fprintf(md, "[`%s`](%s)", text, href)
+	}
+}
+
+func (md *markdownWriter) writeCode(node ast.Node) {
+	fmt.Fprintf(md, "```go\n%s\n```\n\n", md.formatNode(md.pkg, node))
+}
+
+// formatNode prints a declaration or a type with go/format. The HTML type
+// writers (WriteTypeEx and WriteFieldListEx in page_source-code.go) are
+// not used, for they write HTML escaped texts and bold tags, which are
+// shown literally in the Markdown code blocks and code spans.
+func (md *markdownWriter) formatNode(pkg *code.Package, node ast.Node) string {
+	if pkg == nil {
+		pkg = md.pkg
+	}
+	var b bytes.Buffer
+	if err := format.Node(&b, pkg.PPkg.Fset, node); err != nil {
+		return fmt.Sprintf("/* %s */", err)
+	}
+	return b.String()
+}
+
+// writeDocs writes the doc text, which is converted to Markdown.
+func (md *markdownWriter) writeDocs(text string) {
+	if strings.TrimSpace(text) == "" {
+		return
+	}
+	md.Write(bytes.TrimSpace(md.docsMarkdown(text)))
+	md.WriteString("\n\n")
+}
+
+func firstDocLine(text string) string {
+	text = strings.TrimSpace(text)
+	if i := strings.IndexByte(text, '\n'); i >= 0 {
+		text = text[:i]
+	}
+	return text
+}
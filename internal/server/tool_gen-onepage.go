@@ -0,0 +1,275 @@
+package server
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"go101.org/golds/code"
+)
+
+const (
+	OnePageUnit_package = "package"
+	OnePageUnit_module  = "module"
+)
+
+// A onePageDoc is a self-contained HTML file which
+// includes the docs of one or more packages.
+type onePageDoc struct {
+	Title    string
+	Module   *code.Module // nil for package unit
+	Packages []*code.Package
+}
+
+// GenOnePageDocs generates one self-contained HTML file for each of the
+// packages matching the arguments (unit is "package"), or for each of the
+// modules of these packages (unit is "module").
+func GenOnePageDocs(options PageOutputOptions, args []string, outputDir, unit string, silentMode bool, printUsage func(io.Writer)) {
+	if unit != OnePageUnit_package && unit != OnePageUnit_module {
+		log.Fatalln("Unknown one-page unit:", unit)
+	}
+
+	toolchain, err := findToolchainInfo()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	enabledHtmlGenerationMod()
+
+	forTesting := outputDir == ""
+	silent := silentMode || forTesting
+	// The QR code image is not inlined.
+	if options.FooterShowingManner == FooterShowingManner_verbose_and_qrcode {
+		options.FooterShowingManner = FooterShowingManner_verbose
+	}
+
+	ds := &docServer{}
+	if err := ds.analyze(args, options, toolchain, forTesting, printUsage); err != nil {
+		log.Fatal(err)
+	}
+	preregisterHashedIdentifiers(ds.analyzer)
+
+	genOutputDir := outputDir
+	if genOutputDir == "." {
+		genOutputDir = ds.initialWorkingDirectory
+	}
+	defer os.Chdir(ds.initialWorkingDirectory)
+
+	w := &docGenResponseWriter{}
+	r := &http.Request{URL: &url.URL{}}
+	buildPageContent := func(path string) string {
+		w.reset()
+		r.URL.Path = path
+		ds.ServeHTTP(w, r)
+		defer contentPool.collect(w.content)
+		if w.statusCode != http.StatusOK {
+			log.Fatalf("Read page data error: build %s, get non-ok status code: %d", path, w.statusCode)
+		}
+		var b strings.Builder
+		b.Grow(w.content.DataLength())
+		for _, bs := range w.content {
+			b.Write(bs)
+		}
+		return b.String()
+	}
+
+	css := buildPageContent("/" + string(ResTypeCSS) + ":" + addVersionToFilename(ds.currentTheme.Name(), goldsVersion))
+	js := buildPageContent("/" + string(ResTypeJS) + ":" + addVersionToFilename("golds", goldsVersion))
+
+	var docs = collectOnePageDocs(ds.analyzer, unit)
+	if len(docs) == 0 {
+		log.Println("No packages are matched by the arguments.")
+		return
+	}
+
+	for _, doc := range docs {
+		var pageContents = make([]string, len(doc.Packages))
+		for i, pkg := range doc.Packages {
+			pageContents[i] = buildPageContent("/" + string(ResTypePackage) + ":" + hashedScope(pkg.Path()))
+		}
+		content := ds.buildOnePageDoc(doc, pageContents, css, js)
+
+		if forTesting {
+			continue
+		}
+
+		filename := strings.Replace(doc.Title, "/", ".", -1) + ".html"
+		path := filepath.Join(genOutputDir, filename)
+		if err := os.MkdirAll(genOutputDir, 0700); err != nil {
+			log.Fatalln("Mkdir error:", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			log.Fatalln("Write file error:", err)
+		}
+		if !silent {
+			log.Printf("Generated %s (size: %d).", filename, len(content))
+		}
+	}
+
+	if !forTesting {
+		log.Printf("%d one-page docs are generated in %s.", len(docs), outputDir)
+		ds.printRepositoryWarnings()
+	}
+}
+
+// collectOnePageDocs groups the argument packages by the one-page unit.
+// The builtin package is excluded.
+func collectOnePageDocs(analyzer *code.CodeAnalyzer, unit string) []*onePageDoc {
+	var docs []*onePageDoc
+	var moduleDocs = make(map[*code.Module]*onePageDoc)
+	for _, pkg := range analyzer.ArgumentPackages() {
+		if pkg.Path() == "builtin" {
+			continue
+		}
+		if unit == OnePageUnit_module && pkg.Module != nil {
+			doc := moduleDocs[pkg.Module]
+			if doc == nil {
+				doc = &onePageDoc{Title: pkg.Module.Path, Module: pkg.Module}
+				moduleDocs[pkg.Module] = doc
+				docs = append(docs, doc)
+			}
+			doc.Packages = append(doc.Packages, pkg)
+			continue
+		}
+		docs = append(docs, &onePageDoc{Title: pkg.Path(), Packages: []*code.Package{pkg}})
+	}
+	return docs
+}
+
+// The body of a generated HTML page starts after this line.
+const pageBodyStart = `<body onload="onPageLoad()"><div>` + "\n"
+
+// buildOnePageDoc combines the package details pages of the packages in doc
+// into one HTML file, with the CSS and JavaScript files inlined.
+func (ds *docServer) buildOnePageDoc(doc *onePageDoc, pageContents []string, css, js string) string {
+	var included = make(map[string]string, len(doc.Packages)) // package path to id prefix
+	for _, pkg := range doc.Packages {
+		if len(doc.Packages) > 1 {
+			included[pkg.Path()] = onePageIdPrefix(pkg.Path())
+		} else {
+			// Keep the ids unchanged, so that the JavaScript code still works.
+			included[pkg.Path()] = ""
+		}
+	}
+
+	var title = ds.currentTranslation.Text_Package(doc.Title)
+	if doc.Module != nil {
+		title = ds.currentTranslation.Text_ModuleDocs(doc.Title)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta http-equiv="X-UA-Compatible" content="IE=edge">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>%s</title>
+<style>
+%s
+</style>
+<script>
+%s
+</script>
+%s`,
+		title,
+		css,
+		strings.Replace(js, "</script", `<\/script`, -1),
+		pageBodyStart,
+	)
+
+	if doc.Module != nil {
+		fmt.Fprintf(&b, `<pre id="onepage-toc"><code><span style="font-size:xx-large;">module <b>%s</b></span>
+
+<span class="title">%s</span>
+`,
+			doc.Module.Path,
+			ds.currentTranslation.Text_ModulePackages(len(doc.Packages)),
+		)
+		for _, pkg := range doc.Packages {
+			fmt.Fprintf(&b, `	<a href="#%spackage-details">%s</a>`, included[pkg.Path()], pkg.Path())
+			if pkg.OneLineDoc != "" {
+				fmt.Fprintf(&b, `<span class="pkg-summary"> - %s</span>`, html.EscapeString(pkg.OneLineDoc))
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("</code></pre>\n")
+	}
+
+	var footer string
+	for i, pkg := range doc.Packages {
+		content := pageContents[i]
+		if k := strings.Index(content, pageBodyStart); k >= 0 {
+			content = content[k+len(pageBodyStart):]
+		}
+		if k := strings.Index(content, `<pre id="footer">`); k >= 0 {
+			content, footer = content[:k], content[k:]
+		}
+		b.WriteString(ds.rewriteOnePageLinks(content, "pkg/"+hashedScope(pkg.Path())+".html", included[pkg.Path()], included))
+	}
+	b.WriteString(footer)
+
+	return b.String()
+}
+
+func onePageIdPrefix(pkgPath string) string {
+	return pkgPath + ":"
+}
+
+var onePageAttributeRegexp = regexp.MustCompile(`\s(id|for|href)=(?:"([^"]*)"|'([^']*)')`)
+
+// rewriteOnePageLinks rewrites the content of the page at pageFilePath (which
+// is relative to the docs root). The ids in the page are prefixed with idPrefix.
+// Links to the included packages are replaced with internal anchors, links
+// to standard packages are replaced with the pkg.go.dev ones, and the other
+// links to local pages are removed. The folding blocks are all expanded.
+func (ds *docServer) rewriteOnePageLinks(content, pageFilePath, idPrefix string, included map[string]string) string {
+	content = strings.Replace(content, `<input type='checkbox' class="fold"`, `<input type='checkbox' checked class="fold"`, -1)
+
+	return onePageAttributeRegexp.ReplaceAllStringFunc(content, func(attr string) string {
+		m := onePageAttributeRegexp.FindStringSubmatch(attr)
+		space, name, value := attr[:1], m[1], m[2]+m[3]
+		switch name {
+		case "id", "for":
+			return fmt.Sprintf(`%s%s="%s%s"`, space, name, idPrefix, value)
+		}
+
+		if strings.HasPrefix(value, "#") {
+			return fmt.Sprintf(`%shref="#%s%s"`, space, idPrefix, value[1:])
+		}
+		if strings.Contains(value, "://") || strings.HasPrefix(value, "mailto:") {
+			return attr
+		}
+
+		href, fragment := value, ""
+		if k := strings.IndexByte(value, '#'); k >= 0 {
+			href, fragment = value[:k], value[k+1:]
+		}
+		target := path.Join(path.Dir(pageFilePath), href)
+		if strings.HasPrefix(target, "pkg/") && strings.HasSuffix(target, ".html") {
+			pkgPath := deHashScope(strings.TrimSuffix(strings.TrimPrefix(target, "pkg/"), ".html"))
+			if prefix, ok := included[pkgPath]; ok {
+				if fragment == "" {
+					fragment = "package-details"
+				}
+				return fmt.Sprintf(`%shref="#%s%s"`, space, prefix, fragment)
+			}
+			if ds.analyzer.IsStandardPackageByPath(pkgPath) {
+				link := "https://pkg.go.dev/" + pkgPath
+				if strings.HasPrefix(fragment, "name-") {
+					link += "#" + deHashIdentifier(strings.TrimPrefix(fragment, "name-"))
+				}
+				return fmt.Sprintf(`%shref="%s"`, space, link)
+			}
+		}
+		return "" // not a link any more
+	})
+}
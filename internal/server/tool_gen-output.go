@@ -0,0 +1,126 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"go101.org/golds/internal/util"
+)
+
+// docsArchiveFormat returns "zip" or "tar.gz" if the docs should be
+// generated into an archive file, which is determined by the extension
+// of the output path. Otherwise, it returns blank.
+func docsArchiveFormat(outputPath string) string {
+	switch lower := strings.ToLower(outputPath); {
+	case strings.HasSuffix(lower, ".zip"):
+		return "zip"
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return "tar.gz"
+	}
+	return ""
+}
+
+// createDocsArchive creates the archive file to stream the generated files into.
+// Closing the returned ArchiveWriter doesn't close the file, the returned close
+// function closes both.
+func createDocsArchive(outputPath string) (util.ArchiveWriter, func() error, error) {
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0700); err != nil {
+		return nil, nil, err
+	}
+	f, err := os.Create(outputPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var aw util.ArchiveWriter
+	switch docsArchiveFormat(outputPath) {
+	case "zip":
+		aw = util.NewZipWriter(f)
+	case "tar.gz":
+		aw = util.NewTarGzipWriter(f)
+	default:
+		f.Close()
+		return nil, nil, fmt.Errorf("unknown archive format: %s", outputPath)
+	}
+	return aw, func() error {
+		if err := aw.Close(); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	}, nil
+}
+
+// For an embeddable Go package, the generated files
+// are put in this subfolder of the package folder.
+const embeddedDocsFolder = "files"
+
+var embeddedDocsPackageTemplate = template.Must(template.New("").Parse(`// Code generated by Golds {{.GoldsVersion}}. DO NOT EDIT.
+
+// Package {{.Name}} embeds the docs generated by Golds.
+//
+// Serve the docs at /docs/ by
+//
+//	http.Handle("/docs/", {{.Name}}.Handler("/docs/"))
+package {{.Name}}
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+	"strings"
+)
+
+//go:embed all:{{.Folder}}
+var files embed.FS
+
+// FS holds the generated docs files.
+var FS fs.FS
+
+func init() {
+	var err error
+	FS, err = fs.Sub(files, "{{.Folder}}")
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Handler returns an http.Handler which serves the docs.
+// prefix is the URL path the handler is registered at.
+func Handler(prefix string) http.Handler {
+	return http.StripPrefix(strings.TrimSuffix(prefix, "/"), http.FileServer(http.FS(FS)))
+}
+`))
+
+// writeEmbeddedDocsPackage writes the Go source file of an embeddable package
+// into dir. The generated files should be put in the embeddedDocsFolder subfolder.
+func writeEmbeddedDocsPackage(dir, pkgName string) error {
+	if !isValidPackageName(pkgName) {
+		return fmt.Errorf("invalid package name: %s", pkgName)
+	}
+
+	var b strings.Builder
+	err := embeddedDocsPackageTemplate.Execute(&b, struct {
+		GoldsVersion, Name, Folder string
+	}{goldsVersion, pkgName, embeddedDocsFolder})
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, pkgName+".go"), []byte(b.String()), 0644)
+}
+
+func isValidPackageName(name string) bool {
+	if name == "" || name == "main" {
+		return false
+	}
+	for i, r := range name {
+		if r == '_' || 'a' <= r && r <= 'z' || i > 0 && '0' <= r && r <= '9' {
+			continue
+		}
+		return false
+	}
+	return true
+}
@@ -0,0 +1,273 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go101.org/golds/internal/util"
+)
+
+var (
+	// For versioned docs generation only.
+	docsVersions       []string
+	docsVersionDirs    []string // the subfolder names of docsVersions
+	currentDocsVersion string
+)
+
+// The assets shared by all versions of versioned docs.
+// Their filenames are either versioned or never changed.
+func isSharedAsset(res pageResType) bool {
+	switch res {
+	case ResTypeCSS, ResTypeJS, ResTypeSVG, ResTypePNG:
+		return true
+	}
+	return false
+}
+
+const docsVersionsDataFile = "docs-versions.js"
+
+// docsVersionDir returns the name of the subfolder of the output directory
+// in which the docs of a version are generated. The path separators in the
+// version (such as the one in git branch release/1.0) are replaced with
+// underscores, so that the relative paths to the shared assets keep valid.
+func docsVersionDir(version string) (string, error) {
+	dir := strings.NewReplacer("/", "_", "\\", "_").Replace(version)
+	switch dir {
+	case "", ".", "..", "index.html", docsVersionsDataFile,
+		string(ResTypeCSS), string(ResTypeJS), string(ResTypeSVG), string(ResTypePNG):
+		return "", fmt.Errorf("%q can't be used as a docs version", version)
+	}
+	return dir, nil
+}
+
+// GenVersionedDocs generates docs for each of the specified versions into
+// a subfolder of outputDir (see docsVersionDir). A version is a git tag (or any other git revision) if
+// any of the arguments is a local path, otherwise it is a module version,
+// and the arguments should be module paths.
+func GenVersionedDocs(options PageOutputOptions, args []string, outputDir string, versions []string, silentMode bool, printUsage func(io.Writer), increaseGCFrequency bool, viewDocsCommand func(string) string) {
+	if outputDir == "" {
+		log.Fatal("versioned docs can't be generated in memory")
+	}
+	if len(versions) == 0 {
+		log.Fatal("no versions are specified")
+	}
+	if docsArchiveFormat(outputDir) != "" || options.EmbedPackage != "" {
+		log.Fatal("versioned docs can't be generated in an archive or as an embeddable package")
+	}
+	if len(args) == 0 {
+		args = []string{"."}
+	}
+	absOutputDir, err := filepath.Abs(outputDir)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var byGit = false
+	for _, arg := range args {
+		if arg == "." || strings.HasPrefix(arg, "./") || strings.HasPrefix(arg, ".\\") {
+			byGit = true
+			break
+		}
+	}
+
+	genVersionedDocs(options, absOutputDir, outputDir, versions, func(version string) ([]string, func(), error) {
+		return prepareDocsVersion(args, version, byGit)
+	}, silentMode, printUsage, increaseGCFrequency, viewDocsCommand)
+}
+
+// genModuleVersionsDocs generates docs for the argument groups split by
+// code.SplitModuleVersionArguments as versioned docs, each group is
+// generated into outputDir/label/.
+func genModuleVersionsDocs(options PageOutputOptions, argGroups [][]string, labels []string, outputDir string, silentMode bool, printUsage func(io.Writer), increaseGCFrequency bool, viewDocsCommand func(string) string) {
+	if outputDir == "" {
+		log.Fatal("docs of several module versions can't be generated in memory")
+	}
+	if docsArchiveFormat(outputDir) != "" || options.EmbedPackage != "" {
+		log.Fatal("docs of several module versions can't be generated in an archive or as an embeddable package")
+	}
+	absOutputDir, err := filepath.Abs(outputDir)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	groupArgs := make(map[string][]string, len(labels))
+	for i, label := range labels {
+		groupArgs[label] = argGroups[i]
+	}
+	genVersionedDocs(options, absOutputDir, outputDir, labels, func(version string) ([]string, func(), error) {
+		return groupArgs[version], func() {}, nil
+	}, silentMode, printUsage, increaseGCFrequency, viewDocsCommand)
+}
+
+// genVersionedDocs generates docs for each of the versions into a
+// subfolder of absOutputDir (see docsVersionDir). prepare returns the arguments to analyze for
+// a version, and a function to release the resources used for the version.
+func genVersionedDocs(options PageOutputOptions, absOutputDir, outputDir string, versions []string, prepare func(version string) ([]string, func(), error), silentMode bool, printUsage func(io.Writer), increaseGCFrequency bool, viewDocsCommand func(string) string) {
+	var dirs = make([]string, len(versions))
+	var dirVersions = make(map[string]string, len(versions))
+	for i, version := range versions {
+		dir, err := docsVersionDir(version)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if v, ok := dirVersions[dir]; ok {
+			log.Fatalf("the docs of versions %s and %s would be generated in the same folder %s", v, version, dir)
+		}
+		dirVersions[dir] = version
+		dirs[i] = dir
+	}
+
+	docsVersions, docsVersionDirs = versions, dirs
+	defer func() { currentDocsVersion = "" }()
+
+	var ds *docServer
+	var versionPages = make(map[string][]string, len(versions))
+	for i, version := range versions {
+		// The resources used for the version are always released,
+		// even if the generation fails.
+		err := func() error {
+			versionArgs, cleanup, err := prepare(version)
+			if err != nil {
+				return fmt.Errorf("prepare version %s error: %w", version, err)
+			}
+			defer cleanup()
+
+			log.Printf("Generating docs for version %s ...", version)
+			currentDocsVersion = version
+			ds, versionPages[dirs[i]], err = genDocs(options, versionArgs, filepath.Join(absOutputDir, dirs[i]), silentMode, printUsage, increaseGCFrequency)
+			return err
+		}()
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if err := writeDocsVersionsData(absOutputDir, versionPages); err != nil {
+		log.Fatalln("Write file error:", err)
+	}
+	if err := ds.writeDocsVersionsIndex(absOutputDir, versions, dirs); err != nil {
+		log.Fatalln("Write file error:", err)
+	}
+
+	log.Printf("Docs of %d versions are generated in %s.", len(versions), outputDir)
+	ds.printRepositoryWarnings()
+	log.Println("Run the following command to view the docs:")
+	log.Printf("\t%s", viewDocsCommand(outputDir))
+}
+
+// prepareDocsVersion returns the arguments to analyze for a version, and a
+// function to release the resources used for the version. For git revisions,
+// the working directory is changed to a temp git worktree checked out at the
+// revision, until the cleanup function is called.
+func prepareDocsVersion(args []string, version string, byGit bool) ([]string, func(), error) {
+	if !byGit {
+		versionArgs := make([]string, len(args))
+		for i, arg := range args {
+			versionArgs[i] = arg + "@" + version
+		}
+		return versionArgs, func() {}, nil
+	}
+
+	output, err := util.RunShell(time.Second*5, "", nil, "git", "rev-parse", "--show-prefix")
+	if err != nil {
+		return nil, nil, errors.New("the current directory is not in a git repository")
+	}
+	prefix := string(bytes.TrimSpace(output))
+
+	tempDir, err := os.MkdirTemp("", "golds-"+strings.ReplaceAll(version, "/", "_")+"-*")
+	if err != nil {
+		return nil, nil, fmt.Errorf("create temp dir error: %w", err)
+	}
+	worktreeDir := filepath.Join(tempDir, "worktree")
+	output, err = util.RunShell(time.Minute, "", nil, "git", "worktree", "add", "--detach", worktreeDir, version)
+	if err != nil {
+		os.RemoveAll(tempDir)
+		return nil, nil, fmt.Errorf("git worktree add %s error: %s %w", version, output, err)
+	}
+
+	oldDir := util.WorkingDirectory()
+	cleanup := func() {
+		os.Chdir(oldDir)
+		if output, err := util.RunShell(time.Minute, "", nil, "git", "worktree", "remove", "--force", worktreeDir); err != nil {
+			log.Printf("git worktree remove %s error: %s %s", worktreeDir, output, err)
+		}
+		os.RemoveAll(tempDir)
+	}
+	if err := os.Chdir(filepath.Join(worktreeDir, filepath.FromSlash(prefix))); err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("enter worktree dir error: %w", err)
+	}
+	return args, cleanup, nil
+}
+
+// writeDocsVersionsData writes the HTML page paths of each version
+// (keyed by the version subfolder names), which are used by the version switchers to check page existences.
+func writeDocsVersionsData(outputDir string, versionPages map[string][]string) error {
+	data, err := json.Marshal(versionPages)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	buf.WriteString("var docsVersionPages = ")
+	buf.Write(data)
+	buf.WriteString(";\n")
+	return os.WriteFile(filepath.Join(outputDir, docsVersionsDataFile), buf.Bytes(), 0644)
+}
+
+func (ds *docServer) writeDocsVersionsIndex(outputDir string, versions, dirs []string) error {
+	var buf bytes.Buffer
+	title := ds.currentTranslation.Text_DocsVersions()
+	fmt.Fprintf(&buf, `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>%s</title>
+<link href="%s" rel="stylesheet">
+<body><div>
+<pre><code><span style="font-size:xx-large;">%s</span>
+`,
+		title,
+		string(ResTypeCSS)+"/"+addVersionToFilename(ds.currentTheme.Name(), goldsVersion)+resType2ExtTable(ResTypeCSS),
+		title,
+	)
+	for i, v := range versions {
+		fmt.Fprintf(&buf, "\n\t<a href=\"%s/index.html\">%s</a>", html.EscapeString(url.PathEscape(dirs[i])), html.EscapeString(v))
+	}
+	buf.WriteString("\n</code></pre>\n</div></body></html>")
+	return os.WriteFile(filepath.Join(outputDir, "index.html"), buf.Bytes(), 0644)
+}
+
+// writeDocsVersionSwitcher writes a dropdown to jump to the same
+// page in another version (or the overview page if it doesn't exist).
+func writeDocsVersionSwitcher(page *htmlPage) {
+	if currentDocsVersion == "" {
+		return
+	}
+
+	root := strings.TrimSuffix(buildPageHref(page.PathInfo, createPagePathInfo(ResTypeNone, ""), nil, ""), "index.html")
+	fmt.Fprintf(page, `<script src="%s../%s"></script>
+<pre id="docs-versions"><code>%s <select data-root="%s" onchange="switchDocsVersion(this)">`,
+		root, docsVersionsDataFile,
+		page.Translation().Text_DocsVersion(),
+		root,
+	)
+	for i, v := range docsVersions {
+		selected := ""
+		if v == currentDocsVersion {
+			selected = " selected"
+		}
+		fmt.Fprintf(page, `<option value="%s"%s>%s</option>`, html.EscapeString(docsVersionDirs[i]), selected, html.EscapeString(v))
+	}
+	page.WriteString("</select></code></pre>\n")
+}
@@ -210,6 +210,8 @@ func (*Chinese) Text_SortByItem(by string) string {
 		return "按字母排序"
 	case "popularity":
 		return "按流行度排序"
+	case "usage":
+		return "按使用次数排序"
 	case "importedbys":
 		return "按被引入量排序"
 	case "depdepth":
@@ -375,6 +377,296 @@ func (*Chinese) Text_AsTypesOf() string {
 	return "和此类型相关的包级值"
 }
 
+func (*Chinese) Text_Aliases() string {
+	return "别名列表"
+}
+
+func (*Chinese) Text_SameUnderlyingTypes() string {
+	return "底层类型相同的类型"
+}
+
+func (*Chinese) Text_ConvertibleTypes() string {
+	return "可以相互转换的类型（忽略结构体字段标签）"
+}
+
+func (*Chinese) Text_Assignables() string {
+	return "可以赋值给此类型的值的包级值（仅限相关代码包中的）"
+}
+
+func (*Chinese) Text_StructLayout() string {
+	return "内存布局"
+}
+
+func (*Chinese) Text_StructLayoutStat(size, align, padding int64) string {
+	return fmt.Sprintf("尺寸：%d，对齐：%d，填充：%d", size, align, padding)
+}
+
+func (*Chinese) Text_StructLayoutHeader() string {
+	return "偏移     尺寸   对齐  字段"
+}
+
+func (*Chinese) Text_PaddingBytes(n int) string {
+	return fmt.Sprintf("%d个填充字节", n)
+}
+
+func (*Chinese) Text_SuggestedFieldOrder(size int64) string {
+	return fmt.Sprintf("建议的字段顺序（尺寸：%d）：", size)
+}
+
+func (*Chinese) Text_ViewStructLayouts() string {
+	return "查看此包中所有结构体类型的内存布局"
+}
+
+func (*Chinese) Text_PlatformsBadgeTitle() string {
+	return "仅在这些平台上可用"
+}
+
+func (*Chinese) Text_OtherPlatformDeclarations(num int) string {
+	return fmt.Sprintf("仅在其它平台上可用的声明（%d）", num)
+}
+
+func (*Chinese) Text_ExcludedFiles(num int) string {
+	return fmt.Sprintf("被构建约束排除的文件（%d）", num)
+}
+
+func (*Chinese) Text_StructLayouts() string {
+	return "结构体内存布局"
+}
+
+func (*Chinese) Text_WastedBytes(n int) string {
+	return fmt.Sprintf("浪费了%d个字节", n)
+}
+
+///////////////////////////////////////////////////////////////////
+// versioned docs
+///////////////////////////////////////////////////////////////////
+
+func (*Chinese) Text_DocsVersions() string {
+	return "文档版本"
+}
+
+func (*Chinese) Text_DocsVersion() string {
+	return "版本："
+}
+
+///////////////////////////////////////////////////////////////////
+// analysis problems page
+///////////////////////////////////////////////////////////////////
+
+func (*Chinese) Text_AnalysisProblems() string {
+	return "分析问题"
+}
+
+func (*Chinese) Text_NoAnalysisProblems() string {
+	return "没有问题。"
+}
+
+func (*Chinese) Text_NumProblems(num int) string {
+	return fmt.Sprintf("%d 个问题", num)
+}
+
+///////////////////////////////////////////////////////////////////
+// docs check page
+///////////////////////////////////////////////////////////////////
+
+func (*Chinese) Text_DocsCheck() string {
+	return "文档检查"
+}
+
+func (*Chinese) Text_NoDocsIssues() string {
+	return "没有文档问题。"
+}
+
+func (*Chinese) Text_DocsCoverage(numDocumenteds, numExporteds int) string {
+	var coverage float64 = 100
+	if numExporteds > 0 {
+		coverage = float64(numDocumenteds) * 100 / float64(numExporteds)
+	}
+	return fmt.Sprintf("文档覆盖率：%.1f%%（%d/%d）", coverage, numDocumenteds, numExporteds)
+}
+
+///////////////////////////////////////////////////////////////////
+// notes page
+///////////////////////////////////////////////////////////////////
+
+func (*Chinese) Text_AllNotes() string {
+	return "注释标记"
+}
+
+func (*Chinese) Text_ViewAllNotes() string {
+	return "查看命令行参数指定的代码包中的BUG/TODO/FIXME注释"
+}
+
+func (*Chinese) Text_NoNotes() string {
+	return "没有注释标记。"
+}
+
+func (*Chinese) Text_Notes(num int) string {
+	return fmt.Sprintf("注释标记（%d）", num)
+}
+
+func (*Chinese) Text_NotesFilter(kind string) string {
+	switch kind {
+	case "marker":
+		return "标记"
+	case "author":
+		return "作者"
+	case "package":
+		return "代码包"
+	}
+	panic("unknown notes filter kind: " + kind)
+}
+
+func (*Chinese) Text_NotesFilterAll() string {
+	return "全部"
+}
+
+///////////////////////////////////////////////////////////////////
+// function history page
+///////////////////////////////////////////////////////////////////
+
+func (*Chinese) Text_FunctionHistory() string {
+	return "提交历史"
+}
+
+func (*Chinese) Text_LocalCommits() string {
+	return "本地提交"
+}
+
+func (*Chinese) Text_NoGitCommits() string {
+	return "未找到本地提交。"
+}
+
+func (*Chinese) Text_GitHistory() string {
+	return "历史"
+}
+
+func (*Chinese) Text_GitUncommitted() string {
+	return "尚未提交"
+}
+
+func (*Chinese) Text_RecentlyChanged() string {
+	return "最近改动"
+}
+
+///////////////////////////////////////////////////////////////////
+// uncommitted changes and API changes page
+///////////////////////////////////////////////////////////////////
+
+func (*Chinese) Text_UncommittedChanges() string {
+	return "未提交的改动"
+}
+
+func (*Chinese) Text_GitFileStatus(status string) string {
+	switch status {
+	case "modified":
+		return "已修改"
+	case "added":
+		return "新添加"
+	case "untracked":
+		return "未跟踪"
+	case "deleted":
+		return "已删除"
+	}
+	panic("unknown git file status: " + status)
+}
+
+func (*Chinese) Text_NumChangedFiles(num int) string {
+	return fmt.Sprintf("%d个改动文件", num)
+}
+
+func (*Chinese) Text_ViewAPIChanges() string {
+	return "查看自HEAD以来的导出API改动"
+}
+
+func (*Chinese) Text_APIChanges() string {
+	return "自HEAD以来的导出API改动"
+}
+
+func (*Chinese) Text_NoAPIChanges() string {
+	return "没有导出API改动。"
+}
+
+func (*Chinese) Text_APIChangeKind(kind string) string {
+	switch kind {
+	case "added":
+		return "新增"
+	case "removed":
+		return "删除"
+	case "changed":
+		return "修改"
+	}
+	panic("unknown API change kind: " + kind)
+}
+
+///////////////////////////////////////////////////////////////////
+// test coverage
+///////////////////////////////////////////////////////////////////
+
+func (*Chinese) Text_Coverage() string {
+	return "测试覆盖率"
+}
+
+func (*Chinese) Text_CoveragePercent(percent float64) string {
+	return fmt.Sprintf("%.1f%%", percent)
+}
+
+func (*Chinese) Text_CoverageStats(numCovered, numStmts int) string {
+	return fmt.Sprintf("%d条语句中的%d条被覆盖", numStmts, numCovered)
+}
+
+///////////////////////////////////////////////////////////////////
+// hot functions page
+///////////////////////////////////////////////////////////////////
+
+func (*Chinese) Text_HotFunctions() string {
+	return "热点函数"
+}
+
+func (*Chinese) Text_ViewHotFunctions() string {
+	return "查看性能剖析中开销最大的函数"
+}
+
+func (*Chinese) Text_NoProfileSamples() string {
+	return "没有位于被分析的函数中的性能剖析样本。"
+}
+
+func (*Chinese) Text_ProfileSummary(sampleType, total string, numSamples int) string {
+	return fmt.Sprintf("共%d个样本，%s总计%s。", numSamples, sampleType, total)
+}
+
+func (*Chinese) Text_ProfileCost(flat, cum string) string {
+	return fmt.Sprintf("自身%s，累计%s", flat, cum)
+}
+
+func (*Chinese) Text_CalledBy() string {
+	return "调用者"
+}
+
+func (*Chinese) Text_HottestLines() string {
+	return "最热代码行"
+}
+
+///////////////////////////////////////////////////////////////////
+// unnamed types page
+///////////////////////////////////////////////////////////////////
+
+func (*Chinese) Text_UnnamedTypes() string {
+	return "无名类型"
+}
+
+func (*Chinese) Text_UnnamedTypesIntroduction() string {
+	return `下列无名类型被用做多个定义类型的底层类型，或者被一些类型别名所表示。`
+}
+
+func (*Chinese) Text_DefinedTypes() string {
+	return "定义类型列表"
+}
+
+func (*Chinese) Text_ViewUnnamedTypes() string {
+	return "查看被多个定义类型和别名共享的无名类型。"
+}
+
 ///////////////////////////////////////////////////////////////////
 // package dependencies page
 ///////////////////////////////////////////////////////////////////
@@ -410,6 +702,40 @@ func (*Chinese) Text_ViewMethodImplementations() string {
 	return "查看实现了哪些接口方法"
 }
 
+func (*Chinese) Text_WhyNotImplement(forInterface bool, inputHTML string) string {
+	if forInterface {
+		return "为什么类型" + inputHTML + "没有实现此接口？"
+	}
+	return "为什么此类型没有实现接口" + inputHTML + "？"
+}
+
+func (*Chinese) Text_ImplementationDiagnosis() string {
+	return "实现诊断"
+}
+
+func (*Chinese) Text_ImplementationVerdict(typeName, interfaceName string, implemented, pointerImplemented bool) string {
+	switch {
+	case implemented:
+		return fmt.Sprintf("%s实现了%s。", typeName, interfaceName)
+	case pointerImplemented:
+		return fmt.Sprintf("*%[1]s实现了%[2]s，但是%[1]s没有。", typeName, interfaceName)
+	default:
+		return fmt.Sprintf("%[1]s和*%[1]s都没有实现%[2]s。", typeName, interfaceName)
+	}
+}
+
+func (*Chinese) Text_MissingMethods() string {
+	return "缺失的方法"
+}
+
+func (*Chinese) Text_MethodsWithDifferentSignatures() string {
+	return "签名不同的方法"
+}
+
+func (*Chinese) Text_PointerReceiverOnlyMethods(typeName string) string {
+	return "只属于*" + typeName + "的方法集的方法"
+}
+
 ///////////////////////////////////////////////////////////////////
 // object references(uses) page
 ///////////////////////////////////////////////////////////////////
@@ -437,6 +763,10 @@ func (*Chinese) Text_ObjectUses(num int) string {
 	return fmt.Sprintf("%d处使用", num)
 }
 
+func (*Chinese) Text_ReferenceCounts(inPackage, inModule, external int) string {
+	return fmt.Sprintf("包内%d处，模块内%d处，外部%d处", inPackage, inModule, external)
+}
+
 ///////////////////////////////////////////////////////////////////
 // source code page
 ///////////////////////////////////////////////////////////////////
@@ -449,6 +779,24 @@ func (*Chinese) Text_SourceFilePath() string { return "源文件：" }
 
 func (*Chinese) Text_GeneratedFrom() string { return "从此文件生成" }
 
+func (*Chinese) Text_PlatformSpecificDeclarations() string { return "平台相关的声明" }
+
+func (*Chinese) Text_CommentShowingModes() string { return "注释" }
+
+func (*Chinese) Text_CommentShowingMode(mode string) string {
+	switch mode {
+	case "all":
+		return "全部显示"
+	case "docs":
+		return "仅显示文档注释"
+	case "collapsed":
+		return "折叠注释块"
+	case "none":
+		return "全部隐藏"
+	}
+	panic("unknown comment showing mode: " + mode)
+}
+
 ///////////////////////////////////////////////////////////////////
 // statistics
 ///////////////////////////////////////////////////////////////////
@@ -506,6 +854,12 @@ func (*Chinese) Text_StatisticsTitle(titleName string) string {
 		return "值（变量/常量/函数）"
 	case "others":
 		return "其它"
+	case "references":
+		return "引用"
+	case "layouts":
+		return "结构体内存布局"
+	case "coverage":
+		return "测试覆盖率"
 	default:
 		panic("unknown statistics tile: " + titleName)
 	}
@@ -684,18 +1038,90 @@ func (*Chinese) Text_Othertatistics(values map[string]interface{}) []string {
 	}
 }
 
+func (*Chinese) Text_ReferenceStatistics(values map[string]interface{}) []string {
+	return []string{
+		fmt.Sprintf(`
+	被引用最多的%d个输出类型名（只统计声明它们的库包之外的引用）：
+
+`,
+			values["typeNameCount"],
+		),
+		fmt.Sprintf(`
+	被引用最多的%d个输出函数和方法：
+
+`,
+			values["functionCount"],
+		),
+		fmt.Sprintf(`
+	被引用最多的%d个输出变量和常量：
+
+`,
+			values["valueCount"],
+		),
+	}
+}
+
+func (*Chinese) Text_LayoutStatistics(values map[string]interface{}) []string {
+	return []string{
+		fmt.Sprintf(`
+	通过调整字段顺序可以节省最多字节的%d个输出结构体类型：
+
+`,
+			values["structCount"],
+		),
+	}
+}
+
+func (*Chinese) Text_CoverageStatistics(values map[string]interface{}) []string {
+	return []string{
+		fmt.Sprintf(`
+	共%d条语句，其中%.1f%%被测试覆盖。
+	覆盖率最低的%d个函数：
+
+`,
+			values["statementCount"],
+			values["coveragePercent"],
+			values["functionCount"],
+		),
+	}
+}
+
+///////////////////////////////////////////////////////////////////
+// doc comments
+///////////////////////////////////////////////////////////////////
+
+func (*Chinese) Text_DocContents() string {
+	return "目录"
+}
+
+func (*Chinese) Text_BrokenDocLink() string {
+	return "无法解析的文档链接"
+}
+
+///////////////////////////////////////////////////////////////////
+// one-page docs
+///////////////////////////////////////////////////////////////////
+
+func (*Chinese) Text_ModuleDocs(modulePath string) string {
+	return fmt.Sprintf("模块：%s", modulePath)
+}
+
+func (*Chinese) Text_ModulePackages(num int) string {
+	return fmt.Sprintf("代码包（%d）", num)
+}
+
 ///////////////////////////////////////////////////////////////////
 // footer
 ///////////////////////////////////////////////////////////////////
 
-func (*Chinese) Text_GeneratedPageFooter(goldsVersion, qrCodeLink, goOS, goArch string) string {
+func (*Chinese) Text_GeneratedPageFooter(goldsVersion, qrCodeLink, goOS, goArch, buildTags string) string {
 	var qrImg, tip string
 	if qrCodeLink != "" {
 		qrImg = fmt.Sprintf(`<img src="%s">`, qrCodeLink)
 		tip = "（扫描左边的二维码）"
 	}
 	return fmt.Sprintf(`<table><tr><td>%s</td>
-<td>本页面由 <a href="https://go101.org/article/tool-golds.html"><b>Golds</b></a> <i>%s</i> 生成。（GOOS=%s GOARCH=%s）。
+<td>本页面由 <a href="https://go101.org/article/tool-golds.html"><b>Golds</b></a> <i>%s</i> 生成。（GOOS=%s GOARCH=%s%s）。
 <b>Golds</b> 是由<a href="https://gfw.tapirgames.com">老貘</a>创建的一个 <a href="https://gfw.go101.org">Go 101</a> 项目。
 欢迎在 <a href="https://github.com/go101/golds">Golds 项目</a>中提交 PR 和 bug 报告。
 请关注 “Go 101” 微信公众号%s以获取 <b>Golds</b> 的最新消息以及各种 Go 细节和事实。</td></tr></table>`,
@@ -703,14 +1129,16 @@ func (*Chinese) Text_GeneratedPageFooter(goldsVersion, qrCodeLink, goOS, goArch
 		goldsVersion,
 		goOS,
 		goArch,
+		footerBuildTags(buildTags),
 		tip,
 	)
 }
 
-func (*Chinese) Text_GeneratedPageFooterSimple(goldsVersion, goOS, goArch string) string {
-	return fmt.Sprintf(`本页面由 <a href="https://go101.org/article/tool-golds.html"><b>Golds</b></a> <i>%s</i> 生成。（GOOS=%s GOARCH=%s）`,
+func (*Chinese) Text_GeneratedPageFooterSimple(goldsVersion, goOS, goArch, buildTags string) string {
+	return fmt.Sprintf(`本页面由 <a href="https://go101.org/article/tool-golds.html"><b>Golds</b></a> <i>%s</i> 生成。（GOOS=%s GOARCH=%s%s）`,
 		goldsVersion,
 		goOS,
 		goArch,
+		footerBuildTags(buildTags),
 	)
 }
@@ -232,6 +232,8 @@ func (*English) Text_SortByItem(by string) string {
 		return "alphabet"
 	case "popularity":
 		return "popularity"
+	case "usage":
+		return "usage"
 	case "importedbys":
 		return "imported-by count"
 	case "depdepth":
@@ -434,6 +436,309 @@ func (*English) Text_AsTypesOf() string {
 	return "As Types Of"
 }
 
+func (*English) Text_Aliases() string {
+	return "Aliases"
+}
+
+func (*English) Text_SameUnderlyingTypes() string {
+	return "Types With The Same Underlying Type"
+}
+
+func (*English) Text_ConvertibleTypes() string {
+	return "Convertible Types (Struct Tags Ignored)"
+}
+
+func (*English) Text_Assignables() string {
+	return "Assignable Values (From Related Packages)"
+}
+
+func (*English) Text_StructLayout() string {
+	return "Memory Layout"
+}
+
+func (*English) Text_StructLayoutStat(size, align, padding int64) string {
+	return fmt.Sprintf("size: %d, align: %d, padding: %d", size, align, padding)
+}
+
+func (*English) Text_StructLayoutHeader() string {
+	return "offset   size  align  field"
+}
+
+func (*English) Text_PaddingBytes(n int) string {
+	if n == 1 {
+		return "1 byte of padding"
+	}
+	return fmt.Sprintf("%d bytes of padding", n)
+}
+
+func (*English) Text_SuggestedFieldOrder(size int64) string {
+	return fmt.Sprintf("Suggested field order (size: %d):", size)
+}
+
+func (*English) Text_ViewStructLayouts() string {
+	return "view layouts of all struct types in this package"
+}
+
+func (*English) Text_PlatformsBadgeTitle() string {
+	return "only available on these platforms"
+}
+
+func (*English) Text_OtherPlatformDeclarations(num int) string {
+	return fmt.Sprintf("Declarations Only Available On Other Platforms (%d)", num)
+}
+
+func (*English) Text_ExcludedFiles(num int) string {
+	return fmt.Sprintf("Files Excluded By Build Constraints (%d)", num)
+}
+
+func (*English) Text_StructLayouts() string {
+	return "Struct Layouts"
+}
+
+func (*English) Text_WastedBytes(n int) string {
+	if n == 1 {
+		return "1 byte wasted"
+	}
+	return fmt.Sprintf("%d bytes wasted", n)
+}
+
+///////////////////////////////////////////////////////////////////
+// versioned docs
+///////////////////////////////////////////////////////////////////
+
+func (*English) Text_DocsVersions() string {
+	return "Docs Versions"
+}
+
+func (*English) Text_DocsVersion() string {
+	return "Version:"
+}
+
+///////////////////////////////////////////////////////////////////
+// analysis problems page
+///////////////////////////////////////////////////////////////////
+
+func (*English) Text_AnalysisProblems() string {
+	return "Analysis Problems"
+}
+
+func (*English) Text_NoAnalysisProblems() string {
+	return "No problems."
+}
+
+func (*English) Text_NumProblems(num int) string {
+	if num == 1 {
+		return "1 problem"
+	}
+	return fmt.Sprintf("%d problems", num)
+}
+
+///////////////////////////////////////////////////////////////////
+// docs check page
+///////////////////////////////////////////////////////////////////
+
+func (*English) Text_DocsCheck() string {
+	return "Docs Check"
+}
+
+func (*English) Text_NoDocsIssues() string {
+	return "No docs issues."
+}
+
+func (*English) Text_DocsCoverage(numDocumenteds, numExporteds int) string {
+	var coverage float64 = 100
+	if numExporteds > 0 {
+		coverage = float64(numDocumenteds) * 100 / float64(numExporteds)
+	}
+	return fmt.Sprintf("docs coverage: %.1f%% (%d/%d)", coverage, numDocumenteds, numExporteds)
+}
+
+///////////////////////////////////////////////////////////////////
+// notes page
+///////////////////////////////////////////////////////////////////
+
+func (*English) Text_AllNotes() string {
+	return "Notes"
+}
+
+func (*English) Text_ViewAllNotes() string {
+	return "view BUG/TODO/FIXME notes of the argument packages"
+}
+
+func (*English) Text_NoNotes() string {
+	return "No notes."
+}
+
+func (*English) Text_Notes(num int) string {
+	return fmt.Sprintf("Notes (%d)", num)
+}
+
+func (*English) Text_NotesFilter(kind string) string {
+	switch kind {
+	case "marker":
+		return "marker"
+	case "author":
+		return "author"
+	case "package":
+		return "package"
+	}
+	panic("unknown notes filter kind: " + kind)
+}
+
+func (*English) Text_NotesFilterAll() string {
+	return "all"
+}
+
+///////////////////////////////////////////////////////////////////
+// function history page
+///////////////////////////////////////////////////////////////////
+
+func (*English) Text_FunctionHistory() string {
+	return "Commit History"
+}
+
+func (*English) Text_LocalCommits() string {
+	return "Local Commits"
+}
+
+func (*English) Text_NoGitCommits() string {
+	return "No local commits found."
+}
+
+func (*English) Text_GitHistory() string {
+	return "history"
+}
+
+func (*English) Text_GitUncommitted() string {
+	return "not committed yet"
+}
+
+func (*English) Text_RecentlyChanged() string {
+	return "Recently Changed"
+}
+
+///////////////////////////////////////////////////////////////////
+// uncommitted changes and API changes page
+///////////////////////////////////////////////////////////////////
+
+func (*English) Text_UncommittedChanges() string {
+	return "Uncommitted Changes"
+}
+
+func (*English) Text_GitFileStatus(status string) string {
+	switch status {
+	case "modified":
+		return "modified"
+	case "added":
+		return "added"
+	case "untracked":
+		return "untracked"
+	case "deleted":
+		return "deleted"
+	}
+	panic("unknown git file status: " + status)
+}
+
+func (*English) Text_NumChangedFiles(num int) string {
+	if num == 1 {
+		return "1 changed file"
+	}
+	return fmt.Sprintf("%d changed files", num)
+}
+
+func (*English) Text_ViewAPIChanges() string {
+	return "view exported API changes since HEAD"
+}
+
+func (*English) Text_APIChanges() string {
+	return "Exported API Changes Since HEAD"
+}
+
+func (*English) Text_NoAPIChanges() string {
+	return "No exported API changes."
+}
+
+func (*English) Text_APIChangeKind(kind string) string {
+	switch kind {
+	case "added":
+		return "added"
+	case "removed":
+		return "removed"
+	case "changed":
+		return "changed"
+	}
+	panic("unknown API change kind: " + kind)
+}
+
+///////////////////////////////////////////////////////////////////
+// test coverage
+///////////////////////////////////////////////////////////////////
+
+func (*English) Text_Coverage() string {
+	return "Test Coverage"
+}
+
+func (*English) Text_CoveragePercent(percent float64) string {
+	return fmt.Sprintf("%.1f%%", percent)
+}
+
+func (*English) Text_CoverageStats(numCovered, numStmts int) string {
+	return fmt.Sprintf("%d of %d statements covered", numCovered, numStmts)
+}
+
+///////////////////////////////////////////////////////////////////
+// hot functions page
+///////////////////////////////////////////////////////////////////
+
+func (*English) Text_HotFunctions() string {
+	return "Hot Functions"
+}
+
+func (*English) Text_ViewHotFunctions() string {
+	return "View the functions costing the most in the profile"
+}
+
+func (*English) Text_NoProfileSamples() string {
+	return "No profile samples are located in the analyzed functions."
+}
+
+func (*English) Text_ProfileSummary(sampleType, total string, numSamples int) string {
+	return fmt.Sprintf("%d samples, %s %s in total.", numSamples, total, sampleType)
+}
+
+func (*English) Text_ProfileCost(flat, cum string) string {
+	return fmt.Sprintf("flat %s, cum %s", flat, cum)
+}
+
+func (*English) Text_CalledBy() string {
+	return "called by"
+}
+
+func (*English) Text_HottestLines() string {
+	return "Hottest Lines"
+}
+
+///////////////////////////////////////////////////////////////////
+// unnamed types page
+///////////////////////////////////////////////////////////////////
+
+func (*English) Text_UnnamedTypes() string {
+	return "Unnamed Types"
+}
+
+func (*English) Text_UnnamedTypesIntroduction() string {
+	return `The following unnamed types are used as the underlying types
+	of several defined types, or are denoted by some type aliases.`
+}
+
+func (*English) Text_DefinedTypes() string {
+	return "Defined Types"
+}
+
+func (*English) Text_ViewUnnamedTypes() string {
+	return "View unnamed types shared by defined types and aliases."
+}
+
 ///////////////////////////////////////////////////////////////////
 // package dependencies page
 ///////////////////////////////////////////////////////////////////
@@ -473,6 +778,40 @@ func (*English) Text_ViewMethodImplementations() string {
 	return "view implemented interface methods"
 }
 
+func (*English) Text_WhyNotImplement(forInterface bool, inputHTML string) string {
+	if forInterface {
+		return "Why doesn't type " + inputHTML + " implement this interface?"
+	}
+	return "Why doesn't this type implement interface " + inputHTML + "?"
+}
+
+func (*English) Text_ImplementationDiagnosis() string {
+	return "Implementation Diagnosis"
+}
+
+func (*English) Text_ImplementationVerdict(typeName, interfaceName string, implemented, pointerImplemented bool) string {
+	switch {
+	case implemented:
+		return fmt.Sprintf("%s implements %s.", typeName, interfaceName)
+	case pointerImplemented:
+		return fmt.Sprintf("*%[1]s implements %[2]s, but %[1]s doesn't.", typeName, interfaceName)
+	default:
+		return fmt.Sprintf("Neither %[1]s nor *%[1]s implements %[2]s.", typeName, interfaceName)
+	}
+}
+
+func (*English) Text_MissingMethods() string {
+	return "Missing Methods"
+}
+
+func (*English) Text_MethodsWithDifferentSignatures() string {
+	return "Methods With Different Signatures"
+}
+
+func (*English) Text_PointerReceiverOnlyMethods(typeName string) string {
+	return "Methods Only in the Method Set of *" + typeName
+}
+
 ///////////////////////////////////////////////////////////////////
 // object reference page
 ///////////////////////////////////////////////////////////////////
@@ -503,6 +842,10 @@ func (*English) Text_ObjectUses(num int) string {
 	return fmt.Sprintf("%d uses", num)
 }
 
+func (*English) Text_ReferenceCounts(inPackage, inModule, external int) string {
+	return fmt.Sprintf("%d in the package, %d in the module, %d external", inPackage, inModule, external)
+}
+
 ///////////////////////////////////////////////////////////////////
 // source code page
 ///////////////////////////////////////////////////////////////////
@@ -515,6 +858,24 @@ func (*English) Text_SourceFilePath() string { return "Source File" }
 
 func (*English) Text_GeneratedFrom() string { return "Generated From" }
 
+func (*English) Text_PlatformSpecificDeclarations() string { return "Platform-Specific Declarations" }
+
+func (*English) Text_CommentShowingModes() string { return "Comments" }
+
+func (*English) Text_CommentShowingMode(mode string) string {
+	switch mode {
+	case "all":
+		return "show all"
+	case "docs":
+		return "doc comments only"
+	case "collapsed":
+		return "collapse blocks"
+	case "none":
+		return "hide all"
+	}
+	panic("unknown comment showing mode: " + mode)
+}
+
 ///////////////////////////////////////////////////////////////////
 // statistics
 ///////////////////////////////////////////////////////////////////
@@ -572,6 +933,12 @@ func (*English) Text_StatisticsTitle(titleName string) string {
 		return "Values"
 	case "others":
 		return "Others"
+	case "references":
+		return "References"
+	case "layouts":
+		return "Struct Layouts"
+	case "coverage":
+		return "Test Coverage"
 	default:
 		panic("unknown statistics tile: " + titleName)
 	}
@@ -753,18 +1120,91 @@ func (*English) Text_Othertatistics(values map[string]interface{}) []string {
 	}
 }
 
+func (*English) Text_ReferenceStatistics(values map[string]interface{}) []string {
+	return []string{
+		fmt.Sprintf(`
+	The %d most referenced exported type names
+	(only uses outside of their declaring packages are counted):
+
+`,
+			values["typeNameCount"],
+		),
+		fmt.Sprintf(`
+	The %d most referenced exported functions and methods:
+
+`,
+			values["functionCount"],
+		),
+		fmt.Sprintf(`
+	The %d most referenced exported variables and constants:
+
+`,
+			values["valueCount"],
+		),
+	}
+}
+
+func (*English) Text_LayoutStatistics(values map[string]interface{}) []string {
+	return []string{
+		fmt.Sprintf(`
+	The %d exported struct types which could save the most bytes by reordering their fields:
+
+`,
+			values["structCount"],
+		),
+	}
+}
+
+func (*English) Text_CoverageStatistics(values map[string]interface{}) []string {
+	return []string{
+		fmt.Sprintf(`
+	%.1f%% of %d statements are covered by tests.
+	The %d least covered functions:
+
+`,
+			values["coveragePercent"],
+			values["statementCount"],
+			values["functionCount"],
+		),
+	}
+}
+
+///////////////////////////////////////////////////////////////////
+// doc comments
+///////////////////////////////////////////////////////////////////
+
+func (*English) Text_DocContents() string {
+	return "Contents"
+}
+
+func (*English) Text_BrokenDocLink() string {
+	return "unresolved doc link"
+}
+
+///////////////////////////////////////////////////////////////////
+// one-page docs
+///////////////////////////////////////////////////////////////////
+
+func (*English) Text_ModuleDocs(modulePath string) string {
+	return fmt.Sprintf("Module: %s", modulePath)
+}
+
+func (*English) Text_ModulePackages(num int) string {
+	return fmt.Sprintf("Packages (%d)", num)
+}
+
 ///////////////////////////////////////////////////////////////////
 // footer
 ///////////////////////////////////////////////////////////////////
 
-func (*English) Text_GeneratedPageFooter(goldsVersion, qrCodeLink, goOS, goArch string) string {
+func (*English) Text_GeneratedPageFooter(goldsVersion, qrCodeLink, goOS, goArch, buildTags string) string {
 	var qrImg, tip string
 	if qrCodeLink != "" {
 		qrImg = fmt.Sprintf(`<img src="%s">`, qrCodeLink)
 		tip = " (reachable from the left QR code)"
 	}
 	return fmt.Sprintf(`<table><tr><td>%s</td>
-<td>The pages are generated with <a href="https://go101.org/apps-and-libs/golds.html"><b>Golds</b></a> <i>%s</i>. (GOOS=%s GOARCH=%s)
+<td>The pages are generated with <a href="https://go101.org/apps-and-libs/golds.html"><b>Golds</b></a> <i>%s</i>. (GOOS=%s GOARCH=%s%s)
 <b>Golds</b> is a <a href="https://go101.org">Go 101</a> project developed by <a href="https://tapirgames.com">Tapir Liu</a>.
 PR and bug reports are welcome and can be submitted to <a href="https://github.com/go101/golds">the issue list</a>.
 Please follow <a href="https://twitter.com/go100and1">@Go100and1</a>%s to get the latest news of <b>Golds</b>.</td></tr></table>`,
@@ -772,14 +1212,24 @@ Please follow <a href="https://twitter.com/go100and1">@Go100and1</a>%s to get th
 		goldsVersion,
 		goOS,
 		goArch,
+		footerBuildTags(buildTags),
 		tip,
 	)
 }
 
-func (*English) Text_GeneratedPageFooterSimple(goldsVersion, goOS, goArch string) string {
-	return fmt.Sprintf(`The pages are generated with <a href="https://go101.org/apps-and-libs/golds.html"><b>Golds</b></a> <i>%s</i>. (GOOS=%s GOARCH=%s)`,
+func (*English) Text_GeneratedPageFooterSimple(goldsVersion, goOS, goArch, buildTags string) string {
+	return fmt.Sprintf(`The pages are generated with <a href="https://go101.org/apps-and-libs/golds.html"><b>Golds</b></a> <i>%s</i>. (GOOS=%s GOARCH=%s%s)`,
 		goldsVersion,
 		goOS,
 		goArch,
+		footerBuildTags(buildTags),
 	)
 }
+
+// footerBuildTags is shared by all translations.
+func footerBuildTags(buildTags string) string {
+	if buildTags == "" {
+		return ""
+	}
+	return " -tags=" + buildTags
+}
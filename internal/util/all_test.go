@@ -0,0 +1,215 @@
+package util
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTOML(t *testing.T) {
+	for _, c := range []struct {
+		src  string
+		want map[string]interface{}
+		err  string // a substring of the error message
+	}{
+		{src: ``, want: map[string]interface{}{}},
+		{src: "# comment\n\n  a = 1 # comment\n", want: map[string]interface{}{"a": int64(1)}},
+		{src: `a = "x\ty\n\"\\\u00e9\U0001F600\b\f\r"`, want: map[string]interface{}{"a": "x\ty\n\"\\\u00e9\U0001F600\b\f\r"}},
+		{src: `a = 'C:\path\n'`, want: map[string]interface{}{"a": `C:\path\n`}},
+		{src: `a = true` + "\n" + `b = false`, want: map[string]interface{}{"a": true, "b": false}},
+		{src: `a = "\a"`, err: `invalid escape sequence \a`},
+		{src: `a = "\x41"`, err: `invalid escape sequence \x`},
+		{src: `a = "\101"`, err: `invalid escape sequence \1`},
+		{src: `a = "\u12"`, err: `incomplete escape sequence \u`},
+		{src: `a = "\uD800"`, err: `invalid escape sequence \uD800`},
+		{src: `a = "abc`, err: "unclosed string"},
+		{src: `a = 'abc` + "\nb = 1", err: "line 1: key a: unclosed string"},
+		{src: `a = """abc"""`, err: "multi-line strings are not supported"},
+
+		{src: `a = 0`, want: map[string]interface{}{"a": int64(0)}},
+		{src: `a = +17`, want: map[string]interface{}{"a": int64(17)}},
+		{src: `a = -1_000`, want: map[string]interface{}{"a": int64(-1000)}},
+		{src: `a = 9223372036854775807`, want: map[string]interface{}{"a": int64(9223372036854775807)}},
+		{src: `a = 9223372036854775808`, err: `invalid value "9223372036854775808"`},
+		{src: `a = 010`, err: `invalid value "010"`},
+		{src: `a = -007`, err: `invalid value "-007"`},
+		{src: `a = 0x1F`, err: `invalid value "0x1F"`},
+		{src: `a = 1__000`, err: `invalid value "1__000"`},
+		{src: `a = _1`, err: `invalid value "_1"`},
+		{src: `a = 1_`, err: `invalid value "1_"`},
+		{src: `a = -`, err: `invalid value "-"`},
+		{src: `a = 3.14`, err: `invalid value "3.14"`},
+		{src: `a = yes`, err: `invalid value "yes"`},
+		{src: `a =`, err: "key a: value is expected"},
+
+		{
+			src: "a = [1, \"x\",\n  'y', # comment\n]\nb = []",
+			want: map[string]interface{}{
+				"a": []interface{}{int64(1), "x", "y"},
+				"b": []interface{}{},
+			},
+		},
+		{
+			src: "a = 1\n[[arr]]\nc = 3\n[[arr]]\nc = 4\n",
+			want: map[string]interface{}{
+				"a":   int64(1),
+				"arr": []interface{}{map[string]interface{}{"c": int64(3)}, map[string]interface{}{"c": int64(4)}},
+			},
+		},
+		{src: "a = 1\na = 2", err: "line 2: key a is duplicated"},
+		{src: "[[a]]\nb = 1\nb = 2", err: "line 3: key b is duplicated"},
+		{src: "a = [1]\n[[a]]", err: "line 2: key a is duplicated"},
+		{src: "a = 1 b = 2", err: "unexpected 'b'"},
+		{src: "[[a]] b = 1", err: "unexpected 'b'"},
+		{src: "[[a]", err: "]] is expected for table a"},
+		{src: "[[]]", err: "invalid key char ']'"},
+		{src: "a = [1 2]", err: ", or ] is expected in array"},
+		{src: "a = [1,", err: "unclosed array"},
+		{src: "a = [[1]]", err: "nested arrays are not supported"},
+		{src: "a 1", err: "= is expected after key a"},
+		{src: "= 1", err: "invalid key char '='"},
+		{src: "a.b = 1", err: "dotted keys are not supported"},
+		{src: `"a b" = 1`, err: `invalid key char '"'`},
+		{src: "[t]\na = 1", err: "standard tables are not supported"},
+		{src: "a = {b = 1}", err: "inline tables are not supported"},
+		{src: "a = 1979-05-27", err: `invalid value "1979-05-27"`},
+	} {
+		got, err := ParseTOML([]byte(c.src))
+		if c.err != "" {
+			if err == nil || !strings.Contains(err.Error(), c.err) {
+				t.Errorf("ParseTOML(%q): error %v, want %q", c.src, err, c.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseTOML(%q): %v", c.src, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("ParseTOML(%q):\n got: %#v\nwant: %#v", c.src, got, c.want)
+		}
+	}
+}
+
+func TestQuoteTOML(t *testing.T) {
+	for _, c := range []struct {
+		s, want string
+	}{
+		{"abc", `"abc"`},
+		{`a"b\c`, `"a\"b\\c"`},
+		{"\t\n\r\b\f", `"\t\n\r\b\f"`},
+		{"\x00\a\v\x7f", `"\u0000\u0007\u000B\u007F"`},
+		{"é世\U0001F600", "\"é世\U0001F600\""},
+	} {
+		got := QuoteTOML(c.s)
+		if got != c.want {
+			t.Errorf("QuoteTOML(%q) = %s, want %s", c.s, got, c.want)
+		}
+		values, err := ParseTOML([]byte("a = " + got))
+		if err != nil {
+			t.Errorf("parse QuoteTOML(%q) error: %s", c.s, err)
+		} else if values["a"] != c.s {
+			t.Errorf("QuoteTOML(%q) is parsed as %q", c.s, values["a"])
+		}
+	}
+}
+
+func TestArchiveWriters(t *testing.T) {
+	files := []struct {
+		name string
+		data [][]byte
+	}{
+		{"index.html", [][]byte{[]byte("<html>"), []byte("</html>")}},
+		{"pkg/a.com/b.html", [][]byte{[]byte(strings.Repeat("golds ", 1000))}},
+		{"empty.txt", nil},
+	}
+	want := make(map[string]string)
+	var names []string
+	for _, f := range files {
+		want[f.name] = string(bytes.Join(f.data, nil))
+		names = append(names, f.name)
+	}
+
+	archive := func(newWriter func(io.Writer) ArchiveWriter) []byte {
+		var buf bytes.Buffer
+		aw := newWriter(&buf)
+		for _, f := range files {
+			if err := aw.WriteFile(f.name, f.data...); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := aw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+	check := func(format string, gotNames []string, got map[string]string) {
+		if !reflect.DeepEqual(gotNames, names) {
+			t.Errorf("%s: files: got %v, want %v", format, gotNames, names)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: file contents are not identical", format)
+		}
+	}
+
+	zipData := archive(NewZipWriter)
+	if !bytes.Equal(zipData, archive(NewZipWriter)) {
+		t.Errorf("zip: the archives of the same files are not identical")
+	}
+	zipReader, err := zip.NewReader(bytes.NewReader(zipData), int64(len(zipData)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var gotNames []string
+	var got = make(map[string]string)
+	for _, f := range zipReader.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		gotNames = append(gotNames, f.Name)
+		got[f.Name] = string(data)
+	}
+	check("zip", gotNames, got)
+
+	tgzData := archive(NewTarGzipWriter)
+	if !bytes.Equal(tgzData, archive(NewTarGzipWriter)) {
+		t.Errorf("tar.gz: the archives of the same files are not identical")
+	}
+	// UncompressTarGzipData reads the first file only.
+	if data, err := UncompressTarGzipData(tgzData); err != nil || string(data) != want[files[0].name] {
+		t.Errorf("UncompressTarGzipData: %q, %v", data, err)
+	}
+	gzipReader, err := gzip.NewReader(bytes.NewReader(tgzData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tarReader := tar.NewReader(gzipReader)
+	gotNames, got = nil, make(map[string]string)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(tarReader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gotNames = append(gotNames, header.Name)
+		got[header.Name] = string(data)
+	}
+	check("tar.gz", gotNames, got)
+}
@@ -0,0 +1,95 @@
+package util
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"time"
+)
+
+// An ArchiveWriter writes files into an archive.
+type ArchiveWriter interface {
+	// WriteFile writes a file with the concatenation of the data slices.
+	// The name should be a slash-separated relative path.
+	WriteFile(name string, data ...[]byte) error
+	Close() error
+}
+
+// All archived files use the same modification time, so that
+// the archives of the same files are always identical.
+var archiveModTime = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// NewZipWriter returns an ArchiveWriter which writes a zip archive into w.
+func NewZipWriter(w io.Writer) ArchiveWriter {
+	return &zipArchiveWriter{zip.NewWriter(w)}
+}
+
+type zipArchiveWriter struct {
+	zipWriter *zip.Writer
+}
+
+func (aw *zipArchiveWriter) WriteFile(name string, data ...[]byte) error {
+	f, err := aw.zipWriter.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: archiveModTime,
+	})
+	if err != nil {
+		return fmt.Errorf("zip create %s error: %s", name, err)
+	}
+	for _, bs := range data {
+		if _, err := f.Write(bs); err != nil {
+			return fmt.Errorf("zip write %s error: %s", name, err)
+		}
+	}
+	return nil
+}
+
+func (aw *zipArchiveWriter) Close() error {
+	return aw.zipWriter.Close()
+}
+
+// NewTarGzipWriter returns an ArchiveWriter which writes
+// a gzip compressed tar archive into w. It is the reverse
+// of UncompressTarGzipData, but supports multiple files.
+func NewTarGzipWriter(w io.Writer) ArchiveWriter {
+	gzipWriter := gzip.NewWriter(w)
+	return &tarGzipArchiveWriter{gzipWriter, tar.NewWriter(gzipWriter)}
+}
+
+type tarGzipArchiveWriter struct {
+	gzipWriter *gzip.Writer
+	tarWriter  *tar.Writer
+}
+
+func (aw *tarGzipArchiveWriter) WriteFile(name string, data ...[]byte) error {
+	var size int64
+	for _, bs := range data {
+		size += int64(len(bs))
+	}
+	err := aw.tarWriter.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Size:     size,
+		Mode:     0644,
+		ModTime:  archiveModTime,
+	})
+	if err != nil {
+		return fmt.Errorf("tar write header of %s error: %s", name, err)
+	}
+	for _, bs := range data {
+		if _, err := aw.tarWriter.Write(bs); err != nil {
+			return fmt.Errorf("tar write %s error: %s", name, err)
+		}
+	}
+	return nil
+}
+
+func (aw *tarGzipArchiveWriter) Close() error {
+	if err := aw.tarWriter.Close(); err != nil {
+		return err
+	}
+	return aw.gzipWriter.Close()
+}
@@ -2,6 +2,8 @@ package util
 
 import (
 	"os"
+	"path/filepath"
+	"strings"
 )
 
 func WorkingDirectory() string {
@@ -11,3 +13,11 @@ func WorkingDirectory() string {
 	}
 	return wd
 }
+
+// IsInDirectory returns whether or not path is dir or in dir.
+func IsInDirectory(path, dir string) bool {
+	if dir == "" || !strings.HasPrefix(path, dir) {
+		return false
+	}
+	return len(path) == len(dir) || path[len(dir)] == filepath.Separator || strings.HasSuffix(dir, string(filepath.Separator))
+}
@@ -0,0 +1,367 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ParseTOML parses a TOML document into a map. Only the subset of TOML
+// used by the config files of Golds is supported:
+//
+//   - comments, and key/value pairs with bare keys;
+//   - single-line basic and literal strings, booleans and decimal integers;
+//   - arrays of these values (nested arrays are not supported);
+//   - arrays of tables, such as [[code-hosts]].
+//
+// Other TOML features, such as dotted and quoted keys, standard tables,
+// inline tables, floats and date-times, are reported as errors.
+//
+// The values in the result are of types string, bool, int64,
+// []interface{} and map[string]interface{} (the elements of arrays
+// of tables).
+func ParseTOML(data []byte) (map[string]interface{}, error) {
+	p := &tomlParser{src: string(data), line: 1}
+	root := make(map[string]interface{})
+	if err := p.parseDocument(root); err != nil {
+		return nil, fmt.Errorf("line %d: %w", p.line, err)
+	}
+	return root, nil
+}
+
+type tomlParser struct {
+	src  string
+	pos  int
+	line int
+}
+
+func (p *tomlParser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *tomlParser) peek() byte {
+	return p.src[p.pos]
+}
+
+// skipSpaces skips spaces and tabs. If multiline is true,
+// newlines and comments are also skipped.
+func (p *tomlParser) skipSpaces(multiline bool) {
+	for !p.eof() {
+		switch p.peek() {
+		case ' ', '\t', '\r':
+		case '\n':
+			if !multiline {
+				return
+			}
+			p.line++
+		case '#':
+			if !multiline {
+				return
+			}
+			for !p.eof() && p.peek() != '\n' {
+				p.pos++
+			}
+			continue
+		default:
+			return
+		}
+		p.pos++
+	}
+}
+
+// endLine makes sure nothing but a comment follows in the current line.
+func (p *tomlParser) endLine() error {
+	p.skipSpaces(false)
+	if !p.eof() && p.peek() == '#' {
+		for !p.eof() && p.peek() != '\n' {
+			p.pos++
+		}
+	}
+	if !p.eof() && p.peek() != '\n' {
+		return fmt.Errorf("unexpected %q", p.peek())
+	}
+	return nil
+}
+
+func (p *tomlParser) parseDocument(root map[string]interface{}) error {
+	current := root
+	arrayTables := make(map[string]bool)
+	for {
+		p.skipSpaces(true)
+		if p.eof() {
+			return nil
+		}
+
+		if p.peek() == '[' {
+			if !strings.HasPrefix(p.src[p.pos:], "[[") {
+				return errors.New("standard tables are not supported, only arrays of tables")
+			}
+			p.pos += 2
+			p.skipSpaces(false)
+			key, err := p.parseKey()
+			if err != nil {
+				return err
+			}
+			p.skipSpaces(false)
+			if !strings.HasPrefix(p.src[p.pos:], "]]") {
+				return fmt.Errorf("]] is expected for table %s", key)
+			}
+			p.pos += 2
+
+			table := make(map[string]interface{})
+			if v, ok := root[key]; !ok {
+				root[key] = []interface{}{table}
+				arrayTables[key] = true
+			} else if arrayTables[key] {
+				root[key] = append(v.([]interface{}), table)
+			} else {
+				return fmt.Errorf("key %s is duplicated", key)
+			}
+			current = table
+		} else if err := p.parseKeyValue(current); err != nil {
+			return err
+		}
+
+		if err := p.endLine(); err != nil {
+			return err
+		}
+	}
+}
+
+func (p *tomlParser) parseKeyValue(table map[string]interface{}) error {
+	key, err := p.parseKey()
+	if err != nil {
+		return err
+	}
+	p.skipSpaces(false)
+	if p.eof() || p.peek() != '=' {
+		return fmt.Errorf("= is expected after key %s", key)
+	}
+	p.pos++
+	p.skipSpaces(false)
+	value, err := p.parseValue(false)
+	if err != nil {
+		return fmt.Errorf("key %s: %w", key, err)
+	}
+	if _, ok := table[key]; ok {
+		return fmt.Errorf("key %s is duplicated", key)
+	}
+	table[key] = value
+	return nil
+}
+
+func isBareTOMLKeyChar(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '_' || c == '-'
+}
+
+func (p *tomlParser) parseKey() (string, error) {
+	if p.eof() {
+		return "", errors.New("key is expected")
+	}
+	start := p.pos
+	for !p.eof() && isBareTOMLKeyChar(p.peek()) {
+		p.pos++
+	}
+	if p.pos == start {
+		return "", fmt.Errorf("invalid key char %q", p.peek())
+	}
+	if !p.eof() && p.peek() == '.' {
+		return "", errors.New("dotted keys are not supported")
+	}
+	return p.src[start:p.pos], nil
+}
+
+func (p *tomlParser) parseString() (string, error) {
+	quote := p.peek()
+	if strings.HasPrefix(p.src[p.pos:], strings.Repeat(string(quote), 3)) {
+		return "", errors.New("multi-line strings are not supported")
+	}
+	start := p.pos
+	for p.pos++; ; p.pos++ {
+		if p.eof() || p.peek() == '\n' {
+			return "", errors.New("unclosed string")
+		}
+		c := p.peek()
+		if c == '\\' && quote == '"' {
+			p.pos++
+			continue
+		}
+		if c == quote {
+			p.pos++
+			break
+		}
+	}
+	if quote == '\'' {
+		return p.src[start+1 : p.pos-1], nil
+	}
+	s, err := unquoteTOMLBasicString(p.src[start+1 : p.pos-1])
+	if err != nil {
+		return "", fmt.Errorf("invalid string %s: %w", p.src[start:p.pos], err)
+	}
+	return s, nil
+}
+
+// unquoteTOMLBasicString interprets the escape sequences in the content
+// of a TOML basic string. Unlike Go, TOML only supports \b, \t, \n, \f,
+// \r, \", \\, \uXXXX and \UXXXXXXXX.
+func unquoteTOMLBasicString(s string) (string, error) {
+	if strings.IndexByte(s, '\\') < 0 {
+		return s, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '\\' {
+			b.WriteByte(c)
+			continue
+		}
+		i++
+		if i == len(s) {
+			return "", errors.New("incomplete escape sequence")
+		}
+		switch c = s[i]; c {
+		case 'b':
+			b.WriteByte('\b')
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'f':
+			b.WriteByte('\f')
+		case 'r':
+			b.WriteByte('\r')
+		case '"', '\\':
+			b.WriteByte(c)
+		case 'u', 'U':
+			n := 4
+			if c == 'U' {
+				n = 8
+			}
+			if i+n >= len(s) {
+				return "", fmt.Errorf("incomplete escape sequence \\%c", c)
+			}
+			r, err := strconv.ParseUint(s[i+1:i+1+n], 16, 32)
+			if err != nil || !utf8.ValidRune(rune(r)) {
+				return "", fmt.Errorf("invalid escape sequence \\%s", s[i:i+1+n])
+			}
+			b.WriteRune(rune(r))
+			i += n
+		default:
+			return "", fmt.Errorf("invalid escape sequence \\%c", c)
+		}
+	}
+	return b.String(), nil
+}
+
+// QuoteTOML returns a TOML basic string representing s.
+func QuoteTOML(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '\b':
+			b.WriteString(`\b`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		default:
+			if r < 0x20 || r == 0x7F {
+				fmt.Fprintf(&b, `\u%04X`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+func (p *tomlParser) parseValue(inArray bool) (interface{}, error) {
+	if p.eof() {
+		return nil, errors.New("value is expected")
+	}
+	switch p.peek() {
+	case '"', '\'':
+		return p.parseString()
+	case '[':
+		if inArray {
+			return nil, errors.New("nested arrays are not supported")
+		}
+		p.pos++
+		var array = []interface{}{}
+		for {
+			p.skipSpaces(true)
+			if p.eof() {
+				return nil, errors.New("unclosed array")
+			}
+			if p.peek() == ']' {
+				p.pos++
+				return array, nil
+			}
+			v, err := p.parseValue(true)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, v)
+			p.skipSpaces(true)
+			if !p.eof() && p.peek() == ',' {
+				p.pos++
+			} else if p.eof() || p.peek() != ']' {
+				return nil, errors.New(", or ] is expected in array")
+			}
+		}
+	case '{':
+		return nil, errors.New("inline tables are not supported")
+	}
+
+	start := p.pos
+	for !p.eof() && !strings.ContainsRune(" \t\r\n,]#", rune(p.peek())) {
+		p.pos++
+	}
+	token := p.src[start:p.pos]
+	switch token {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	}
+	if v, ok := parseTOMLInteger(token); ok {
+		return v, nil
+	}
+	return nil, fmt.Errorf("invalid value %q", token)
+}
+
+// parseTOMLInteger parses a TOML decimal integer. Different from Go,
+// leading zeros are not allowed, so "010" is invalid (instead of an
+// octal integer). An underscore must be surrounded by digits.
+func parseTOMLInteger(token string) (int64, bool) {
+	digits := token
+	if digits != "" && (digits[0] == '+' || digits[0] == '-') {
+		digits = digits[1:]
+	}
+	if digits == "" || len(digits) > 1 && digits[0] == '0' {
+		return 0, false
+	}
+	for i := 0; i < len(digits); i++ {
+		if c := digits[i]; c == '_' {
+			if i == 0 || i == len(digits)-1 || digits[i+1] == '_' {
+				return 0, false
+			}
+		} else if c < '0' || c > '9' {
+			return 0, false
+		}
+	}
+	n, err := strconv.ParseInt(strings.ReplaceAll(token, "_", ""), 10, 64)
+	return n, err == nil
+}